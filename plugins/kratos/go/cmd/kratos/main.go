@@ -18,6 +18,7 @@ func main() {
 	}
 
 	rootCmd.AddCommand(cli.InitCmd())
+	rootCmd.AddCommand(cli.DBCmd())
 	rootCmd.AddCommand(cli.SessionCmd())
 	rootCmd.AddCommand(cli.QueryCmd())
 	rootCmd.AddCommand(cli.RecallCmd())
@@ -26,7 +27,13 @@ func main() {
 	rootCmd.AddCommand(cli.UninstallCmd())
 	rootCmd.AddCommand(cli.StatusCmd())
 	rootCmd.AddCommand(cli.PipelineCmd())
+	rootCmd.AddCommand(cli.FeatureCmd())
+	rootCmd.AddCommand(cli.BoardCmd())
+	rootCmd.AddCommand(cli.StatsCmd())
 	rootCmd.AddCommand(cli.TodoCmd())
+	rootCmd.AddCommand(cli.DecisionCmd())
+	rootCmd.AddCommand(cli.RoutingCmd())
+	rootCmd.AddCommand(cli.GatesCmd())
 	rootCmd.AddCommand(cli.HookCmd())
 
 	if err := rootCmd.Execute(); err != nil {
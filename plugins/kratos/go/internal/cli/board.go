@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/formatter"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// BoardCmd returns the 'board' command, the multi-feature dashboard
+func BoardCmd() *cobra.Command {
+	var format, feature string
+	var staleDays int
+
+	cmd := &cobra.Command{
+		Use:   "board",
+		Short: "Show every feature pipeline in the repository",
+		Long: `Load every .claude/feature/*/status.json in the repository and show
+progress, health and the next action of each feature, most recent first.
+
+A feature is critical when a gate or verdict blocks it, and a warning when its
+documents are stale, missing or orphaned (see 'pipeline check') or it has had
+no activity for --stale-days.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := []string{statusPath(feature)}
+			if feature == "" {
+				var err error
+				paths, err = filepath.Glob(pipeline.Path(gitRoot(), "*"))
+				if err != nil {
+					return fmt.Errorf("failed to list features: %w", err)
+				}
+			}
+
+			staleAfter := time.Duration(staleDays) * 24 * time.Hour
+			now := time.Now()
+
+			entries := []*models.BoardEntry{}
+			for _, path := range paths {
+				status, err := pipeline.Load(path)
+				if err != nil {
+					if feature != "" {
+						return err
+					}
+					fmt.Fprintf(os.Stderr, "warning: skipping %v\n", err)
+					continue
+				}
+				entries = append(entries, pipeline.Summarize(status, filepath.Dir(path), staleAfter, now))
+			}
+
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i].LastActivity > entries[j].LastActivity
+			})
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				result := map[string]interface{}{
+					"features": entries,
+					"count":    len(entries),
+				}
+				return json.NewEncoder(out).Encode(result)
+			case "markdown":
+				fmt.Fprint(out, formatter.FormatBoardMarkdown(entries))
+			case "text":
+				fmt.Fprint(out, formatter.FormatBoard(entries))
+			default:
+				return fmt.Errorf("invalid format: %s (use text, json or markdown)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json, markdown")
+	cmd.Flags().StringVar(&feature, "feature", "", "Show a single feature")
+	cmd.Flags().IntVar(&staleDays, "stale-days", 7, "Days without activity before a feature is flagged stale")
+
+	return cmd
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runBoardCmd executes the 'board' command and returns its output
+func runBoardCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := BoardCmd()
+	cmd.SetArgs(args)
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	return output.String(), err
+}
+
+func TestBoardCmd(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	require.NoError(t, pipelineInit("billing", "Invoices", "P2"))
+	require.NoError(t, pipelineUpdate("billing", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+
+	// An unreadable status.json is skipped
+	broken := filepath.Join(tmpDir, ".claude", "feature", "broken")
+	require.NoError(t, os.MkdirAll(broken, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(broken, "status.json"), []byte("{"), 0o644))
+
+	out, err := runBoardCmd(t, "--format", "json")
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, float64(2), result["count"])
+
+	features := result["features"].([]interface{})
+	names := []string{}
+	for _, f := range features {
+		names = append(names, f.(map[string]interface{})["feature"].(string))
+	}
+	assert.ElementsMatch(t, []string{"auth", "billing"}, names)
+
+	out, err = runBoardCmd(t, "--feature", "billing")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Found 1 feature(s)")
+	assert.Contains(t, out, "billing [P2]")
+
+	out, err = runBoardCmd(t, "--format", "markdown")
+	require.NoError(t, err)
+	assert.Contains(t, out, "| # | Feature |")
+
+	_, err = runBoardCmd(t, "--format", "xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// DBCmd returns the 'db' command group for schema maintenance
+func DBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the Kratos memory database schema",
+		Long: `Inspect and upgrade the schema of the Kratos memory database.
+
+The baseline schema is schema version 1; every numbered migration embedded
+in the binary advances it by one step. Other commands apply pending
+migrations when they open the database.`,
+	}
+
+	cmd.AddCommand(DBMigrateCmd())
+	cmd.AddCommand(DBVersionCmd())
+
+	return cmd
+}
+
+// DBMigrateCmd returns the 'db migrate' command
+func DBMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		Long: `Apply every embedded migration newer than the database's schema version.
+
+Each migration runs in its own transaction. Running this on an up-to-date
+database is a no-op.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := db.OpenConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			from, err := db.SchemaVersion(conn)
+			if err != nil {
+				return err
+			}
+
+			applied, err := db.Migrate(conn)
+			if err != nil {
+				return fmt.Errorf("migration failed: %w", err)
+			}
+
+			to, err := db.SchemaVersion(conn)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"status":       "migrated",
+				"from_version": from,
+				"to_version":   to,
+				"applied":      applied,
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+}
+
+// DBVersionCmd returns the 'db version' command
+func DBVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Show the database schema version",
+		Long: `Show the schema version recorded in the database, the latest version
+known to this binary, and any migrations still pending.
+
+This command never modifies the database.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := db.OpenConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			current, err := db.SchemaVersion(conn)
+			if err != nil {
+				return err
+			}
+
+			latest, err := db.LatestVersion()
+			if err != nil {
+				return err
+			}
+
+			pending, err := db.PendingMigrations(conn)
+			if err != nil {
+				return err
+			}
+
+			applied, err := db.AppliedMigrations(conn)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"version":    current,
+				"latest":     latest,
+				"up_to_date": len(pending) == 0 && current > 0,
+				"pending":    pending,
+				"applied":    applied,
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDBVersionCmd_Uninitialized tests db version on a database that was never initialized
+func TestDBVersionCmd_Uninitialized(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	cmd := DBVersionCmd()
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, float64(0), result["version"])
+	assert.Equal(t, false, result["up_to_date"])
+}
+
+// TestDBMigrateCmd tests that db migrate brings the database to the latest version
+func TestDBMigrateCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	latest, err := db.LatestVersion()
+	require.NoError(t, err)
+
+	cmd := DBMigrateCmd()
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, "migrated", result["status"])
+	assert.Equal(t, float64(0), result["from_version"])
+	assert.Equal(t, float64(latest), result["to_version"])
+
+	// Version reports up to date afterwards
+	versionCmd := DBVersionCmd()
+	var versionOutput bytes.Buffer
+	versionCmd.SetOut(&versionOutput)
+	require.NoError(t, versionCmd.Execute())
+
+	var version map[string]interface{}
+	require.NoError(t, json.Unmarshal(versionOutput.Bytes(), &version))
+	assert.Equal(t, float64(latest), version["version"])
+	assert.Equal(t, true, version["up_to_date"])
+	assert.Empty(t, version["pending"])
+}
+
+// TestCommands_UpgradeVersion1Database tests that commands migrate a database created by an older binary
+func TestCommands_UpgradeVersion1Database(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	// The baseline schema is what a version 1 binary created
+	baseline, err := os.ReadFile(filepath.Join("..", "db", "schema.sql"))
+	require.NoError(t, err)
+	conn, err := db.OpenConnection()
+	require.NoError(t, err)
+	_, err = conn.Exec(string(baseline))
+	require.NoError(t, err)
+	_, err = conn.Exec("INSERT INTO sessions (session_id, project, started_at) VALUES ('old', 'demo', 1)")
+	require.NoError(t, err)
+	version, err := db.SchemaVersion(conn)
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+	require.NoError(t, conn.Close())
+
+	run := func(cmd *cobra.Command, args ...string) {
+		t.Helper()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		require.NoError(t, cmd.Execute())
+	}
+	run(StepRecordAgentCmd(), "old", "ares", "sonnet", "Implement retry", "--stage", "9-implementation", "--input-tokens", "10")
+	run(QuerySearchCmd(), "retry")
+	run(QueryFilesCmd())
+
+	conn, err = db.OpenConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	pending, err := db.PendingMigrations(conn)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// DecisionCmd returns the 'decision' command group
+func DecisionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decision",
+		Short: "Record and query architecture and implementation decisions",
+		Long: `Record the choices agents and users make (question, choice, alternatives,
+rationale, impact) and recall why they were made later.
+
+Decisions are stored per session and feature and are full-text searchable.`,
+	}
+
+	cmd.AddCommand(DecisionRecordCmd())
+	cmd.AddCommand(DecisionListCmd())
+	cmd.AddCommand(DecisionSearchCmd())
+	cmd.AddCommand(DecisionShowCmd())
+
+	return cmd
+}
+
+// DecisionRecordCmd records a decision
+func DecisionRecordCmd() *cobra.Command {
+	var decisionType, feature, agent, rationale, impact string
+	var alternatives []string
+
+	cmd := &cobra.Command{
+		Use:   "record <session_id> <question> <choice>",
+		Short: "Record a decision",
+		Long: `Record a decision made during a session.
+
+If --feature is omitted, the session's feature (if any) is used.
+
+Example:
+  kratos decision record <session_id> "Session storage" "Postgres" \
+    --type architecture --agent apollo \
+    --alternatives Redis --alternatives "JWT only" \
+    --rationale "Already operated in prod" --impact "No new infra"`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sessionID := args[0]
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			session, err := db.GetSession(conn, sessionID)
+			if err != nil {
+				return err
+			}
+
+			decision := &models.Decision{
+				SessionID:    sessionID,
+				FeatureName:  session.FeatureName,
+				DecisionType: decisionType,
+				Question:     args[1],
+				Choice:       args[2],
+				Alternatives: alternatives,
+			}
+			if feature != "" {
+				decision.FeatureName = &feature
+			}
+			if agent != "" {
+				decision.AgentName = &agent
+			}
+			if rationale != "" {
+				decision.Rationale = &rationale
+			}
+			if impact != "" {
+				decision.Impact = &impact
+			}
+
+			if err := db.RecordDecision(conn, decision); err != nil {
+				return fmt.Errorf("failed to record decision: %w", err)
+			}
+
+			result := map[string]interface{}{
+				"status":   "success",
+				"decision": decision,
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&decisionType, "type", "implementation", "Decision type: architecture, implementation, trade_off, direction")
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (defaults to the session's feature)")
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent that made the decision (e.g. themis, apollo)")
+	cmd.Flags().StringArrayVar(&alternatives, "alternatives", nil, "Alternative considered (repeatable)")
+	cmd.Flags().StringVar(&rationale, "rationale", "", "Why this choice was made")
+	cmd.Flags().StringVar(&impact, "impact", "", "Expected impact")
+
+	return cmd
+}
+
+// DecisionListCmd lists decisions
+func DecisionListCmd() *cobra.Command {
+	var filter db.DecisionFilter
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List decisions",
+		Long: `List recorded decisions, newest first.
+
+Filters can be combined; without filters the most recent decisions across
+all projects are returned.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			filter.Project = projectKey(filter.Project)
+			decisions, err := db.ListDecisions(conn, filter)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"decisions": decisions,
+				"count":     len(decisions),
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Filter by project")
+	cmd.Flags().StringVar(&filter.FeatureName, "feature", "", "Filter by feature name")
+	cmd.Flags().StringVar(&filter.SessionID, "session", "", "Filter by session ID")
+	cmd.Flags().StringVar(&filter.DecisionType, "type", "", "Filter by decision type")
+	cmd.Flags().StringVar(&filter.AgentName, "agent", "", "Filter by agent name")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 20, "Maximum number of decisions to return (0 for all)")
+
+	return cmd
+}
+
+// DecisionSearchCmd searches decisions with full-text search
+func DecisionSearchCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "search <term>",
+		Short: "Full-text search across decisions",
+		Long: `Search decision questions, choices, rationales and impacts.
+
+Results are ranked by relevance.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchTerm := args[0]
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			decisions, err := db.SearchDecisions(conn, searchTerm, limit)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"query":     searchTerm,
+				"decisions": decisions,
+				"count":     len(decisions),
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of results")
+
+	return cmd
+}
+
+// DecisionShowCmd shows a single decision
+func DecisionShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a decision",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid ID: %s", args[0])
+			}
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			decision, err := db.GetDecision(conn, id)
+			if err != nil {
+				return err
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(decision)
+		},
+	}
+}
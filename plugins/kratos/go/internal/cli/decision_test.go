@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestSession initializes the DB and starts a session, returning its ID
+func startTestSession(t *testing.T, args ...string) string {
+	t.Helper()
+	require.NoError(t, InitCmd().Execute())
+
+	startCmd := SessionStartCmd()
+	startCmd.SetArgs(args)
+	var startOutput bytes.Buffer
+	startCmd.SetOut(&startOutput)
+	require.NoError(t, startCmd.Execute())
+
+	var startResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(startOutput.Bytes(), &startResult))
+	return startResult["session_id"].(string)
+}
+
+// Test: decision record / show / list / search round trip
+func TestDecisionCmds(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	sessionID := startTestSession(t, "/test/project", "auth-feature")
+
+	// Record
+	recordCmd := DecisionRecordCmd()
+	recordCmd.SetArgs([]string{
+		sessionID, "Session storage", "Postgres",
+		"--type", "architecture",
+		"--agent", "apollo",
+		"--alternatives", "Redis",
+		"--alternatives", "JWT only",
+		"--rationale", "Retry-safe and already operated",
+	})
+	var recordOutput bytes.Buffer
+	recordCmd.SetOut(&recordOutput)
+	require.NoError(t, recordCmd.Execute())
+
+	var recordResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(recordOutput.Bytes(), &recordResult))
+	assert.Equal(t, "success", recordResult["status"])
+	decision := recordResult["decision"].(map[string]interface{})
+	assert.Equal(t, "auth-feature", decision["feature_name"], "feature defaults to the session's feature")
+	id := decision["id"].(float64)
+
+	// Show
+	showCmd := DecisionShowCmd()
+	showCmd.SetArgs([]string{"1"})
+	var showOutput bytes.Buffer
+	showCmd.SetOut(&showOutput)
+	require.NoError(t, showCmd.Execute())
+
+	var shown map[string]interface{}
+	require.NoError(t, json.Unmarshal(showOutput.Bytes(), &shown))
+	assert.Equal(t, id, shown["id"])
+	assert.Equal(t, []interface{}{"Redis", "JWT only"}, shown["alternatives"])
+
+	// List
+	listCmd := DecisionListCmd()
+	listCmd.SetArgs([]string{"--feature", "auth-feature", "--agent", "apollo"})
+	var listOutput bytes.Buffer
+	listCmd.SetOut(&listOutput)
+	require.NoError(t, listCmd.Execute())
+
+	var listResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(listOutput.Bytes(), &listResult))
+	assert.Equal(t, float64(1), listResult["count"])
+
+	// Search
+	searchCmd := DecisionSearchCmd()
+	searchCmd.SetArgs([]string{"retry"})
+	var searchOutput bytes.Buffer
+	searchCmd.SetOut(&searchOutput)
+	require.NoError(t, searchCmd.Execute())
+
+	var searchResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(searchOutput.Bytes(), &searchResult))
+	assert.Equal(t, float64(1), searchResult["count"])
+}
+
+// Test: decision record for an unknown session fails
+func TestDecisionRecordCmd_UnknownSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	require.NoError(t, InitCmd().Execute())
+
+	cmd := DecisionRecordCmd()
+	cmd.SetArgs([]string{"nope", "q", "c"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "session not found")
+}
+
+// Test: decision commands migrate a database that was never initialized
+func TestDecisionCmds_UninitializedDB(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	listCmd := DecisionListCmd()
+	var listOutput bytes.Buffer
+	listCmd.SetOut(&listOutput)
+	require.NoError(t, listCmd.Execute())
+
+	var listResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(listOutput.Bytes(), &listResult))
+	assert.Equal(t, float64(0), listResult["count"])
+}
+
+// Test: decision show with invalid ID
+func TestDecisionShowCmd_InvalidID(t *testing.T) {
+	cmd := DecisionShowCmd()
+	cmd.SetArgs([]string{"abc"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid ID")
+}
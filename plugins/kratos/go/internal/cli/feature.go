@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// FeatureCmd returns the 'feature' command group
+func FeatureCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "feature",
+		Short: "Query feature pipelines across all projects",
+		Long: `Query the features table, which mirrors every feature's status.json.
+
+'pipeline init' and 'pipeline update' keep the mirror current; 'feature sync'
+backfills features created before mirroring existed.`,
+	}
+
+	cmd.AddCommand(FeatureListCmd())
+	cmd.AddCommand(FeatureShowCmd())
+	cmd.AddCommand(FeatureSyncCmd())
+
+	return cmd
+}
+
+// FeatureListCmd lists mirrored features
+func FeatureListCmd() *cobra.Command {
+	var filter db.FeatureFilter
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List features",
+		Long: `List features from every project, most recently updated first.
+
+Use --project to restrict the list to one repository.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			filter.Project = projectKey(filter.Project)
+			features, err := db.ListFeatures(conn, filter)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"features": features,
+				"count":    len(features),
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Filter by project")
+	cmd.Flags().StringVar(&filter.Status, "status", "", "Filter by status: in_progress, completed, abandoned")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 50, "Maximum number of features to return (0 for all)")
+
+	return cmd
+}
+
+// FeatureShowCmd shows a single feature
+func FeatureShowCmd() *cobra.Command {
+	var project string
+
+	cmd := &cobra.Command{
+		Use:   "show <feature>",
+		Short: "Show a feature",
+		Long: `Show the mirrored pipeline state of a feature.
+
+Without --project the most recently updated feature with that name is shown.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			feature, err := db.GetFeature(conn, projectKey(project), args[0])
+			if err != nil {
+				return err
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(feature)
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "", "Project the feature belongs to")
+
+	return cmd
+}
+
+// FeatureSyncCmd mirrors existing status.json files into the database
+func FeatureSyncCmd() *cobra.Command {
+	var feature string
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Mirror status.json files of the current repository",
+		Long: `Read .claude/feature/*/status.json in the current repository and mirror
+each feature and its events.jsonl into the database.
+
+Use --feature to sync a single feature.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := []string{statusPath(feature)}
+			if feature == "" {
+				var err error
+				paths, err = filepath.Glob(pipeline.Path(gitRoot(), "*"))
+				if err != nil {
+					return fmt.Errorf("failed to list features: %w", err)
+				}
+			}
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			features := []*models.Feature{}
+			for _, path := range paths {
+				status, err := pipeline.Load(path)
+				if err != nil {
+					return err
+				}
+				f := featureFromStatus(projectPath(), path, status)
+				if err := db.UpsertFeature(conn, f); err != nil {
+					return err
+				}
+				features = append(features, f)
+
+				events, err := pipeline.ReadEvents(pipeline.EventsPath(path))
+				if err != nil {
+					return err
+				}
+				if err := db.InsertPipelineEvents(conn, eventsFromLog(projectPath(), path, status, events)); err != nil {
+					return err
+				}
+			}
+
+			result := map[string]interface{}{
+				"status":   "synced",
+				"features": features,
+				"count":    len(features),
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (defaults to all features)")
+
+	return cmd
+}
+
+// mirrorFeature copies a feature's status.json state and new events into the database
+// Mirroring is best-effort: status.json stays the source of truth, so a
+// database failure is reported as a warning instead of failing the command
+func mirrorFeature(path string, status *pipeline.Status, events []*pipeline.Event) {
+	conn, err := db.GetConnection()
+	if err == nil {
+		defer conn.Close()
+		err = db.UpsertFeature(conn, featureFromStatus(projectPath(), path, status))
+	}
+	if err == nil && len(events) > 0 {
+		err = db.InsertPipelineEvents(conn, eventsFromLog(projectPath(), path, status, events))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to mirror feature to database: %v\n", err)
+	}
+}
+
+// eventsFromLog converts events.jsonl entries into pipeline_events rows
+func eventsFromLog(project, path string, status *pipeline.Status, events []*pipeline.Event) []*models.PipelineEvent {
+	name := status.Feature
+	if name == "" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+
+	rows := make([]*models.PipelineEvent, 0, len(events))
+	for _, e := range events {
+		rows = append(rows, &models.PipelineEvent{
+			Project:     project,
+			FeatureName: name,
+			Seq:         int64(e.Seq),
+			Revision:    int64(e.Revision),
+			Timestamp:   parseStatusTime(e.Timestamp),
+			EventType:   e.Type,
+			Stage:       optional(e.Stage),
+			FromStatus:  optional(e.From),
+			ToStatus:    optional(e.To),
+			Verdict:     optional(e.Verdict),
+			AgentName:   optional(e.Agent),
+			SessionID:   optional(e.SessionID),
+			Reason:      optional(e.Reason),
+		})
+	}
+	return rows
+}
+
+// featureDocColumns maps the stages that produce a tracked document to the feature field
+var featureDocColumns = map[string]func(*models.Feature) **string{
+	"1-prd":            func(f *models.Feature) **string { return &f.PRDPath },
+	"5-tech-spec":      func(f *models.Feature) **string { return &f.TechSpecPath },
+	"8-test-plan":      func(f *models.Feature) **string { return &f.TestPlanPath },
+	"9-implementation": func(f *models.Feature) **string { return &f.ImplementationNotesPath },
+}
+
+// featureFromStatus converts a parsed status.json into a feature row
+func featureFromStatus(project, path string, status *pipeline.Status) *models.Feature {
+	name := status.Feature
+	if name == "" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+
+	f := &models.Feature{
+		FeatureName:     name,
+		Project:         project,
+		CreatedAt:       parseStatusTime(status.Created),
+		UpdatedAt:       parseStatusTime(status.Updated),
+		Status:          "in_progress",
+		StatusPath:      &path,
+		StagesCompleted: map[int]int64{},
+	}
+	if f.UpdatedAt == 0 {
+		f.UpdatedAt = time.Now().UnixMilli()
+	}
+	if f.CreatedAt == 0 {
+		f.CreatedAt = f.UpdatedAt
+	}
+
+	if status.Description != "" {
+		f.Description = &status.Description
+	}
+	if status.Priority != "" {
+		f.Priority = &status.Priority
+	}
+	if status.CurrentStage != "" {
+		f.CurrentStageName = &status.CurrentStage
+		if n := pipeline.StageNumber(status.CurrentStage); n >= 0 {
+			f.CurrentStage = n
+		}
+	}
+	if status.Finished() {
+		f.Status = "completed"
+	}
+
+	for id, stage := range status.Stages {
+		if stage.Status != pipeline.StatusComplete {
+			continue
+		}
+
+		if n := pipeline.StageNumber(id); n >= 0 && n <= db.MaxFeatureStage {
+			completed := parseStatusTime(stage.Completed)
+			if completed == 0 {
+				completed = f.UpdatedAt
+			}
+			f.StagesCompleted[n] = completed
+		}
+
+		if field, ok := featureDocColumns[id]; ok {
+			if doc := stage.Document(); doc != "" {
+				if !filepath.IsAbs(doc) {
+					doc = filepath.Join(filepath.Dir(path), doc)
+				}
+				*field(f) = &doc
+			}
+		}
+	}
+
+	return f
+}
+
+// parseStatusTime converts an RFC3339 status.json timestamp to Unix epoch ms
+// Returns 0 for missing or malformed values
+func parseStatusTime(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// optional returns a pointer to value, or nil if it is empty
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
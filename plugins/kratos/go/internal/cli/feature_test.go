@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupFeatureTest points the database, project and repository root at temp locations
+func setupFeatureTest(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	t.Setenv("KRATOS_PROJECT", "demo-repo")
+	t.Chdir(tmpDir)
+	return tmpDir
+}
+
+// Test: pipeline init/update mirror the feature and feature list/show read it back
+func TestFeatureMirroredFromPipeline(t *testing.T) {
+	setupFeatureTest(t)
+
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	require.NoError(t, pipelineUpdate("auth", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("auth", "2-prd-review", "in-progress", "", "", "", false, pipeline.NoRevision))
+
+	// Show
+	showCmd := FeatureShowCmd()
+	showCmd.SetArgs([]string{"auth"})
+	var showOutput bytes.Buffer
+	showCmd.SetOut(&showOutput)
+	require.NoError(t, showCmd.Execute())
+
+	var feature map[string]interface{}
+	require.NoError(t, json.Unmarshal(showOutput.Bytes(), &feature))
+	assert.Equal(t, "demo-repo", feature["project"])
+	assert.Equal(t, float64(2), feature["current_stage"])
+	assert.Equal(t, "2-prd-review", feature["current_stage_name"])
+	assert.Equal(t, "P1", feature["priority"])
+	assert.Equal(t, "Login flow", feature["description"])
+	assert.Equal(t, "in_progress", feature["status"])
+	assert.Contains(t, feature["stages_completed"], "1")
+	assert.Contains(t, feature["prd_path"], filepath.Join(".claude", "feature", "auth", "prd.md"))
+
+	// List
+	listCmd := FeatureListCmd()
+	listCmd.SetArgs([]string{"--project", "demo-repo"})
+	var listOutput bytes.Buffer
+	listCmd.SetOut(&listOutput)
+	require.NoError(t, listCmd.Execute())
+
+	var listResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(listOutput.Bytes(), &listResult))
+	assert.Equal(t, float64(1), listResult["count"])
+}
+
+// Test: feature show errors for an unknown feature
+func TestFeatureShowNotFound(t *testing.T) {
+	setupFeatureTest(t)
+	require.NoError(t, InitCmd().Execute())
+
+	showCmd := FeatureShowCmd()
+	showCmd.SetArgs([]string{"missing"})
+	showCmd.SetOut(&bytes.Buffer{})
+	showCmd.SetErr(&bytes.Buffer{})
+	assert.Error(t, showCmd.Execute())
+}
+
+// Test: feature sync backfills status.json files written without mirroring
+func TestFeatureSync(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+
+	for _, name := range []string{"one", "two"} {
+		path := filepath.Join(tmpDir, ".claude", "feature", name, "status.json")
+		status := pipeline.New(name, "", "", now())
+		status.CurrentStage = "5-tech-spec"
+		require.NoError(t, pipeline.Save(path, status))
+	}
+
+	syncCmd := FeatureSyncCmd()
+	var syncOutput bytes.Buffer
+	syncCmd.SetOut(&syncOutput)
+	require.NoError(t, syncCmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(syncOutput.Bytes(), &result))
+	assert.Equal(t, "synced", result["status"])
+	assert.Equal(t, float64(2), result["count"])
+}
+
+// Test: featureFromStatus maps stages, documents and completion
+func TestFeatureFromStatus(t *testing.T) {
+	path := filepath.Join(string(os.PathSeparator), "repo", ".claude", "feature", "auth", "status.json")
+	status, err := pipeline.Parse([]byte(`{
+		"feature": "auth",
+		"created": "2026-01-01T10:00:00Z",
+		"updated": "2026-01-02T10:00:00Z",
+		"current_stage": "11-review",
+		"stages": {
+			"1-prd":       {"status": "complete", "completed": "2026-01-01T12:00:00Z", "documents": ["prd.md"]},
+			"4-discuss":   {"status": "skipped"},
+			"5-tech-spec": {"status": "complete", "documents": ["specs/tech.md"]},
+			"11-review":   {"status": "complete", "completed": "2026-01-02T10:00:00Z"}
+		}
+	}`))
+	require.NoError(t, err)
+
+	f := featureFromStatus("repo", path, status)
+	assert.Equal(t, "auth", f.FeatureName)
+	assert.Equal(t, 11, f.CurrentStage)
+	assert.Equal(t, "completed", f.Status, "all stages complete or skipped")
+	assert.Equal(t, time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC).UnixMilli(), f.CreatedAt)
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC).UnixMilli(), f.StagesCompleted[1])
+	assert.Equal(t, f.UpdatedAt, f.StagesCompleted[5], "missing timestamp falls back to updated")
+	assert.NotContains(t, f.StagesCompleted, 4)
+	require.NotNil(t, f.PRDPath)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "prd.md"), *f.PRDPath)
+	require.NotNil(t, f.TechSpecPath)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "specs", "tech.md"), *f.TechSpecPath)
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/gates"
+	"github.com/spf13/cobra"
+)
+
+// GatesCmd returns the 'gates' command group
+func GatesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gates",
+		Short: "Inspect the SubagentStop deliverable gates",
+		Long: `Inspect the gates an agent's work must pass before the subagent-stop hook
+lets it finish.
+
+A gate can require sections, regexes (or their absence) and a minimum length
+of the agent's final message, and files that must exist with a minimum size.
+File paths are relative to the repository and may be globs; {feature} is the
+folder of the feature the agent works on and {document} the document of its
+running stage. A failed gate blocks the agent at most max_blocks times.
+
+Gates are read from .claude/kratos/gates.json or gates.yaml in the repository
+on top of the built-in gates, each replacing the gate of the same agent.
+
+Example .claude/kratos/gates.yaml:
+
+  max_blocks: 2
+  gates:
+    - agent: artemis
+      files:
+        - path: "{document}"
+          min_length: 1000
+      patterns:
+        - pattern: "\\bedge cases?\\b"
+          message: no edge cases were covered
+    - agent: clio
+      disabled: true`,
+	}
+
+	cmd.AddCommand(GatesCheckCmd())
+	cmd.AddCommand(GatesShowCmd())
+
+	return cmd
+}
+
+// GatesCheckCmd runs an agent's gate against a final message read from stdin
+func GatesCheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check <agent>",
+		Short: "Run an agent's gate against the final message on stdin",
+		Long: `Run an agent's gate against the final message on stdin and the deliverables
+in the repository, without counting a block.
+
+Example:
+  echo "TODO: ... created auth.go ... done" | kratos gates check ares`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			engine, err := gates.Load(gates.ProjectPaths(gitRoot())...)
+			if err != nil {
+				return fmt.Errorf("failed to load gates: %w", err)
+			}
+			message, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("failed to read message: %w", err)
+			}
+
+			result := engine.Check(args[0], agentWork(args[0], string(message)))
+			if result == nil {
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(map[string]any{"agent": args[0], "gate": false, "ok": true})
+			}
+			out := map[string]any{"agent": result.Agent, "gate": true, "ok": result.Passed(), "result": result}
+			if !result.Passed() {
+				out["reason"] = result.Reason()
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+		},
+	}
+}
+
+// GatesShowCmd prints the effective gates config
+func GatesShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective gates and the files they were read from",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := gates.ProjectPaths(gitRoot())
+			cfg, err := gates.LoadConfig(paths...)
+			if err != nil {
+				return fmt.Errorf("failed to load gates: %w", err)
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(struct {
+				Paths []string `json:"paths"`
+				*gates.Config
+			}{paths, cfg})
+		},
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/routing"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +20,17 @@ func debugLog(format string, args ...any) {
 	fmt.Fprintf(os.Stderr, "[kratos-hook] "+format+"\n", args...)
 }
 
+// readHookPayload reads the hook payload on stdin, nil if it cannot be read
+// Handlers treat a missing payload like a malformed one
+func readHookPayload() []byte {
+	raw, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		debugLog("stdin read error: %v", err)
+		return nil
+	}
+	return raw
+}
+
 // hookInput is the JSON Claude Code sends on stdin for UserPromptSubmit
 type hookInput struct {
 	Prompt    string `json:"prompt"`
@@ -35,40 +49,6 @@ type hookSpecificOutput struct {
 	AdditionalContext string `json:"additionalContext"`
 }
 
-// kratosKeywordPattern maps each keyword to a pre-compiled word-boundary regex.
-var kratosKeywordPatterns []keywordPattern
-
-type keywordPattern struct {
-	keyword string
-	re      *regexp.Regexp
-}
-
-func init() {
-	keywords := []string{
-		"kratos",
-		"athena",
-		"ares",
-		"metis",
-		"apollo",
-		"artemis",
-		"hermes",
-		"hephaestus",
-		"daedalus",
-		"clio",
-		"mimir",
-		"hades",
-		"cassandra",
-		"ananke",
-	}
-	kratosKeywordPatterns = make([]keywordPattern, len(keywords))
-	for i, kw := range keywords {
-		kratosKeywordPatterns[i] = keywordPattern{
-			keyword: kw,
-			re:      regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(kw) + `\b`),
-		}
-	}
-}
-
 // Patterns to strip before keyword matching (prevent false positives)
 var stripPatterns = []*regexp.Regexp{
 	regexp.MustCompile("(?s)```.*?```"),                // fenced code blocks
@@ -98,6 +78,8 @@ type subagentStartHookSpecific struct {
 
 // subagentStopInput is the JSON Claude Code sends for SubagentStop
 type subagentStopInput struct {
+	SessionID            string `json:"session_id"`
+	AgentID              string `json:"agent_id"`
 	AgentType            string `json:"agent_type"`
 	StopHookActive       bool   `json:"stop_hook_active"`
 	LastAssistantMessage string `json:"last_assistant_message"`
@@ -142,6 +124,10 @@ func HookCmd() *cobra.Command {
 		Short: "Hook handlers for Claude Code events",
 	}
 
+	cmd.AddCommand(dispatchCmd())
+	cmd.AddCommand(sessionStartCmd())
+	cmd.AddCommand(sessionEndCmd())
+	cmd.AddCommand(toolUseCmd())
 	cmd.AddCommand(promptSubmitCmd())
 	cmd.AddCommand(subagentStartCmd())
 	cmd.AddCommand(subagentStopCmd())
@@ -153,19 +139,24 @@ func promptSubmitCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "prompt-submit",
 		Short: "Handle UserPromptSubmit hook — detect Kratos keywords and inject skill activation",
+		Long: `Handle the UserPromptSubmit hook.
+
+Matches the prompt, without code, URLs, paths and system reminders, against
+the routing rules: Kratos and agent names, their aliases and intent phrases
+such as "review this". Matched rules inject their context and are reported
+to Claude, along with the project state: the active feature, its stage and
+next agent, the last decisions and the open todos, within the configured
+token budget (context_budget). Rules are read from ~/.kratos/routing.json (or $KRATOS_ROUTING)
+and .claude/kratos/routing.json or routing.yaml in the repository, on top of
+the built-in set; see 'kratos routing'.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handlePromptSubmit()
+			return promptSubmitHook(readHookPayload())
 		},
 	}
 }
 
-func handlePromptSubmit() error {
-	raw, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		debugLog("stdin read error: %v", err)
-		return outputPassthrough()
-	}
-
+// promptSubmitHook handles a UserPromptSubmit payload
+func promptSubmitHook(raw []byte) error {
 	var input hookInput
 	if err := json.Unmarshal(raw, &input); err != nil {
 		debugLog("json parse error: %v", err)
@@ -180,17 +171,30 @@ func handlePromptSubmit() error {
 	// Sanitize: strip code blocks, URLs, paths, system reminders
 	cleaned := sanitizePrompt(prompt)
 
-	// Match keywords (case-insensitive, word-boundary)
-	matched := matchKeywords(cleaned)
+	// Rules of the prompt's repository
+	if input.Cwd != "" {
+		if err := os.Chdir(input.Cwd); err != nil {
+			debugLog("failed to enter %s: %v", input.Cwd, err)
+		}
+	}
+
+	// Match routing rules (case-insensitive, word-boundary)
+	router := loadRouter()
+	matched := router.Match(cleaned)
 
 	if len(matched) == 0 {
 		return outputPassthrough()
 	}
 
-	debugLog("matched keywords: %v", matched)
+	for _, m := range matched {
+		debugLog("matched rule %s: %s %q (%s)", m.Rule, m.Kind, m.Matched, m.Source)
+	}
 
-	// Build injection context
+	// Build injection context, with the project state so Kratos need not re-read it
 	context := buildInjectionContext(matched)
+	if memory := memoryContext(input.SessionID, router.ContextBudget(), time.Now()); memory != "" {
+		context += "\n\n" + memory
+	}
 
 	output := hookOutput{
 		Continue: true,
@@ -211,26 +215,33 @@ func sanitizePrompt(prompt string) string {
 	return cleaned
 }
 
-func matchKeywords(text string) []string {
-	var matched []string
-	for _, kp := range kratosKeywordPatterns {
-		if kp.re.MatchString(text) {
-			matched = append(matched, kp.keyword)
-		}
+// loadRouter returns the user's and the repository's routing rules over the
+// built-in set, only the built-in set if a config file is invalid
+func loadRouter() *routing.Router {
+	router, err := routing.Load(routingPaths()...)
+	if err != nil {
+		debugLog("routing config ignored: %v", err)
+		router, _ = routing.Compile(routing.Default())
 	}
-	return matched
+	return router
 }
 
-func buildInjectionContext(matched []string) string {
+func buildInjectionContext(matched []routing.Match) string {
 	// Determine if it's "kratos" itself or a specific god name
 	hasKratos := false
-	var godNames []string
+	var godNames, reports, injects []string
+	seen := map[string]bool{}
 
-	for _, kw := range matched {
-		if kw == "kratos" {
+	for _, m := range matched {
+		if m.Rule == "kratos" {
 			hasKratos = true
 		} else {
-			godNames = append(godNames, kw)
+			godNames = append(godNames, m.Rule)
+		}
+		reports = append(reports, fmt.Sprintf("%s (%s %q)", m.Rule, m.Kind, m.Matched))
+		if m.Inject != "" && !seen[m.Inject] {
+			seen[m.Inject] = true
+			injects = append(injects, m.Inject)
 		}
 	}
 
@@ -246,9 +257,10 @@ func buildInjectionContext(matched []string) string {
 		sb.WriteString(". ")
 	}
 
-	sb.WriteString("\nYou MUST invoke the Kratos skill using the Skill tool:\n")
-	sb.WriteString("Skill(skill: \"kratos:auto\")\n\n")
-	sb.WriteString("Do NOT respond to the user's message directly. Invoke the skill FIRST, then follow its instructions to handle the user's request.")
+	sb.WriteString("\nMatched rule(s): ")
+	sb.WriteString(strings.Join(reports, ", "))
+	sb.WriteString("\n\n")
+	sb.WriteString(strings.Join(injects, "\n\n"))
 
 	return sb.String()
 }
@@ -292,28 +304,27 @@ func subagentStartCmd() *cobra.Command {
 		Use:   "subagent-start",
 		Short: "Handle SubagentStart hook — inject TODO-first quality gate or Hermes tier checklist",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			raw, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				debugLog("subagent-start: stdin read error: %v", err)
-				return outputSubagentStartContext(todoQualityGate)
-			}
-
-			var input subagentStartInput
-			if err := json.Unmarshal(raw, &input); err != nil {
-				debugLog("subagent-start: json parse error: %v", err)
-				return outputSubagentStartContext(todoQualityGate)
-			}
+			return subagentStartHook(readHookPayload())
+		},
+	}
+}
 
-			agentType := strings.ToLower(input.AgentType)
+// subagentStartHook handles a SubagentStart payload
+func subagentStartHook(raw []byte) error {
+	var input subagentStartInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		debugLog("subagent-start: json parse error: %v", err)
+		return outputSubagentStartContext(todoQualityGate)
+	}
 
-			if strings.Contains(agentType, "hermes") {
-				return handleHermesStart(input)
-			}
+	agentType := strings.ToLower(input.AgentType)
 
-			// For all other agents (ares, hephaestus, etc.) — inject TODO quality gate
-			return outputSubagentStartContext(todoQualityGate)
-		},
+	if strings.Contains(agentType, "hermes") {
+		return handleHermesStart(input)
 	}
+
+	// For all other agents (ares, hephaestus, etc.) — inject TODO quality gate
+	return outputSubagentStartContext(todoQualityGate)
 }
 
 // handleHermesStart creates the hermes-checklist.json and injects tier instructions.
@@ -373,53 +384,45 @@ func handleHermesStart(input subagentStartInput) error {
 }
 
 // findActiveFeatureDir scans .claude/feature/*/status.json and returns the feature folder
-// for the first feature where stage 11-review has status pending, in-progress, or ready.
+// whose stage 11-review is active. A review that is in-progress or ready wins over one
+// that is pending, and a pending review wins when the feature's current stage is the
+// review or the PRD alignment right before it. Both status.json layouts are accepted.
 func findActiveFeatureDir(cwd string) (string, error) {
-	pattern := filepath.Join(cwd, ".claude", "feature", "*", "status.json")
-	matches, err := filepath.Glob(pattern)
+	matches, err := filepath.Glob(pipeline.Path(cwd, "*"))
 	if err != nil {
 		return "", err
 	}
 
-	activeStatuses := map[string]bool{
-		"pending":     true,
-		"in-progress": true,
-		"ready":       true,
-	}
-
+	best, bestRank := "", 0
 	for _, statusFile := range matches {
-		data, err := os.ReadFile(statusFile)
+		status, err := pipeline.Load(statusFile)
 		if err != nil {
-			debugLog("findActiveFeatureDir: failed to read %s: %v", statusFile, err)
-			continue
-		}
-
-		var statusJSON map[string]interface{}
-		if err := json.Unmarshal(data, &statusJSON); err != nil {
 			debugLog("findActiveFeatureDir: failed to parse %s: %v", statusFile, err)
 			continue
 		}
 
-		// Navigate: stages["11-review"].status
-		stages, ok := statusJSON["stages"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		reviewStage, ok := stages["11-review"].(map[string]interface{})
+		reviewStage, ok := status.Stages["11-review"]
 		if !ok {
 			continue
 		}
-		status, ok := reviewStage["status"].(string)
-		if !ok {
-			continue
+
+		rank := 0
+		switch strings.ToLower(reviewStage.Status) {
+		case pipeline.StatusInProgress, pipeline.StatusReady:
+			rank = 3
+		case pipeline.StatusPending:
+			rank = 1
+			if status.CurrentStage == "11-review" || status.CurrentStage == "10-prd-alignment" {
+				rank = 2
+			}
 		}
 
-		if activeStatuses[strings.ToLower(status)] {
-			return filepath.Dir(statusFile), nil
+		if rank > bestRank {
+			best, bestRank = filepath.Dir(statusFile), rank
 		}
 	}
 
-	return "", nil
+	return best, nil
 }
 
 // outputSubagentStartContext writes the SubagentStart JSON response to stdout.
@@ -438,94 +441,56 @@ func outputSubagentStartContext(additionalContext string) error {
 	return nil
 }
 
-// subagentStopCmd verifies that Ares and Hephaestus produced complete deliverables.
+// subagentStopCmd verifies that agents produced complete deliverables.
 // Returns {"ok": true} to allow completion or {"ok": false, "reason": "..."} to block.
 func subagentStopCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "subagent-stop",
-		Short: "Handle SubagentStop hook — quality gate for Ares and Hephaestus",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			raw, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				return outputSubagentOK()
-			}
+		Short: "Handle SubagentStop hook — quality gate for every Kratos agent",
+		Long: `Handle the SubagentStop hook.
 
-			var input subagentStopInput
-			if err := json.Unmarshal(raw, &input); err != nil {
-				return outputSubagentOK()
-			}
+Runs the gate of the stopping agent: required sections, regexes and a minimum
+length for its final message, and deliverables that must exist, such as the
+document of its stage in the feature it works on. A failed gate blocks the
+agent with the reasons, at most max_blocks times. Hermes must also complete
+its tier checklist.
 
-			// Prevent infinite loops
-			if input.StopHookActive {
-				return outputSubagentOK()
-			}
+Gates are read from .claude/kratos/gates.json or gates.yaml in the
+repository, on top of the built-in gates; see 'kratos gates'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return subagentStopHook(readHookPayload())
+		},
+	}
+}
 
-			agentType := strings.ToLower(input.AgentType)
-			msg := input.LastAssistantMessage
-			msgLower := strings.ToLower(msg)
-
-			// Ares (implementation agent) quality checks
-			if strings.Contains(agentType, "ares") {
-				var failures []string
-
-				hasTodoList := strings.Contains(msgLower, "todo:") ||
-					strings.Contains(msgLower, "task list:") ||
-					regexp.MustCompile(`(?i)##\s*(tasks|todo|plan)`).MatchString(msg)
-				if !hasTodoList {
-					failures = append(failures, "no TODO list was written before starting work")
-				}
-
-				mentionsFiles := regexp.MustCompile(`(?i)(created|wrote|implemented|modified|updated).*\.(ts|js|py|go|rs|java|cs|rb|md)`).MatchString(msg)
-				if !mentionsFiles {
-					failures = append(failures, "no specific files were mentioned as created or modified")
-				}
-
-				declaresComplete := strings.Contains(msgLower, "complete") ||
-					strings.Contains(msgLower, "done") ||
-					strings.Contains(msgLower, "finished") ||
-					strings.Contains(msgLower, "implemented")
-				if !declaresComplete {
-					failures = append(failures, "implementation completion was not confirmed")
-				}
-
-				if len(failures) > 0 {
-					return outputSubagentBlock(fmt.Sprintf(
-						"Ares quality gate failed: %s. Write a TODO list, implement all items, and confirm which files were created.",
-						strings.Join(failures, "; "),
-					))
-				}
-			}
+// subagentStopHook handles a SubagentStop payload
+func subagentStopHook(raw []byte) error {
+	var input subagentStopInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return outputSubagentOK()
+	}
 
-			// Hephaestus (tech spec agent) quality checks
-			if strings.Contains(agentType, "hephaestus") {
-				specSections := []string{"architecture", "data model", "api", "implementation", "schema", "interface"}
-				var found []string
-				for _, s := range specSections {
-					if strings.Contains(msgLower, s) {
-						found = append(found, s)
-					}
-				}
-				if len(found) < 2 {
-					return outputSubagentBlock(fmt.Sprintf(
-						"Hephaestus quality gate failed: technical spec appears incomplete (only found sections: %s). A complete spec must cover architecture, data models, API design, and implementation details.",
-						func() string {
-							if len(found) == 0 {
-								return "none"
-							}
-							return strings.Join(found, ", ")
-						}(),
-					))
-				}
-			}
+	if input.Cwd != "" {
+		if err := os.Chdir(input.Cwd); err != nil {
+			debugLog("failed to enter %s: %v", input.Cwd, err)
+		}
+	}
 
-			// Hermes (code review agent) tier checklist checks
-			if strings.Contains(agentType, "hermes") {
-				return handleHermesStop(input)
-			}
+	if reason := subagentGate(loadGates(), input, time.Now()); reason != "" {
+		return outputSubagentBlock(reason)
+	}
 
-			return outputSubagentOK()
-		},
+	// Prevent infinite loops
+	if input.StopHookActive {
+		return outputSubagentOK()
+	}
+
+	// Hermes (code review agent) tier checklist checks
+	if strings.Contains(strings.ToLower(input.AgentType), "hermes") {
+		return handleHermesStop(input)
 	}
+
+	return outputSubagentOK()
 }
 
 func outputSubagentOK() error {
@@ -714,53 +679,53 @@ func fixPMCmd() *cobra.Command {
 		Use:   "fix-pm",
 		Short: "Handle PreToolUse Bash hook — auto-correct npm to the project's package manager",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			raw, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				return nil
-			}
-
-			var input preToolUseInput
-			if err := json.Unmarshal(raw, &input); err != nil {
-				return nil
-			}
+			return fixPMHook(readHookPayload())
+		},
+	}
+}
 
-			command := input.ToolInput.Command
+// fixPMHook handles a PreToolUse Bash payload
+func fixPMHook(raw []byte) error {
+	var input preToolUseInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil
+	}
 
-			// Only act if npm is used
-			if !npmWordBoundary.MatchString(command) {
-				return nil
-			}
+	command := input.ToolInput.Command
 
-			// Detect package manager from lockfiles
-			cwd := os.Getenv("CLAUDE_PROJECT_DIR")
-			if cwd == "" {
-				cwd, _ = os.Getwd()
-			}
+	// Only act if npm is used
+	if !npmWordBoundary.MatchString(command) {
+		return nil
+	}
 
-			pm, lockfile := detectPackageManager(cwd)
-			if pm == "" {
-				return nil // no alternative PM found, let npm through
-			}
+	// Detect package manager from lockfiles
+	cwd := os.Getenv("CLAUDE_PROJECT_DIR")
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
 
-			fixed := npmWordBoundary.ReplaceAllString(command, pm)
+	pm, lockfile := detectPackageManager(cwd)
+	if pm == "" {
+		return nil // no alternative PM found, let npm through
+	}
 
-			output := preToolUseOutput{
-				HookSpecificOutput: preToolUseHookSpecific{
-					HookEventName:      "PreToolUse",
-					PermissionDecision: "allow",
-					UpdatedInput:       map[string]string{"command": fixed},
-					AdditionalContext:  fmt.Sprintf("[Kratos] Auto-corrected: npm → %s (detected %s in project root). Use %s for all package operations in this project.", pm, lockfile, pm),
-				},
-			}
+	fixed := npmWordBoundary.ReplaceAllString(command, pm)
 
-			data, err := json.Marshal(output)
-			if err != nil {
-				return nil
-			}
-			fmt.Println(string(data))
-			return nil
+	output := preToolUseOutput{
+		HookSpecificOutput: preToolUseHookSpecific{
+			HookEventName:      "PreToolUse",
+			PermissionDecision: "allow",
+			UpdatedInput:       map[string]string{"command": fixed},
+			AdditionalContext:  fmt.Sprintf("[Kratos] Auto-corrected: npm → %s (detected %s in project root). Use %s for all package operations in this project.", pm, lockfile, pm),
 		},
 	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil
+	}
+	fmt.Println(string(data))
+	return nil
 }
 
 // detectPackageManager checks lockfiles in cwd to determine the package manager.
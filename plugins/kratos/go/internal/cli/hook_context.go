@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/formatter"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+)
+
+// memoryDecisions is the number of latest decisions in the memory context
+const memoryDecisions = 3
+
+// memoryContext describes the project state for a prompt of a Claude Code
+// session in at most budget tokens: the active feature, its stage and next
+// agent, the latest decisions and the open todos. Empty if there is none
+func memoryContext(claudeSessionID string, budget int, now time.Time) string {
+	if budget <= 0 {
+		return ""
+	}
+
+	conn, err := db.GetConnection()
+	if err != nil {
+		debugLog("memory context: %v", err)
+		return ""
+	}
+	defer conn.Close()
+
+	entry := activeFeature(conn, claudeSessionID, now)
+
+	decisions, err := db.ListDecisions(conn, db.DecisionFilter{Project: projectPath(), Limit: memoryDecisions})
+	if err != nil {
+		debugLog("memory context: %v", err)
+	}
+
+	var todos []string
+	open, err := db.ListTodos(conn, getProject(), "open", "all")
+	if err != nil {
+		debugLog("memory context: %v", err)
+	}
+	for _, todo := range open {
+		todos = append(todos, fmt.Sprintf("#%d %s", todo.ID, todo.Text))
+	}
+
+	return formatter.FormatMemoryContext(entry, decisions, todos, budget)
+}
+
+// activeFeature summarizes the unfinished feature of the Claude Code session's
+// memory session, else the latest unfinished feature of the repository. nil if none
+func activeFeature(conn *sql.DB, claudeSessionID string, now time.Time) *models.BoardEntry {
+	root := gitRoot()
+
+	var status *pipeline.Status
+	session, err := currentSession(conn, claudeSessionID)
+	if err != nil {
+		debugLog("memory context: %v", err)
+	}
+	if session != nil && session.FeatureName != nil {
+		s, err := pipeline.Load(pipeline.Path(root, *session.FeatureName))
+		if err == nil && !s.Finished() && s.PipelineStatus != pipeline.StatusComplete {
+			status = s
+			if status.Feature == "" {
+				status.Feature = *session.FeatureName
+			}
+		}
+	}
+	if status == nil {
+		status = latestFeature(root)
+	}
+	if status == nil {
+		return nil
+	}
+	return pipeline.Summarize(status, filepath.Dir(pipeline.Path(root, status.Feature)), 7*24*time.Hour, now)
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryContext(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	t.Setenv("CLAUDE_ENV_FILE", "")
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	require.NoError(t, pipelineInit("billing", "Invoices", "P2"))
+	_, err := handleSessionStart(sessionStartInput{SessionID: "claude-1", Cwd: tmpDir, Source: "startup"}, time.Now())
+	require.NoError(t, err)
+	session := claudeSession(t, "claude-1")
+
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	for i, choice := range []string{"Postgres", "JWT", "bcrypt", "Redis"} {
+		require.NoError(t, db.RecordDecision(conn, &models.Decision{
+			SessionID: session.SessionID,
+			Timestamp: int64(i + 1),
+			Question:  "Choice " + choice,
+			Choice:    choice,
+		}))
+	}
+	_, err = db.AddTodo(conn, "Rotate keys", "demo-repo", "user", nil)
+	require.NoError(t, err)
+	_, err = db.AddTodo(conn, "Other repo", "other-repo", "user", nil)
+	require.NoError(t, err)
+
+	// The session's feature wins over the latest one
+	_, err = db.SetSessionFeature(conn, session.SessionID, "auth")
+	require.NoError(t, err)
+
+	memory := memoryContext("claude-1", 1000, time.Now())
+	assert.Contains(t, memory, "Feature: auth — Login flow")
+	assert.Contains(t, memory, "Next: ")
+	assert.Contains(t, memory, "Choice Redis: Redis")
+	assert.Contains(t, memory, "Choice JWT: JWT")
+	assert.NotContains(t, memory, "Postgres")
+	assert.Contains(t, memory, "Open todos (1):\n    - #1 Rotate keys")
+	assert.NotContains(t, memory, "Other repo")
+
+	// A small budget keeps the feature and drops the rest
+	memory = memoryContext("claude-1", 40, time.Now())
+	assert.Contains(t, memory, "Feature: auth")
+	assert.NotContains(t, memory, "Open todos")
+
+	assert.Empty(t, memoryContext("claude-1", 0, time.Now()))
+}
+
+func TestPromptSubmitMemoryContext(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	userRouting := filepath.Join(tmpDir, "routing.json")
+	t.Setenv("KRATOS_ROUTING", userRouting)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+
+	result := runJSON(t, RoutingCheckCmd(), "Kratos, continue")
+	assert.Contains(t, result["memory"], "Feature: auth — Login flow")
+
+	// A negative budget turns the project state off
+	require.NoError(t, os.WriteFile(userRouting, []byte(`{"context_budget": -1}`), 0644))
+	result = runJSON(t, RoutingCheckCmd(), "Kratos, continue")
+	assert.Empty(t, result["memory"])
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+)
+
+// hookHandlers routes each Claude Code hook event to the handler of its payload
+var hookHandlers = map[string]func(raw []byte) error{
+	"SessionStart":     sessionStartHook,
+	"SessionEnd":       sessionEndHook,
+	"UserPromptSubmit": promptSubmitHook,
+	"PreToolUse":       fixPMHook,
+	"PostToolUse":      toolUseHook,
+	"SubagentStart":    subagentStartHook,
+	"SubagentStop":     subagentStopHook,
+}
+
+// dispatchCmd routes any hook event to its handler in a single process
+func dispatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "dispatch",
+		Short: "Handle any hook event — route the payload by hook_event_name",
+		Long: `Handle any Claude Code hook event.
+
+Reads the payload once and runs the handler of its hook_event_name, the same
+as the event's own subcommand:
+
+  SessionStart      session-start
+  SessionEnd        session-end
+  UserPromptSubmit  prompt-submit
+  PreToolUse        fix-pm
+  PostToolUse       tool-use
+  SubagentStart     subagent-start
+  SubagentStop      subagent-stop
+
+Other events are ignored. Registering every hook as 'kratos hook dispatch'
+keeps hooks.json independent of the handler names.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dispatchHook(readHookPayload())
+		},
+	}
+}
+
+// dispatchHook runs the handler of the payload's hook event
+func dispatchHook(raw []byte) error {
+	var event struct {
+		HookEventName string `json:"hook_event_name"`
+	}
+	if err := json.Unmarshal(raw, &event); err != nil {
+		debugLog("dispatch: json parse error: %v", err)
+		return nil
+	}
+
+	handler, ok := hookHandlers[event.HookEventName]
+	if !ok {
+		debugLog("dispatch: no handler for %q", event.HookEventName)
+		return nil
+	}
+	return handler(raw)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatchHook(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	t.Setenv("CLAUDE_ENV_FILE", "")
+
+	payloads := []string{
+		`{"hook_event_name":"SessionStart","session_id":"claude-1","cwd":"` + tmpDir + `","source":"startup"}`,
+		`{"hook_event_name":"PostToolUse","session_id":"claude-1","tool_name":"Task","tool_input":{"subagent_type":"kratos:ares","description":"Implement login","model":"sonnet"}}`,
+		`{"hook_event_name":"Notification","session_id":"claude-1","message":"idle"}`,
+		`not json`,
+	}
+	for _, payload := range payloads {
+		require.NoError(t, dispatchHook([]byte(payload)))
+	}
+
+	session := claudeSession(t, "claude-1")
+	assert.Equal(t, "active", session.Status)
+	assert.Equal(t, int64(1), session.TotalAgentsSpawned)
+
+	require.NoError(t, dispatchHook([]byte(`{"hook_event_name":"SessionEnd","session_id":"claude-1","reason":"logout"}`)))
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	session, err = db.GetSession(conn, session.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", session.Status)
+}
+
+func TestDispatchHook_ConcurrentSessions(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	t.Setenv("CLAUDE_ENV_FILE", "")
+
+	// Two windows on the same project: the second was open before the plugin
+	// was enabled, so its session starts with its first tool call
+	payloads := []string{
+		`{"hook_event_name":"SessionStart","session_id":"claude-1","cwd":"` + tmpDir + `","source":"startup"}`,
+		`{"hook_event_name":"PostToolUse","session_id":"claude-2","tool_name":"Edit","tool_input":{"file_path":"b.go"}}`,
+		`{"hook_event_name":"PostToolUse","session_id":"claude-1","tool_name":"Task","tool_input":{"subagent_type":"kratos:ares","description":"Implement login","model":"sonnet"}}`,
+		`{"hook_event_name":"PostToolUse","session_id":"claude-2","tool_name":"Task","tool_input":{"subagent_type":"kratos:hermes","description":"Review","model":"opus"}}`,
+	}
+	for _, payload := range payloads {
+		require.NoError(t, dispatchHook([]byte(payload)))
+	}
+	first := claudeSession(t, "claude-1")
+	second := claudeSession(t, "claude-2")
+	assert.NotEqual(t, first.SessionID, second.SessionID)
+	assert.Equal(t, first.Project, second.Project)
+
+	// Agents record by Claude Code session ID into their own window's session
+	runJSON(t, StepRecordAgentCmd(), "claude-2", "hermes", "opus", "Second pass")
+	runJSON(t, StepRecordAgentCmd(), first.SessionID, "ares", "sonnet", "Fix tests")
+
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	agents := func(sessionID string) []string {
+		steps, err := db.GetStepsForSession(conn, sessionID)
+		require.NoError(t, err)
+		names := []string{}
+		for _, step := range steps {
+			if step.AgentName != nil {
+				names = append(names, *step.AgentName)
+			}
+		}
+		return names
+	}
+	assert.Equal(t, []string{"ares", "ares"}, agents(first.SessionID))
+	assert.Equal(t, []string{"hermes", "hermes"}, agents(second.SessionID))
+
+	// Ending one window leaves the other running, and its later events are dropped
+	require.NoError(t, dispatchHook([]byte(`{"hook_event_name":"SessionEnd","session_id":"claude-2"}`)))
+	require.NoError(t, dispatchHook([]byte(payloads[3])))
+	assert.Equal(t, "active", claudeSession(t, "claude-1").Status)
+	assert.Equal(t, "completed", claudeSession(t, "claude-2").Status)
+	assert.Equal(t, []string{"hermes", "hermes"}, agents(second.SessionID))
+	assert.Equal(t, []string{"ares", "ares"}, agents(first.SessionID))
+}
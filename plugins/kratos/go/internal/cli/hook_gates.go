@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/gates"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+)
+
+// gateBlockTTL is how long a subagent's block count is kept
+const gateBlockTTL = 24 * time.Hour
+
+// gateBlock counts how often a gate blocked one subagent
+type gateBlock struct {
+	Count   int   `json:"count"`
+	Updated int64 `json:"updated"` // Unix epoch ms
+}
+
+// loadGates returns the repository's gates over the built-in set, only the
+// built-in set if a gates file is invalid
+func loadGates() *gates.Engine {
+	engine, err := gates.Load(gates.ProjectPaths(gitRoot())...)
+	if err != nil {
+		debugLog("gates config ignored: %v", err)
+		engine, _ = gates.Compile(gates.Default())
+	}
+	return engine
+}
+
+// subagentGate runs the gate of a stopping subagent and returns why it must
+// keep working, empty if it may stop. A gate blocks the same subagent at most
+// max_blocks times
+func subagentGate(engine *gates.Engine, input subagentStopInput, now time.Time) string {
+	result := engine.Check(input.AgentType, agentWork(input.AgentType, input.LastAssistantMessage))
+	if result == nil {
+		return ""
+	}
+
+	key := gateBlockKey(input)
+	if result.Passed() {
+		updateGateBlocks(now, func(blocks map[string]*gateBlock) { delete(blocks, key) })
+		return ""
+	}
+	// Claude Code marks a subagent already continuing because of a block
+	if input.StopHookActive && result.MaxBlocks <= 1 {
+		return ""
+	}
+
+	count := 0
+	updateGateBlocks(now, func(blocks map[string]*gateBlock) {
+		block := blocks[key]
+		if block == nil {
+			block = &gateBlock{}
+			blocks[key] = block
+		}
+		if block.Count < result.MaxBlocks {
+			block.Count++
+			block.Updated = now.UnixMilli()
+			count = block.Count
+		}
+	})
+	if count == 0 {
+		debugLog("subagent-stop: %s gate blocked %d times, allowing stop: %s", result.Agent, result.MaxBlocks, result.Reason())
+		return ""
+	}
+
+	reason := result.Reason()
+	if result.MaxBlocks > 1 {
+		reason += fmt.Sprintf(" (attempt %d/%d)", count, result.MaxBlocks)
+	}
+	return reason
+}
+
+// agentWork collects what a stopping subagent produced: its message and, for
+// the feature it works on, the feature folder and the document of its running stage
+func agentWork(agentType, message string) gates.Work {
+	root := gitRoot()
+	work := gates.Work{Message: message, Root: root}
+
+	agent := agentType
+	if i := strings.LastIndex(agent, ":"); i >= 0 {
+		agent = agent[i+1:]
+	}
+	status := findAgentFeature(root, agent)
+	if status == nil {
+		return work
+	}
+
+	work.FeatureDir = filepath.Dir(pipeline.Path(root, status.Feature))
+	stage := status.Stages[status.AgentStage(agent)]
+	if stage != nil && strings.EqualFold(stage.Agent, agent) && stage.Document() != "" &&
+		(stage.Status == pipeline.StatusInProgress || stage.Status == pipeline.StatusReady) {
+		work.Document = filepath.Join(work.FeatureDir, stage.Document())
+	}
+	return work
+}
+
+// gateBlockKey identifies a subagent across its stop attempts
+func gateBlockKey(input subagentStopInput) string {
+	if input.AgentID != "" {
+		return input.AgentID
+	}
+	return input.SessionID + "/" + strings.ToLower(input.AgentType)
+}
+
+// updateGateBlocks applies fn to the block counts in ~/.kratos/gate-blocks.json,
+// dropping counts older than gateBlockTTL. The file is locked like status.json,
+// so concurrent subagents do not lose each other's counts; fn is not applied
+// when the lock cannot be taken
+func updateGateBlocks(now time.Time, fn func(blocks map[string]*gateBlock)) {
+	path := filepath.Join(kratosHome(), "gate-blocks.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		debugLog("subagent-stop: failed to create %s: %v", filepath.Dir(path), err)
+		return
+	}
+	unlock, err := pipeline.Lock(path)
+	if err != nil {
+		debugLog("subagent-stop: %v", err)
+		return
+	}
+	defer unlock()
+
+	blocks := map[string]*gateBlock{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &blocks); err != nil {
+			debugLog("subagent-stop: resetting %s: %v", path, err)
+			blocks = map[string]*gateBlock{}
+		}
+	}
+
+	for key, block := range blocks {
+		if now.Sub(time.UnixMilli(block.Updated)) > gateBlockTTL {
+			delete(blocks, key)
+		}
+	}
+	fn(blocks)
+
+	data, err := json.MarshalIndent(blocks, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		debugLog("subagent-stop: failed to write %s: %v", path, err)
+	}
+}
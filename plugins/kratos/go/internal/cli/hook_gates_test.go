@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubagentGate(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	now := time.Now()
+
+	dir := filepath.Join(tmpDir, ".claude", "kratos")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gates.json"), []byte(`{"gates": [
+		{"agent": "athena", "files": [{"path": "{document}", "min_length": 300}]}
+	]}`), 0644))
+
+	// Athena works on 1-prd, so a {document} check requires prd.md
+	athena := subagentStopInput{AgentID: "agent-1", AgentType: "kratos:athena", LastAssistantMessage: "PRD written"}
+	reason := subagentGate(loadGates(), athena, now)
+	assert.Contains(t, reason, "Athena quality gate failed: .claude/feature/auth/prd.md was not written.")
+
+	// Blocked once already, the agent may stop
+	athena.StopHookActive = true
+	assert.Empty(t, subagentGate(loadGates(), athena, now))
+
+	prd := filepath.Join(tmpDir, ".claude", "feature", "auth", "prd.md")
+	require.NoError(t, os.WriteFile(prd, []byte(strings.Repeat("requirement\n", 30)), 0644))
+	athena.StopHookActive = false
+	assert.Empty(t, subagentGate(loadGates(), athena, now))
+
+	// Project gates may block more than once
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gates.yaml"), []byte(`
+gates:
+  - agent: artemis
+    max_blocks: 2
+    patterns:
+      - pattern: "\\bedge cases?\\b"
+        message: no edge cases were covered
+`), 0644))
+
+	artemis := subagentStopInput{AgentID: "agent-2", AgentType: "kratos:artemis", LastAssistantMessage: "Test plan done", StopHookActive: true}
+	engine := loadGates()
+	assert.Equal(t, "Artemis quality gate failed: no edge cases were covered. (attempt 1/2)", subagentGate(engine, artemis, now))
+	assert.Contains(t, subagentGate(engine, artemis, now), "(attempt 2/2)")
+	assert.Empty(t, subagentGate(engine, artemis, now))
+
+	// Another subagent has its own count
+	artemis.AgentID = "agent-3"
+	assert.Contains(t, subagentGate(engine, artemis, now), "(attempt 1/2)")
+	// Counts expire
+	artemis.AgentID = "agent-2"
+	assert.Contains(t, subagentGate(engine, artemis, now.Add(2*gateBlockTTL)), "(attempt 1/2)")
+
+	// Agents without a gate always stop
+	assert.Empty(t, subagentGate(engine, subagentStopInput{AgentType: "general-purpose"}, now))
+}
+
+func TestUpdateGateBlocksConcurrent(t *testing.T) {
+	setupFeatureTest(t)
+	now := time.Now()
+
+	// Every writer's count survives the others' read-modify-write
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			updateGateBlocks(now, func(blocks map[string]*gateBlock) {
+				blocks[key] = &gateBlock{Count: 1, Updated: now.UnixMilli()}
+			})
+		}(fmt.Sprintf("agent-%d", i))
+	}
+	wg.Wait()
+
+	var blocks map[string]*gateBlock
+	updateGateBlocks(now, func(b map[string]*gateBlock) { blocks = b })
+	assert.Len(t, blocks, 20)
+}
+
+func TestGatesCmds(t *testing.T) {
+	setupFeatureTest(t)
+
+	check := GatesCheckCmd()
+	check.SetIn(strings.NewReader("## Architecture\n...\n## Data model\n..."))
+	result := runJSON(t, check, "kratos:hephaestus")
+	assert.Equal(t, true, result["ok"])
+
+	check = GatesCheckCmd()
+	check.SetIn(strings.NewReader("done"))
+	result = runJSON(t, check, "ares")
+	assert.Equal(t, false, result["ok"])
+	assert.Contains(t, result["reason"], "Ares quality gate failed")
+
+	result = runJSON(t, GatesShowCmd())
+	assert.Len(t, result["gates"], 2)
+	assert.Equal(t, float64(1), result["max_blocks"])
+}
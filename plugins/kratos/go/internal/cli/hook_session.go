@@ -0,0 +1,485 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/formatter"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// sessionReuseWindow is how long a project's active session is resumed by a
+// SessionStart without a Claude Code session ID (a manual run)
+const sessionReuseWindow = time.Hour
+
+// bannerActions is the number of last-session actions shown in the resume banner
+const bannerActions = 3
+
+// sessionStartInput is the JSON Claude Code sends for SessionStart
+type sessionStartInput struct {
+	SessionID string `json:"session_id"`
+	Cwd       string `json:"cwd"`
+	Source    string `json:"source"` // startup, resume, clear or compact
+}
+
+// sessionEndInput is the JSON Claude Code sends for SessionEnd
+type sessionEndInput struct {
+	SessionID string `json:"session_id"`
+	Cwd       string `json:"cwd"`
+	Reason    string `json:"reason"` // clear, logout, prompt_input_exit or other
+}
+
+// kratosHome returns the directory holding the memory database and the installed
+// binary (~/.kratos unless KRATOS_MEMORY_DB moves it)
+func kratosHome() string {
+	return filepath.Dir(db.GetDBPath())
+}
+
+// sessionStartCmd starts or resumes the memory session and injects the resume banner
+func sessionStartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "session-start",
+		Short: "Handle SessionStart hook — start the memory session and inject the resume banner",
+		Long: `Handle the SessionStart hook.
+
+Each Claude Code session (the session_id of the hook payload) gets its own
+memory session, so windows open on different repositories never share one.
+The memory session of a Claude Code session that is resumed, cleared or
+compacted is reused; a new Claude Code session ends the project's previous
+active session first. The session ID is exported as KRATOS_SESSION_ID
+through CLAUDE_ENV_FILE so kratos commands run by agents are attributed to
+it. When the project's last session worked on an unfinished feature, a
+banner with its stage, last actions and next step is injected as additional
+context.
+
+Without a session_id (a manual run) the project's active session is reused
+when it started within the last hour, otherwise it is ended and replaced.
+
+The running binary is also installed to ~/.kratos/bin so agents use one
+fixed path (exported as KRATOS_BIN through CLAUDE_ENV_FILE).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sessionStartHook(readHookPayload())
+		},
+	}
+}
+
+// sessionStartHook handles a SessionStart payload
+func sessionStartHook(raw []byte) error {
+	var input sessionStartInput
+	if len(strings.TrimSpace(string(raw))) > 0 {
+		if err := json.Unmarshal(raw, &input); err != nil {
+			debugLog("session-start: json parse error: %v", err)
+			return outputPassthrough()
+		}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		if err := installBinary(exe, filepath.Join(kratosHome(), "bin")); err != nil {
+			debugLog("session-start: failed to install binary: %v", err)
+		}
+	}
+
+	context, err := handleSessionStart(input, time.Now())
+	if err != nil {
+		debugLog("session-start: %v", err)
+		return outputPassthrough()
+	}
+
+	return outputJSON(hookOutput{
+		Continue: true,
+		HookSpecificOutput: &hookSpecificOutput{
+			HookEventName:     "SessionStart",
+			AdditionalContext: context,
+		},
+	})
+}
+
+// handleSessionStart resumes or creates the memory session and returns the context to inject
+func handleSessionStart(input sessionStartInput, now time.Time) (string, error) {
+	if input.Cwd != "" {
+		if err := os.Chdir(input.Cwd); err != nil {
+			return "", fmt.Errorf("failed to enter %s: %w", input.Cwd, err)
+		}
+	}
+	project := projectPath()
+
+	conn, err := db.GetConnection()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var active *models.Session
+	if input.SessionID != "" {
+		active, err = currentSession(conn, input.SessionID)
+	} else {
+		active, err = db.GetActiveSession(conn, project)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check active session: %w", err)
+	}
+	if active != nil {
+		continuing := input.SessionID != "" || (input.Source != "" && input.Source != "startup")
+		if continuing || now.Sub(time.UnixMilli(active.StartedAt)) < sessionReuseWindow {
+			exportSessionID(active.SessionID)
+			return fmt.Sprintf("Kratos: Resuming session %s\n", active.SessionID), nil
+		}
+	}
+
+	// The previous launch never reached session-end; close it so a new one can start
+	previous, err := db.GetActiveSession(conn, project)
+	if err != nil {
+		return "", fmt.Errorf("failed to check active session: %w", err)
+	}
+	if previous != nil {
+		if err := db.EndSession(conn, previous.SessionID, "Ended by a new session (no session-end recorded)"); err != nil {
+			return "", err
+		}
+	}
+
+	// Read the last session before the new one replaces it
+	last, err := db.GetLastSessionForProject(conn, project)
+	if err != nil {
+		return "", err
+	}
+
+	session, err := createHookSession(conn, project, input.SessionID, now)
+	if err != nil {
+		return "", err
+	}
+	exportSessionID(session.SessionID)
+
+	context := fmt.Sprintf("Kratos: Memory session started - %s\n", session.SessionID)
+	if banner := resumeBanner(conn, last, now); banner != "" {
+		context += "\n" + banner
+	}
+	return context, nil
+}
+
+// sessionEndCmd ends the memory session with a summary of its activity
+func sessionEndCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "session-end",
+		Short: "Handle SessionEnd hook — end the memory session with an activity summary",
+		Long: `Handle the SessionEnd hook.
+
+Ends the memory session of the Claude Code session (without a session_id,
+the project's active session) with a summary built from the database:
+agents spawned, pipeline stages completed, files changed, decisions
+recorded and project todos closed. A session without a feature is linked to
+the feature it worked on. A session that recorded no work is marked
+abandoned instead of completed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sessionEndHook(readHookPayload())
+		},
+	}
+}
+
+// sessionEndHook handles a SessionEnd payload
+func sessionEndHook(raw []byte) error {
+	var input sessionEndInput
+	if len(strings.TrimSpace(string(raw))) > 0 {
+		if err := json.Unmarshal(raw, &input); err != nil {
+			debugLog("session-end: json parse error: %v", err)
+			return nil
+		}
+	}
+
+	message, err := handleSessionEnd(input, time.Now())
+	if err != nil {
+		debugLog("session-end: %v", err)
+		return nil
+	}
+	fmt.Print(message)
+	return nil
+}
+
+// handleSessionEnd ends the active session and returns the message to print
+func handleSessionEnd(input sessionEndInput, now time.Time) (string, error) {
+	if input.Cwd != "" {
+		if err := os.Chdir(input.Cwd); err != nil {
+			return "", fmt.Errorf("failed to enter %s: %w", input.Cwd, err)
+		}
+	}
+
+	conn, err := db.GetConnection()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	session, err := currentSession(conn, input.SessionID)
+	if err != nil {
+		return "", err
+	}
+	if session == nil {
+		return "Kratos: No active session to end\n", nil
+	}
+
+	activity, err := db.GetSessionActivity(conn, session.SessionID, now.UnixMilli())
+	if err != nil {
+		return "", fmt.Errorf("failed to collect session activity: %w", err)
+	}
+
+	// Attribute the work to a feature: the session's, the one it completed stages
+	// of, else the most recently updated unfinished feature in the repo
+	if activity.Feature == "" && !activity.Idle() {
+		if status := latestFeature(gitRoot()); status != nil {
+			activity.Feature = status.Feature
+		}
+	}
+	if activity.Feature != "" && session.FeatureName == nil {
+		if _, err := db.SetSessionFeature(conn, session.SessionID, activity.Feature); err != nil {
+			debugLog("session-end: %v", err)
+		}
+	}
+
+	currentStage := ""
+	if activity.Feature != "" {
+		if status, err := pipeline.Load(statusPath(activity.Feature)); err == nil {
+			currentStage = status.CurrentStage
+		}
+	}
+
+	state := "completed"
+	if activity.Idle() {
+		state = "abandoned"
+	}
+	summary := formatter.FormatSessionSummary(activity, currentStage)
+	if err := db.FinishSession(conn, session.SessionID, state, strings.TrimSpace(summary)); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Kratos: Session ended - %s (%s)\n%s", session.SessionID, state, summary), nil
+}
+
+// currentSession returns the active memory session of a Claude Code session,
+// or without a Claude Code session ID the project's active session. nil if none
+func currentSession(conn *sql.DB, claudeSessionID string) (*models.Session, error) {
+	if claudeSessionID == "" {
+		session, err := db.GetActiveSession(conn, projectPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check active session: %w", err)
+		}
+		return session, nil
+	}
+
+	session, err := db.GetSessionByClaudeID(conn, claudeSessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.Status != "active" {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// hookSession returns the memory session hook events are recorded in, nil if it ended
+// A Claude Code session that never had one, e.g. one running when the plugin
+// was enabled, gets one started now
+func hookSession(conn *sql.DB, claudeSessionID string, now time.Time) (*models.Session, error) {
+	if claudeSessionID == "" {
+		return currentSession(conn, "")
+	}
+
+	session, err := db.GetSessionByClaudeID(conn, claudeSessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return createHookSession(conn, projectPath(), claudeSessionID, now)
+	}
+	if session.Status != "active" {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// createHookSession starts a memory session for a Claude Code session (none if empty)
+func createHookSession(conn *sql.DB, project, claudeSessionID string, now time.Time) (*models.Session, error) {
+	session := &models.Session{
+		SessionID: uuid.New().String(),
+		Project:   project,
+		StartedAt: now.UnixMilli(),
+		Status:    "active",
+	}
+	if err := db.CreateSession(conn, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	if claudeSessionID != "" {
+		if err := db.LinkClaudeSession(conn, session.SessionID, claudeSessionID); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// exportSessionID exports the memory session as KRATOS_SESSION_ID to the Claude
+// Code session's commands, so pipeline updates made by agents are attributed to it
+func exportSessionID(sessionID string) {
+	if err := exportEnv("KRATOS_SESSION_ID", sessionID); err != nil {
+		debugLog("failed to export KRATOS_SESSION_ID: %v", err)
+	}
+}
+
+// latestFeature returns the most recently updated unfinished feature under root, nil if none
+func latestFeature(root string) *pipeline.Status {
+	matches, err := filepath.Glob(pipeline.Path(root, "*"))
+	if err != nil {
+		return nil
+	}
+
+	var latest *pipeline.Status
+	for _, path := range matches {
+		status, err := pipeline.Load(path)
+		if err != nil || status.Finished() || status.PipelineStatus == pipeline.StatusComplete {
+			continue
+		}
+		if status.Feature == "" {
+			status.Feature = filepath.Base(filepath.Dir(path))
+		}
+		if latest == nil || status.Updated > latest.Updated {
+			latest = status
+		}
+	}
+	return latest
+}
+
+// resumeBanner describes the unfinished feature of the last session, empty if there is none
+func resumeBanner(conn *sql.DB, last *models.Session, now time.Time) string {
+	if last == nil || last.FeatureName == nil {
+		return ""
+	}
+	path := statusPath(*last.FeatureName)
+	status, err := pipeline.Load(path)
+	if err != nil {
+		debugLog("session-start: no pipeline for %s: %v", *last.FeatureName, err)
+		return ""
+	}
+	entry := pipeline.Summarize(status, filepath.Dir(path), 7*24*time.Hour, now)
+	if entry.Health == pipeline.HealthComplete {
+		return ""
+	}
+
+	var actions []string
+	if steps, err := db.GetStepsForSession(conn, last.SessionID); err == nil {
+		if len(steps) > bannerActions {
+			steps = steps[len(steps)-bannerActions:]
+		}
+		for _, step := range steps {
+			action := step.Action
+			if step.AgentName != nil {
+				action = *step.AgentName + ": " + action
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	lastActive := entry.LastActivity
+	if lastActive == 0 {
+		lastActive = last.StartedAt
+	}
+	return formatter.FormatResumeBanner(entry, lastActive, actions)
+}
+
+// installBinary copies the kratos binary at src into dir unless an identical copy is there,
+// and exports its path as KRATOS_BIN through CLAUDE_ENV_FILE
+func installBinary(src, dir string) error {
+	dst := filepath.Join(dir, installedName())
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if same, err := filepath.EvalSymlinks(src); err == nil && same == dst {
+		return exportEnv("KRATOS_BIN", filepath.ToSlash(dst))
+	}
+	if !sameContent(src, srcInfo, dst) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		// Copy beside the target and rename, so a running copy is never half-written
+		// and concurrent sessions never share a temporary file
+		tmp, err := os.CreateTemp(dir, installedName()+".*.tmp")
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := copyFile(src, tmp.Name()); err != nil {
+			return err
+		}
+		if err := os.Chmod(tmp.Name(), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), dst); err != nil {
+			return err
+		}
+	}
+	return exportEnv("KRATOS_BIN", filepath.ToSlash(dst))
+}
+
+// sameContent reports whether dst exists with the size and SHA-256 hash of src
+func sameContent(src string, srcInfo os.FileInfo, dst string) bool {
+	dstInfo, err := os.Stat(dst)
+	if err != nil || dstInfo.Size() != srcInfo.Size() {
+		return false
+	}
+	srcHash, err := hashFile(src)
+	if err != nil {
+		return false
+	}
+	dstHash, err := hashFile(dst)
+	return err == nil && srcHash == dstHash
+}
+
+// hashFile returns the hex SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// installedName is the file name of the installed binary
+func installedName() string {
+	if runtime.GOOS == "windows" {
+		return "kratos.exe"
+	}
+	return "kratos"
+}
+
+// exportEnv appends an exported variable to the Claude Code environment file, if any
+func exportEnv(name, value string) error {
+	envFile := os.Getenv("CLAUDE_ENV_FILE")
+	if envFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(envFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "export %s=\"%s\"\n", name, value)
+	return err
+}
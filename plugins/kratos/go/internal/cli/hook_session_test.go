@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// claudeSession returns the memory session of a Claude Code session
+func claudeSession(t *testing.T, claudeSessionID string) *models.Session {
+	t.Helper()
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	session, err := db.GetSessionByClaudeID(conn, claudeSessionID)
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	return session
+}
+
+func TestHandleSessionStart(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	envFile := filepath.Join(tmpDir, "claude.env")
+	t.Setenv("CLAUDE_ENV_FILE", envFile)
+
+	// A finished session left feature auth at its first stage
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	feature := "auth"
+	start := time.Now().Add(-48 * time.Hour).UnixMilli()
+	require.NoError(t, db.CreateSession(conn, &models.Session{SessionID: "old", Project: "demo-repo", FeatureName: &feature, StartedAt: start, Status: "active"}))
+	require.NoError(t, db.RecordAgentSpawn(conn, "old", "athena", "opus", "Write PRD", nil, nil))
+	require.NoError(t, db.EndSession(conn, "old", "PRD drafted"))
+
+	now := time.Now()
+	context, err := handleSessionStart(sessionStartInput{SessionID: "claude-1", Cwd: tmpDir, Source: "startup"}, now)
+	require.NoError(t, err)
+	first := claudeSession(t, "claude-1")
+	assert.Equal(t, "demo-repo", first.Project)
+	assert.Contains(t, context, "Kratos: Memory session started - "+first.SessionID)
+	assert.Contains(t, context, "Feature: auth — Login flow")
+	assert.Contains(t, context, "    - athena: Write PRD")
+	assert.Contains(t, context, "Recommendation:")
+
+	// The session ID is exported to the Claude Code session's commands
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "export KRATOS_SESSION_ID=\""+first.SessionID+"\"\n")
+
+	// The same Claude Code session resumes its memory session, however late
+	context, err = handleSessionStart(sessionStartInput{SessionID: "claude-1", Source: "compact"}, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Kratos: Resuming session "+first.SessionID+"\n", context)
+
+	// A new Claude Code session gets its own session and ends the project's previous one
+	context, err = handleSessionStart(sessionStartInput{SessionID: "claude-2", Source: "startup"}, now.Add(time.Minute))
+	require.NoError(t, err)
+	second := claudeSession(t, "claude-2")
+	assert.NotEqual(t, first.SessionID, second.SessionID)
+	assert.Contains(t, context, "Kratos: Memory session started - "+second.SessionID)
+	assert.Equal(t, "completed", claudeSession(t, "claude-1").Status)
+	active, err := db.GetActiveSession(conn, "demo-repo")
+	require.NoError(t, err)
+	assert.Equal(t, second.SessionID, active.SessionID)
+
+	// Without a session ID the project's active session is resumed within the hour...
+	context, err = handleSessionStart(sessionStartInput{Source: "startup"}, now.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "Kratos: Resuming session "+second.SessionID+"\n", context)
+
+	// ...and ended and replaced after it
+	context, err = handleSessionStart(sessionStartInput{Source: "startup"}, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	next, err := db.GetActiveSession(conn, "demo-repo")
+	require.NoError(t, err)
+	assert.Equal(t, "Kratos: Memory session started - "+next.SessionID+"\n", context)
+	previous, err := db.GetSession(conn, second.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", previous.Status)
+}
+
+func TestInstallBinary(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "kratos-build")
+	require.NoError(t, os.WriteFile(src, []byte("v1"), 0755))
+	envFile := filepath.Join(tmpDir, "claude.env")
+	t.Setenv("CLAUDE_ENV_FILE", envFile)
+
+	dir := filepath.Join(tmpDir, "bin")
+	require.NoError(t, installBinary(src, dir))
+	dst := filepath.Join(dir, installedName())
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "export KRATOS_BIN=\""+filepath.ToSlash(dst)+"\"\n", string(env))
+
+	// An identical copy is not rewritten, however old
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(dst, past, past))
+	require.NoError(t, installBinary(src, dir))
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(past))
+
+	// A different copy is replaced, whatever its modification time
+	require.NoError(t, os.WriteFile(dst, []byte("v0"), 0755))
+	require.NoError(t, os.Chtimes(src, past, past))
+	require.NoError(t, installBinary(src, dir))
+	data, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	// No temporary copy is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestHandleSessionEnd(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+
+	now := time.Now()
+	_, err := handleSessionStart(sessionStartInput{SessionID: "claude-1", Cwd: tmpDir, Source: "startup"}, now)
+	require.NoError(t, err)
+	active := claudeSession(t, "claude-1")
+
+	// A window open before the plugin was enabled gets its session on first use
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+	other, err := hookSession(conn, "claude-2", now)
+	require.NoError(t, err)
+	require.NoError(t, db.RecordAgentSpawn(conn, active.SessionID, "athena", "opus", "Write PRD", nil, nil))
+	require.NoError(t, db.RecordFileChange(conn, active.SessionID, "Write prd.md", ".claude/feature/auth/prd.md"))
+
+	message, err := handleSessionEnd(sessionEndInput{SessionID: "claude-1", Cwd: tmpDir}, now.Add(40*time.Minute))
+	require.NoError(t, err)
+	assert.Contains(t, message, "Kratos: Session ended - "+active.SessionID+" (completed)\n")
+	assert.Contains(t, message, "Worked on auth for 40m (2 steps)\n")
+	assert.Contains(t, message, "Agents: athena/opus\n")
+	assert.Contains(t, message, "Files changed (1): .claude/feature/auth/prd.md\n")
+
+	// The session is linked to the feature it worked on; the other window's keeps running
+	session, err := db.GetSession(conn, active.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", session.Status)
+	require.NotNil(t, session.FeatureName)
+	assert.Equal(t, "auth", *session.FeatureName)
+	require.NotNil(t, session.Summary)
+	assert.Contains(t, *session.Summary, "Agents: athena/opus")
+	assert.Equal(t, "active", claudeSession(t, "claude-2").Status)
+
+	message, err = handleSessionEnd(sessionEndInput{SessionID: "claude-1"}, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Kratos: No active session to end\n", message)
+
+	// A session that did nothing is abandoned
+	message, err = handleSessionEnd(sessionEndInput{SessionID: "claude-2"}, now.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "Kratos: Session ended - "+other.SessionID+" (abandoned)\nNo recorded activity (5m)\n", message)
+	session, err = db.GetSession(conn, other.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "abandoned", session.Status)
+	assert.Nil(t, session.FeatureName)
+}
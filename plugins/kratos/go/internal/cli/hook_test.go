@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
 )
 
 func TestSanitizePrompt(t *testing.T) {
@@ -87,21 +89,21 @@ func TestMatchKeywords(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := matchKeywords(tt.text)
+			got := loadRouter().Match(tt.text)
 			if len(got) != len(tt.want) {
-				t.Errorf("matchKeywords() returned %d matches, want %d: %v", len(got), len(tt.want), got)
+				t.Errorf("Match() returned %d matches, want %d: %v", len(got), len(tt.want), got)
 				return
 			}
 			for _, w := range tt.want {
 				found := false
 				for _, g := range got {
-					if g == w {
+					if g.Rule == w {
 						found = true
 						break
 					}
 				}
 				if !found {
-					t.Errorf("matchKeywords() missing expected match %q in %v", w, got)
+					t.Errorf("Match() missing expected match %q in %v", w, got)
 				}
 			}
 		})
@@ -110,7 +112,7 @@ func TestMatchKeywords(t *testing.T) {
 
 func TestBuildInjectionContext(t *testing.T) {
 	t.Run("kratos keyword", func(t *testing.T) {
-		ctx := buildInjectionContext([]string{"kratos"})
+		ctx := buildInjectionContext(loadRouter().Match("kratos"))
 		if !strings.Contains(ctx, "invoked Kratos by name") {
 			t.Error("should mention Kratos invocation")
 		}
@@ -120,7 +122,7 @@ func TestBuildInjectionContext(t *testing.T) {
 	})
 
 	t.Run("god name only", func(t *testing.T) {
-		ctx := buildInjectionContext([]string{"athena"})
+		ctx := buildInjectionContext(loadRouter().Match("athena"))
 		if strings.Contains(ctx, "invoked Kratos by name") {
 			t.Error("should NOT mention Kratos invocation for god-only match")
 		}
@@ -130,7 +132,7 @@ func TestBuildInjectionContext(t *testing.T) {
 	})
 
 	t.Run("mixed", func(t *testing.T) {
-		ctx := buildInjectionContext([]string{"kratos", "ares", "hermes"})
+		ctx := buildInjectionContext(loadRouter().Match("kratos ares hermes"))
 		if !strings.Contains(ctx, "invoked Kratos by name") {
 			t.Error("should mention Kratos")
 		}
@@ -603,4 +605,46 @@ func TestHermesChecklistEnforcement(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestFindActiveFeatureDir_BinaryLayouts(t *testing.T) {
+	root := t.TempDir()
+
+	// Feature written by `pipeline init` in the canonical schema, review pending
+	early := pipeline.New("early", "Early feature", "P2", now())
+	if err := pipeline.Save(pipeline.Path(root, "early"), early); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Feature in the legacy "pipeline" layout with the review in progress
+	legacyDir := filepath.Join(root, ".claude", "feature", "legacy")
+	legacy := map[string]interface{}{
+		"feature": "legacy",
+		"stage":   "11-review",
+		"pipeline": map[string]interface{}{
+			"11-review": map[string]interface{}{"status": "in-progress", "assignee": "hermes"},
+		},
+	}
+	if err := pipeline.WriteJSON(filepath.Join(legacyDir, "status.json"), legacy); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	got, err := findActiveFeatureDir(root)
+	if err != nil {
+		t.Fatalf("findActiveFeatureDir returned unexpected error: %v", err)
+	}
+	if got != legacyDir {
+		t.Errorf("got %q, want in-progress review %q", got, legacyDir)
+	}
+
+	// With only the pending feature left, it is still found
+	if err := os.RemoveAll(legacyDir); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	got, err = findActiveFeatureDir(root)
+	if err != nil {
+		t.Fatalf("findActiveFeatureDir returned unexpected error: %v", err)
+	}
+	if want := filepath.Join(root, ".claude", "feature", "early"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// kratosAgents are the plugin's agents, matched in Task descriptions and prompts
+var kratosAgents = []string{
+	"ananke", "apollo", "ares", "artemis", "athena", "cassandra", "clio", "daedalus",
+	"hades", "hephaestus", "hera", "hermes", "metis", "mimir", "prometheus", "themis",
+}
+
+// kratosAgentPatterns holds a word-boundary regex per agent, in kratosAgents order
+var kratosAgentPatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(kratosAgents))
+	for i, agent := range kratosAgents {
+		patterns[i] = regexp.MustCompile(`(?i)\b` + agent + `\b`)
+	}
+	return patterns
+}()
+
+// toolUseInput is the JSON Claude Code sends for PostToolUse
+type toolUseInput struct {
+	SessionID    string          `json:"session_id"`
+	Cwd          string          `json:"cwd"`
+	ToolName     string          `json:"tool_name"`
+	ToolInput    json.RawMessage `json:"tool_input"`
+	ToolResponse json.RawMessage `json:"tool_response"`
+}
+
+// taskToolInput is the tool_input of the Task tool
+type taskToolInput struct {
+	Description  string `json:"description"`
+	Prompt       string `json:"prompt"`
+	SubagentType string `json:"subagent_type"`
+	Model        string `json:"model"`
+}
+
+// fileToolInput is the tool_input of the Write, Edit and MultiEdit tools
+type fileToolInput struct {
+	FilePath string `json:"file_path"`
+}
+
+// fileToolResponse is the part of a Write/Edit/MultiEdit result describing the change
+type fileToolResponse struct {
+	Type            string `json:"type"` // Write only: create or update
+	Content         string `json:"content"`
+	StructuredPatch []struct {
+		Lines []string `json:"lines"`
+	} `json:"structuredPatch"`
+}
+
+// toolUseCmd records agent spawns and file changes from PostToolUse
+func toolUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tool-use",
+		Short: "Handle PostToolUse hook — record agent spawns and file changes",
+		Long: `Handle the PostToolUse hook for the Task, Write, Edit and MultiEdit tools.
+
+A Task call is recorded as an agent spawn of the Claude Code session's memory
+session (started if it has none) with its model, description, pipeline
+stage and the token usage and duration of its result. A file write or edit is recorded in the file change journal as
+created or modified, with the lines added and removed by that change.
+
+Nothing is printed; the hook never blocks the tool.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return toolUseHook(readHookPayload())
+		},
+	}
+}
+
+// toolUseHook handles a PostToolUse payload
+func toolUseHook(raw []byte) error {
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil
+	}
+	if err := handleToolUse(raw); err != nil {
+		debugLog("tool-use: %v", err)
+	}
+	return nil
+}
+
+// handleToolUse records the tool call in payload in the memory session of its Claude Code session
+func handleToolUse(payload []byte) error {
+	var input toolUseInput
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return fmt.Errorf("failed to parse hook payload: %w", err)
+	}
+	switch input.ToolName {
+	case "Task", "Write", "Edit", "MultiEdit":
+	default:
+		return nil
+	}
+
+	if input.Cwd != "" {
+		if err := os.Chdir(input.Cwd); err != nil {
+			return fmt.Errorf("failed to enter %s: %w", input.Cwd, err)
+		}
+	}
+
+	conn, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	session, err := hookSession(conn, input.SessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return nil
+	}
+
+	if input.ToolName == "Task" {
+		return recordTaskSpawn(conn, session, input, payload)
+	}
+	return recordToolFileChange(conn, session, input)
+}
+
+// recordTaskSpawn records a Task call as an agent spawn
+func recordTaskSpawn(conn *sql.DB, session *models.Session, input toolUseInput, payload []byte) error {
+	var task taskToolInput
+	if err := json.Unmarshal(input.ToolInput, &task); err != nil {
+		return fmt.Errorf("failed to parse Task input: %w", err)
+	}
+
+	agent := detectAgent(task)
+	model := task.Model
+	if model == "" {
+		model = agentDefaultModel(agent)
+	}
+	action := task.Description
+	if action == "" {
+		action = "Agent task"
+	}
+
+	usage, err := parseTaskUsage(payload)
+	if err != nil {
+		return err
+	}
+	stage, _ := resolveAgentStage(conn, session, agent, debugLog)
+
+	if err := db.RecordAgentSpawn(conn, session.SessionID, agent, model, action, stage, usage); err != nil {
+		return fmt.Errorf("failed to record agent spawn: %w", err)
+	}
+	return nil
+}
+
+// recordToolFileChange records a Write, Edit or MultiEdit call in the file change journal
+func recordToolFileChange(conn *sql.DB, session *models.Session, input toolUseInput) error {
+	var file fileToolInput
+	if err := json.Unmarshal(input.ToolInput, &file); err != nil {
+		return fmt.Errorf("failed to parse %s input: %w", input.ToolName, err)
+	}
+	if file.FilePath == "" {
+		return nil
+	}
+
+	change := &models.FileChange{
+		SessionID:  session.SessionID,
+		FilePath:   file.FilePath,
+		ChangeType: db.ChangeModified,
+	}
+
+	var response fileToolResponse
+	if len(input.ToolResponse) > 0 && json.Unmarshal(input.ToolResponse, &response) == nil {
+		if response.Type == "create" {
+			change.ChangeType = db.ChangeCreated
+		}
+		change.LinesAdded, change.LinesRemoved = patchLineCounts(response)
+	}
+
+	if err := db.RecordFileChangeDetail(conn, input.ToolName, change); err != nil {
+		return fmt.Errorf("failed to record file change: %w", err)
+	}
+	return nil
+}
+
+// detectAgent names the agent a Task call spawns: the subagent type without its
+// plugin prefix (kratos:ares), else a Kratos agent named in the description or
+// prompt, else the raw subagent type
+func detectAgent(task taskToolInput) string {
+	subagent := task.SubagentType
+	if i := strings.LastIndex(subagent, ":"); i >= 0 {
+		subagent = subagent[i+1:]
+	}
+	for _, agent := range kratosAgents {
+		if strings.EqualFold(subagent, agent) {
+			return agent
+		}
+	}
+
+	for _, text := range []string{task.Description, task.Prompt} {
+		for i, re := range kratosAgentPatterns {
+			if re.MatchString(text) {
+				return kratosAgents[i]
+			}
+		}
+	}
+
+	if task.SubagentType != "" {
+		return task.SubagentType
+	}
+	return "unknown"
+}
+
+// agentDefaultModel reads the model of a Kratos agent from its definition's
+// frontmatter, empty if the plugin's agents directory is not found
+func agentDefaultModel(agent string) string {
+	root := os.Getenv("CLAUDE_PLUGIN_ROOT")
+	if root == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return ""
+		}
+		root = filepath.Dir(filepath.Dir(exe))
+	}
+
+	f, err := os.Open(filepath.Join(root, "agents", agent+".md"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "---" && i > 0 {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "model:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// patchLineCounts counts the lines a file tool added and removed, from its
+// structured patch or, for a created file, its content. nil if unknown
+func patchLineCounts(response fileToolResponse) (*int64, *int64) {
+	var added, removed int64
+	switch {
+	case len(response.StructuredPatch) > 0:
+		for _, hunk := range response.StructuredPatch {
+			for _, line := range hunk.Lines {
+				if strings.HasPrefix(line, "+") {
+					added++
+				} else if strings.HasPrefix(line, "-") {
+					removed++
+				}
+			}
+		}
+	case response.Type == "create":
+		added = int64(strings.Count(response.Content, "\n"))
+		if response.Content != "" && !strings.HasSuffix(response.Content, "\n") {
+			added++
+		}
+	default:
+		return nil, nil
+	}
+	return &added, &removed
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleToolUse(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	_, err := handleSessionStart(sessionStartInput{SessionID: "claude-1", Cwd: tmpDir, Source: "startup"}, time.Now())
+	require.NoError(t, err)
+	active := claudeSession(t, "claude-1")
+
+	// The model comes from the agent definition when the Task call names none
+	pluginRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(pluginRoot, "agents"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginRoot, "agents", "themis.md"),
+		[]byte("---\nname: themis\nmodel: opus\n---\n\nmodel: haiku\n"), 0644))
+	t.Setenv("CLAUDE_PLUGIN_ROOT", pluginRoot)
+
+	payloads := []string{
+		`{"session_id":"claude-1","tool_name":"Task","tool_input":{"subagent_type":"kratos:themis","description":"Clarify scope"},` +
+			`"tool_response":{"totalDurationMs":1500,"usage":{"input_tokens":10,"output_tokens":20}}}`,
+		`{"session_id":"claude-1","tool_name":"Task","tool_input":{"subagent_type":"general-purpose","description":"Ask Hera to verify","model":"sonnet"}}`,
+		`{"session_id":"claude-1","tool_name":"Write","tool_input":{"file_path":"src/new.go"},"tool_response":{"type":"create","content":"a\nb\nc"}}`,
+		`{"session_id":"claude-1","tool_name":"MultiEdit","tool_input":{"file_path":"src/old.go"},` +
+			`"tool_response":{"structuredPatch":[{"lines":[" x","-y","+z","+w"]}]}}`,
+		`{"session_id":"claude-1","tool_name":"Read","tool_input":{"file_path":"src/old.go"}}`,
+	}
+	for _, payload := range payloads {
+		require.NoError(t, handleToolUse([]byte(payload)))
+	}
+
+	conn, err := db.GetConnection()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	steps, err := db.GetStepsForSession(conn, active.SessionID)
+	require.NoError(t, err)
+	require.Len(t, steps, 4)
+	assert.Equal(t, "themis", *steps[0].AgentName)
+	assert.Equal(t, "opus", *steps[0].AgentModel)
+	assert.Equal(t, "Clarify scope", steps[0].Action)
+	require.NotNil(t, steps[0].Usage)
+	assert.Equal(t, int64(1500), steps[0].Usage.DurationMs)
+	assert.Equal(t, "hera", *steps[1].AgentName)
+	assert.Equal(t, "sonnet", *steps[1].AgentModel)
+	assert.Nil(t, steps[1].Usage)
+
+	changes, err := db.ListFileChanges(conn, db.FileChangeFilter{SessionID: active.SessionID})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	byPath := map[string][2]int64{}
+	for _, change := range changes {
+		require.NotNil(t, change.LinesAdded)
+		require.NotNil(t, change.LinesRemoved)
+		byPath[change.FilePath+" "+change.ChangeType] = [2]int64{*change.LinesAdded, *change.LinesRemoved}
+	}
+	assert.Equal(t, [2]int64{3, 0}, byPath["src/new.go created"])
+	assert.Equal(t, [2]int64{2, 1}, byPath["src/old.go modified"])
+
+	// A window in another project records into its own session, started on first use
+	t.Setenv("KRATOS_PROJECT", "other-repo")
+	require.NoError(t, handleToolUse([]byte(`{"session_id":"claude-2","tool_name":"Edit","tool_input":{"file_path":"lib.go"}}`)))
+	other := claudeSession(t, "claude-2")
+	assert.Equal(t, "other-repo", other.Project)
+	changes, err = db.ListFileChanges(conn, db.FileChangeFilter{SessionID: other.SessionID})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Nil(t, changes[0].LinesAdded)
+
+	// Once the session ended nothing more is recorded
+	_, err = handleSessionEnd(sessionEndInput{SessionID: "claude-1"}, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, handleToolUse([]byte(payloads[2])))
+	changes, err = db.ListFileChanges(conn, db.FileChangeFilter{SessionID: active.SessionID})
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, active.SessionID, claudeSession(t, "claude-1").SessionID)
+}
+
+func TestDetectAgent(t *testing.T) {
+	assert.Equal(t, "ares", detectAgent(taskToolInput{SubagentType: "kratos:ares"}))
+	assert.Equal(t, "daedalus", detectAgent(taskToolInput{SubagentType: "Daedalus"}))
+	assert.Equal(t, "cassandra", detectAgent(taskToolInput{SubagentType: "general-purpose", Prompt: "You are Cassandra, the risk analyst"}))
+	// Names inside other words do not match
+	assert.Equal(t, "Explore", detectAgent(taskToolInput{SubagentType: "Explore", Description: "Find the hermetic seal"}))
+	assert.Equal(t, "unknown", detectAgent(taskToolInput{}))
+}
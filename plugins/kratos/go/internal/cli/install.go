@@ -15,7 +15,7 @@ func InstallCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "install",
 		Short: "Install Kratos hooks globally",
-		Long:  "Copies hook files to ~/.claude/hooks/kratos/ and updates settings.json",
+		Long:  "Copies the kratos binary to ~/.claude/hooks/kratos/ and registers its hook subcommands in settings.json",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return installHooks()
 		},
@@ -41,12 +41,6 @@ func installHooks() error {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	// Get hooks source directory (relative to executable)
-	// Assuming kratos binary is in plugins/kratos/bin/
-	binDir := filepath.Dir(kratosExe)
-	pluginRoot := filepath.Dir(binDir)
-	hooksSource := filepath.Join(pluginRoot, "hooks")
-
 	// Create directories
 	fmt.Println("Creating directories...")
 	if err := os.MkdirAll(hooksDir, 0755); err != nil {
@@ -54,25 +48,6 @@ func installHooks() error {
 	}
 	fmt.Printf("  ✓ %s\n", hooksDir)
 
-	// Copy hook files
-	fmt.Println("\nCopying hook files...")
-	hookFiles := []string{
-		"session-start.cjs",
-		"session-end.cjs",
-		"tool-use.cjs",
-	}
-
-	for _, file := range hookFiles {
-		src := filepath.Join(hooksSource, file)
-		dst := filepath.Join(hooksDir, file)
-
-		if err := copyFile(src, dst); err != nil {
-			fmt.Printf("  ⚠ %s (skipped: %v)\n", file, err)
-		} else {
-			fmt.Printf("  ✓ %s\n", file)
-		}
-	}
-
 	// Copy kratos binary
 	fmt.Println("\nCopying kratos binary...")
 	kratosDst := filepath.Join(hooksDir, "kratos")
@@ -139,7 +114,6 @@ func updateSettings(settingsFile, hooksDir string) error {
 	}
 
 	// Generate hook config
-	hookPath := filepath.ToSlash(hooksDir) // Normalize for JSON
 	kratosBinPath := filepath.ToSlash(filepath.Join(hooksDir, "kratos"))
 
 	// UserPromptSubmit hook (keyword detection → skill injection)
@@ -149,7 +123,7 @@ func updateSettings(settingsFile, hooksDir string) error {
 			"hooks": []map[string]interface{}{
 				{
 					"type":    "command",
-					"command": fmt.Sprintf("\"%s\" hook prompt-submit", kratosBinPath),
+					"command": fmt.Sprintf("\"%s\" hook dispatch", kratosBinPath),
 					"timeout": 3000,
 				},
 			},
@@ -163,7 +137,7 @@ func updateSettings(settingsFile, hooksDir string) error {
 			"hooks": []map[string]interface{}{
 				{
 					"type":    "command",
-					"command": fmt.Sprintf("node \"%s/session-start.cjs\"", hookPath),
+					"command": fmt.Sprintf("\"%s\" hook dispatch", kratosBinPath),
 					"timeout": 5000,
 				},
 			},
@@ -173,25 +147,26 @@ func updateSettings(settingsFile, hooksDir string) error {
 	// PostToolUse hook
 	hooks["PostToolUse"] = []map[string]interface{}{
 		{
-			"matcher": "Task|Write|Edit",
+			"matcher": "Task|Write|Edit|MultiEdit",
 			"hooks": []map[string]interface{}{
 				{
 					"type":    "command",
-					"command": fmt.Sprintf("node \"%s/tool-use.cjs\"", hookPath),
+					"command": fmt.Sprintf("\"%s\" hook dispatch", kratosBinPath),
 					"timeout": 5000,
 				},
 			},
 		},
 	}
 
-	// Stop hook
-	hooks["Stop"] = []map[string]interface{}{
+	// SessionEnd hook (replaces the Stop hook of earlier installs, which fired every turn)
+	delete(hooks, "Stop")
+	hooks["SessionEnd"] = []map[string]interface{}{
 		{
 			"matcher": "",
 			"hooks": []map[string]interface{}{
 				{
 					"type":    "command",
-					"command": fmt.Sprintf("node \"%s/session-end.cjs\"", hookPath),
+					"command": fmt.Sprintf("\"%s\" hook dispatch", kratosBinPath),
 					"timeout": 10000,
 				},
 			},
@@ -7,8 +7,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
 	"github.com/spf13/cobra"
 )
 
@@ -21,8 +21,15 @@ func PipelineCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(pipelineInitCmd())
+	cmd.AddCommand(pipelineTemplatesCmd())
 	cmd.AddCommand(pipelineUpdateCmd())
 	cmd.AddCommand(pipelineGetCmd())
+	cmd.AddCommand(pipelineNextCmd())
+	cmd.AddCommand(pipelineCheckCmd())
+	cmd.AddCommand(pipelineTaskCmd())
+	cmd.AddCommand(pipelineMigrateCmd())
+	cmd.AddCommand(pipelineLogCmd())
+	cmd.AddCommand(pipelineReplayCmd())
 
 	return cmd
 }
@@ -39,67 +46,44 @@ func gitRoot() string {
 
 // statusPath resolves the status.json path for a feature
 func statusPath(feature string) string {
-	return filepath.Join(gitRoot(), ".claude", "feature", feature, "status.json")
-}
-
-// readStatusJSON reads and parses a status.json file
-func readStatusJSON(path string) (map[string]interface{}, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read %s: %w", path, err)
-	}
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
-	}
-	return status, nil
-}
-
-// writeStatusJSON atomically writes a status.json file
-func writeStatusJSON(path string, status map[string]interface{}) error {
-	data, err := json.MarshalIndent(status, "", "  ")
-	if err != nil {
-		return fmt.Errorf("cannot marshal JSON: %w", err)
-	}
-	data = append(data, '\n')
-
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("cannot create directory %s: %w", dir, err)
-	}
-
-	tmp := path + ".tmp"
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
-		return fmt.Errorf("cannot write temp file: %w", err)
-	}
-	if err := os.Rename(tmp, path); err != nil {
-		os.Remove(tmp)
-		return fmt.Errorf("cannot rename temp file: %w", err)
-	}
-	return nil
+	return pipeline.Path(gitRoot(), feature)
 }
 
 // now returns the current time in RFC3339 format
 func now() string {
-	return time.Now().Format(time.RFC3339)
+	return pipeline.Now()
+}
+
+// printStatus writes a status as indented JSON to stdout
+func printStatus(status *pipeline.Status) {
+	out, _ := json.MarshalIndent(status, "", "  ")
+	fmt.Println(string(out))
 }
 
 // --- pipeline init ---
 
 func pipelineInitCmd() *cobra.Command {
-	var feature, description, priority string
+	var feature, description, priority, template, mode string
 
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a new feature pipeline status.json",
+		Long: `Initialize a new feature pipeline status.json.
+
+--template selects the pipeline definition: the built-in default (11 stages,
+PRD to code review), bugfix, spike, or any template in
+.claude/kratos/pipelines/*.json|yaml, which override built-ins of the same
+name. See 'pipeline templates'.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return pipelineInit(feature, description, priority)
+			return pipelineInitTemplate(feature, description, priority, template, mode)
 		},
 	}
 
 	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (required)")
 	cmd.Flags().StringVar(&description, "description", "", "Feature description (required)")
 	cmd.Flags().StringVar(&priority, "priority", "P2", "Priority: P0, P1, P2, P3")
+	cmd.Flags().StringVar(&template, "template", pipeline.DefaultTemplate, "Pipeline template")
+	cmd.Flags().StringVar(&mode, "mode", "", "Execution mode: normal, eco, power (defaults to the template's)")
 	cmd.MarkFlagRequired("feature")
 	cmd.MarkFlagRequired("description")
 
@@ -107,6 +91,10 @@ func pipelineInitCmd() *cobra.Command {
 }
 
 func pipelineInit(feature, description, priority string) error {
+	return pipelineInitTemplate(feature, description, priority, pipeline.DefaultTemplate, "")
+}
+
+func pipelineInitTemplate(feature, description, priority, templateName, mode string) error {
 	path := statusPath(feature)
 
 	// Check if already exists
@@ -114,162 +102,84 @@ func pipelineInit(feature, description, priority string) error {
 		return fmt.Errorf("status.json already exists at %s", path)
 	}
 
-	ts := now()
-
-	status := map[string]interface{}{
-		"feature":     feature,
-		"description": description,
-		"priority":    priority,
-		"created":     ts,
-		"updated":     ts,
-		"stage":       "1-prd",
-		"pipeline": map[string]interface{}{
-			"1-prd": map[string]interface{}{
-				"status":    "in-progress",
-				"assignee":  "athena",
-				"started":   ts,
-				"completed": nil,
-				"document":  "prd.md",
-			},
-			"2-prd-review": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "athena",
-				"started":   nil,
-				"completed": nil,
-				"document":  "prd-review.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"1-prd"},
-					"condition": "prd.status === 'complete'",
-				},
-			},
-			"3-decomposition": map[string]interface{}{
-				"status":    "skipped",
-				"assignee":  "daedalus",
-				"started":   nil,
-				"completed": nil,
-				"document":  "decomposition.md",
-				"optional":  true,
-				"gate": map[string]interface{}{
-					"requires":  []string{"2-prd-review"},
-					"condition": "prd-review.verdict === 'approved' AND user opts in",
-				},
-			},
-			"4-discuss": map[string]interface{}{
-				"status":    "skipped",
-				"assignee":  "themis",
-				"started":   nil,
-				"completed": nil,
-				"document":  "context.md",
-				"optional":  true,
-				"gate": map[string]interface{}{
-					"requires":  []string{"2-prd-review"},
-					"condition": "prd-review.verdict === 'approved' AND user opts in",
-				},
-			},
-			"5-tech-spec": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "hephaestus",
-				"started":   nil,
-				"completed": nil,
-				"document":  "tech-spec.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"2-prd-review"},
-					"condition": "prd-review.verdict === 'approved'",
-				},
-			},
-			"6-spec-review-pm": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "athena",
-				"started":   nil,
-				"completed": nil,
-				"document":  "spec-review-pm.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"5-tech-spec"},
-					"condition": "tech-spec.status === 'complete'",
-				},
-			},
-			"7-spec-review-sa": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "apollo",
-				"started":   nil,
-				"completed": nil,
-				"document":  "spec-review-sa.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"5-tech-spec"},
-					"condition": "tech-spec.status === 'complete'",
-				},
-			},
-			"8-test-plan": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "artemis",
-				"started":   nil,
-				"completed": nil,
-				"document":  "test-plan.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"6-spec-review-pm", "7-spec-review-sa"},
-					"condition": "both reviews passed",
-				},
-			},
-			"9-implementation": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "ares",
-				"started":   nil,
-				"completed": nil,
-				"document":  "implementation-notes.md",
-				"mode":      nil,
-				"tasks":     nil,
-				"gate": map[string]interface{}{
-					"requires":  []string{"8-test-plan"},
-					"condition": "test-plan exists",
-				},
-			},
-			"10-prd-alignment": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "hera",
-				"started":   nil,
-				"completed": nil,
-				"document":  "prd-alignment.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"9-implementation"},
-					"condition": "implementation complete",
-				},
-			},
-			"11-review": map[string]interface{}{
-				"status":    "blocked",
-				"assignee":  "hermes",
-				"started":   nil,
-				"completed": nil,
-				"document":  "code-review.md",
-				"gate": map[string]interface{}{
-					"requires":  []string{"10-prd-alignment"},
-					"condition": "prd-alignment verdict === 'aligned'",
-				},
-			},
-		},
-		"documents": map[string]interface{}{},
-		"history":   []interface{}{},
+	template, err := pipeline.LoadTemplate(gitRoot(), templateName)
+	if err != nil {
+		return err
 	}
+	if mode != "" {
+		template.Mode = mode
+		if err := template.Validate(); err != nil {
+			return err
+		}
+	}
+
+	status := template.Instantiate(feature, description, priority, now())
 
-	if err := writeStatusJSON(path, status); err != nil {
+	if err := pipeline.Create(path, status); err != nil {
 		return err
 	}
+	events, err := pipeline.ReadEvents(pipeline.EventsPath(path))
+	if err != nil {
+		return err
+	}
+	mirrorFeature(path, status, events)
 
 	// Output result as JSON
-	out, _ := json.MarshalIndent(status, "", "  ")
-	fmt.Println(string(out))
+	printStatus(status)
 	return nil
 }
 
+// --- pipeline templates ---
+
+func pipelineTemplatesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "templates",
+		Short: "List the pipeline templates available to 'pipeline init --template'",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			templates, err := pipeline.Templates(gitRoot())
+			if err != nil {
+				return err
+			}
+			result := map[string]interface{}{
+				"templates": templates,
+				"count":     len(templates),
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+}
+
 // --- pipeline update ---
 
 func pipelineUpdateCmd() *cobra.Command {
 	var feature, stage, status, mode, verdict, document string
+	var force bool
+	var expectRevision int
 
 	cmd := &cobra.Command{
 		Use:   "update",
 		Short: "Update a pipeline stage status",
+		Long: `Update a pipeline stage status.
+
+Moving a stage to in-progress, ready or complete requires its gate to be met:
+every stage in gate.requires must be complete (optional stages may be skipped)
+and review stages must carry a passing verdict (prd-review approved, PM review
+approved, SA review sound, PRD alignment aligned). --force overrides the gate
+and records the unmet requirements in history.
+
+Completing a review with a negative verdict (revisions, concerns, unsound,
+gaps, changes-required) reopens the stage it reviews, resets every stage
+downstream of it to pending and increments that stage's revision counter.
+A misaligned PRD alignment blocks the pipeline for the user to decide.
+
+Updates hold an exclusive lock on status.json and increment its revision, so
+parallel agents never lose each other's changes. With --expect-revision the
+update fails unless status.json is still at that revision (compare-and-swap).
+
+History entries are attributed to the project's active session (or
+KRATOS_SESSION_ID), which is linked to the feature if it had none.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return pipelineUpdate(feature, stage, status, mode, verdict, document)
+			return pipelineUpdate(feature, stage, status, mode, verdict, document, force, expectRevision)
 		},
 	}
 
@@ -279,6 +189,8 @@ func pipelineUpdateCmd() *cobra.Command {
 	cmd.Flags().StringVar(&mode, "mode", "", "Implementation mode: ares or user (stage 9 only)")
 	cmd.Flags().StringVar(&verdict, "verdict", "", "Review verdict: approved, revisions, sound, concerns, unsound, changes-requested, rejected")
 	cmd.Flags().StringVar(&document, "document", "", "Document path to record")
+	cmd.Flags().BoolVar(&force, "force", false, "Override an unmet stage gate (logged to history)")
+	expectRevisionFlag(cmd, &expectRevision)
 	cmd.MarkFlagRequired("feature")
 	cmd.MarkFlagRequired("stage")
 	cmd.MarkFlagRequired("status")
@@ -286,95 +198,124 @@ func pipelineUpdateCmd() *cobra.Command {
 	return cmd
 }
 
-func pipelineUpdate(feature, stage, newStatus, mode, verdict, document string) error {
+func pipelineUpdate(feature, stageID, newStatus, mode, verdict, document string, force bool, expectRevision int) error {
 	path := statusPath(feature)
+	sessionID := activeSessionID()
 
-	statusJSON, err := readStatusJSON(path)
+	status, events, err := pipeline.Update(path, expectRevision, func(status *pipeline.Status) error {
+		history := len(status.History)
+		if err := applyUpdate(status, filepath.Dir(path), stageID, newStatus, mode, verdict, document, force); err != nil {
+			return err
+		}
+		stampSession(status, history, sessionID)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+	mirrorFeature(path, status, events)
+	linkSessionFeature(sessionID, feature)
 
-	ts := now()
+	// Output result
+	printStatus(status)
+	return nil
+}
 
-	// Get pipeline map
-	pipeline, ok := statusJSON["pipeline"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("invalid pipeline structure in status.json")
+// applyUpdate changes a stage's status, enforcing its gate and recording history
+func applyUpdate(status *pipeline.Status, dir, stageID, newStatus, mode, verdict, document string, force bool) error {
+	stage, err := status.Stage(stageID)
+	if err != nil {
+		return err
 	}
 
-	// Get stage map
-	stageData, ok := pipeline[stage]
-	if !ok {
-		return fmt.Errorf("unknown stage: %s", stage)
-	}
-	stageMap, ok := stageData.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("invalid stage data for: %s", stage)
+	ts := now()
+	oldStatus := stage.Status
+
+	// Enforce the stage gate
+	if pipeline.GatedStatus(newStatus) && newStatus != oldStatus {
+		if unmet := status.CheckGate(stageID); len(unmet) > 0 {
+			if !force {
+				return fmt.Errorf("gate for %s not met: %s (use --force to override)", stageID, strings.Join(unmet, "; "))
+			}
+			status.History = append(status.History, &pipeline.HistoryEntry{
+				Timestamp: ts,
+				Stage:     stageID,
+				Action:    "gate-forced",
+				Notes:     "forced past unmet gate: " + strings.Join(unmet, "; "),
+			})
+		}
 	}
 
 	// Update status
-	oldStatus, _ := stageMap["status"].(string)
-	stageMap["status"] = newStatus
+	stage.Status = newStatus
 
 	// Auto-set timestamps
-	if newStatus == "in-progress" && oldStatus != "in-progress" {
-		stageMap["started"] = ts
+	if newStatus == pipeline.StatusInProgress && oldStatus != pipeline.StatusInProgress {
+		stage.Started = ts
 	}
-	if newStatus == "complete" {
-		stageMap["completed"] = ts
-		if stageMap["started"] == nil {
-			stageMap["started"] = ts
+	if newStatus == pipeline.StatusComplete {
+		stage.Completed = ts
+		if stage.Started == "" {
+			stage.Started = ts
 		}
+		status.RecordInputs(stageID, dir)
 	}
 
 	// Optional fields
 	if mode != "" {
-		stageMap["mode"] = mode
+		stage.Mode = mode
+		status.ImplementationMode = mode
 	}
 	if verdict != "" {
-		stageMap["verdict"] = verdict
+		stage.Verdict = verdict
 	}
 	if document != "" {
-		stageMap["document"] = document
+		stage.SetDocument(document)
 	}
 
 	// Update top-level fields
-	statusJSON["updated"] = ts
-	if newStatus == "in-progress" || newStatus == "complete" {
-		statusJSON["stage"] = stage
+	status.Updated = ts
+	if newStatus == pipeline.StatusInProgress || newStatus == pipeline.StatusComplete {
+		status.CurrentStage = stageID
 	}
+	status.SettlePipelineStatus()
 
 	// Record in history
-	historyEntry := map[string]interface{}{
-		"timestamp": ts,
-		"stage":     stage,
-		"action":    fmt.Sprintf("status changed from '%s' to '%s'", oldStatus, newStatus),
-	}
-	if verdict != "" {
-		historyEntry["verdict"] = verdict
+	status.History = append(status.History, &pipeline.HistoryEntry{
+		Timestamp: ts,
+		Stage:     stageID,
+		Action:    historyAction(newStatus),
+		From:      oldStatus,
+		To:        newStatus,
+		Agent:     stage.Agent,
+		Verdict:   verdict,
+		Notes:     fmt.Sprintf("status changed from '%s' to '%s'", oldStatus, newStatus),
+	})
+
+	// A negative verdict sends work back upstream
+	if entry := status.Revise(stageID, ts); entry != nil {
+		status.History = append(status.History, entry)
 	}
+	return nil
+}
 
-	history, _ := statusJSON["history"].([]interface{})
-	statusJSON["history"] = append(history, historyEntry)
+// expectRevisionFlag registers --expect-revision on a command that writes status.json
+func expectRevisionFlag(cmd *cobra.Command, expect *int) {
+	cmd.Flags().IntVar(expect, "expect-revision", pipeline.NoRevision, "Fail unless status.json is at this revision")
+}
 
-	// Record document in documents map
-	if document != "" {
-		docs, _ := statusJSON["documents"].(map[string]interface{})
-		if docs == nil {
-			docs = map[string]interface{}{}
-		}
-		docs[stage] = document
-		statusJSON["documents"] = docs
+// historyAction names the history action for a stage status change
+func historyAction(newStatus string) string {
+	switch newStatus {
+	case pipeline.StatusInProgress:
+		return "started"
+	case pipeline.StatusComplete:
+		return "completed"
+	case pipeline.StatusSkipped:
+		return "skipped"
+	default:
+		return "status-changed"
 	}
-
-	if err := writeStatusJSON(path, statusJSON); err != nil {
-		return err
-	}
-
-	// Output result
-	out, _ := json.MarshalIndent(statusJSON, "", "  ")
-	fmt.Println(string(out))
-	return nil
 }
 
 // --- pipeline get ---
@@ -397,14 +338,175 @@ func pipelineGetCmd() *cobra.Command {
 }
 
 func pipelineGet(feature string) error {
-	path := statusPath(feature)
-
-	statusJSON, err := readStatusJSON(path)
+	status, err := pipeline.Load(statusPath(feature))
 	if err != nil {
 		return err
 	}
 
-	out, _ := json.MarshalIndent(statusJSON, "", "  ")
-	fmt.Println(string(out))
+	printStatus(status)
 	return nil
 }
+
+// --- pipeline next ---
+
+func pipelineNextCmd() *cobra.Command {
+	var feature string
+
+	cmd := &cobra.Command{
+		Use:   "next",
+		Short: "Compute the next actionable stage and agent",
+		Long: `Compute the next actionable stage of a feature pipeline.
+
+Negative review verdicts are routed back to the stage that must be revised;
+otherwise the first unfinished stage decides the action. Gates, skipped
+optional stages and stage-9 task progress are taken into account.
+
+Actions: start, continue, revise, complete, wait-user, blocked, done.
+The output lists the stage, its agent, the documents the agent reads, stages
+that may run in parallel, unmet gate requirements and the reason.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := pipeline.Load(statusPath(feature))
+			if err != nil {
+				return err
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(pipeline.Next(status))
+		},
+	}
+
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (required)")
+	cmd.MarkFlagRequired("feature")
+
+	return cmd
+}
+
+// --- pipeline check ---
+
+func pipelineCheckCmd() *cobra.Command {
+	var feature string
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Detect stale, missing and orphaned feature documents",
+		Long: `Compare feature documents with stage completion timestamps.
+
+For every finished stage the documents it was built on (PRD -> tech spec ->
+reviews -> test plan -> implementation) are checked. An input is stale when its
+hash differs from the one recorded when the stage completed or, for stages
+completed before hashes were recorded, when it was modified or its stage
+completed afterwards. Finished stages without their document are missing;
+markdown files no stage references are orphaned.
+
+Without --feature every feature in the repository is checked. The command
+exits non-zero when any issue is found, so it can gate CI.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := []string{statusPath(feature)}
+			if feature == "" {
+				var err error
+				paths, err = filepath.Glob(pipeline.Path(gitRoot(), "*"))
+				if err != nil {
+					return fmt.Errorf("failed to list features: %w", err)
+				}
+			}
+
+			reports := []*pipeline.CheckReport{}
+			issues := 0
+			for _, path := range paths {
+				status, err := pipeline.Load(path)
+				if err != nil {
+					return err
+				}
+				report := pipeline.Check(status, filepath.Dir(path))
+				issues += len(report.Issues)
+				reports = append(reports, report)
+			}
+
+			result := map[string]interface{}{
+				"status":   "ok",
+				"features": reports,
+				"issues":   issues,
+			}
+			if issues > 0 {
+				result["status"] = "conflicts"
+			}
+
+			if err := json.NewEncoder(cmd.OutOrStdout()).Encode(result); err != nil {
+				return err
+			}
+			if issues > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("pipeline check found %d document issue(s)", issues)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (defaults to all features)")
+
+	return cmd
+}
+
+// --- pipeline migrate ---
+
+func pipelineMigrateCmd() *cobra.Command {
+	var feature string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite legacy status.json files in the canonical schema",
+		Long: `Rewrite status.json files that use the legacy "pipeline"/"stage" layout
+into the canonical "stages"/"current_stage" schema described in
+references/status-json-schema.md.
+
+Without --feature every feature in the repository is checked. Files already
+in the canonical schema are left untouched.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := []string{statusPath(feature)}
+			if feature == "" {
+				var err error
+				paths, err = filepath.Glob(pipeline.Path(gitRoot(), "*"))
+				if err != nil {
+					return fmt.Errorf("failed to list features: %w", err)
+				}
+			}
+
+			files := []map[string]interface{}{}
+			migrated := 0
+			for _, path := range paths {
+				status, err := pipeline.Load(path)
+				if err != nil {
+					return err
+				}
+				if status.Legacy && !dryRun {
+					// Rewriting under the lock keeps concurrent updates intact
+					if _, _, err := pipeline.Update(path, pipeline.NoRevision, func(*pipeline.Status) error { return nil }); err != nil {
+						return err
+					}
+				}
+				if status.Legacy {
+					migrated++
+				}
+				files = append(files, map[string]interface{}{
+					"feature": status.Feature,
+					"path":    path,
+					"legacy":  status.Legacy,
+				})
+			}
+
+			result := map[string]interface{}{
+				"status":   "migrated",
+				"dry_run":  dryRun,
+				"files":    files,
+				"migrated": migrated,
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (defaults to all features)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report legacy files without rewriting them")
+
+	return cmd
+}
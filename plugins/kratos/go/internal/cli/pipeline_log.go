@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// --- pipeline log ---
+
+func pipelineLogCmd() *cobra.Command {
+	var feature, stage, eventType, session, source string
+	var limit int
+	var full bool
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the typed event log of a feature pipeline",
+		Long: `Show pipeline events oldest first: stage transitions with from/to status,
+verdicts, forced gates, revisions and task progress.
+
+By default events are read from the feature's events.jsonl. --source db
+queries the events mirrored into the database instead, which works across
+features (omit --feature) and sessions (--session).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var result map[string]interface{}
+			var err error
+			switch source {
+			case "file":
+				result, err = fileEvents(feature, stage, eventType, session, limit, full)
+			case "db":
+				result, err = dbEvents(feature, stage, eventType, session, limit)
+			default:
+				err = fmt.Errorf("invalid source: %s (use file or db)", source)
+			}
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (required for --source file)")
+	cmd.Flags().StringVar(&stage, "stage", "", "Only events of this stage")
+	cmd.Flags().StringVar(&eventType, "type", "", "Only events of this type, e.g. completed, gate-forced")
+	cmd.Flags().StringVar(&session, "session", "", "Only events recorded in this session")
+	cmd.Flags().StringVar(&source, "source", "file", "Event source: file or db")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Show only the most recent N events")
+	cmd.Flags().BoolVar(&full, "full", false, "Include snapshots and stage changes (file source)")
+
+	return cmd
+}
+
+// fileEvents reads and filters a feature's events.jsonl
+func fileEvents(feature, stage, eventType, session string, limit int, full bool) (map[string]interface{}, error) {
+	if feature == "" {
+		return nil, fmt.Errorf("--feature is required for --source file")
+	}
+	events, err := pipeline.ReadEvents(pipeline.EventsPath(statusPath(feature)))
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []*pipeline.Event{}
+	for _, e := range events {
+		if (stage != "" && e.Stage != stage) || (eventType != "" && e.Type != eventType) || (session != "" && e.SessionID != session) {
+			continue
+		}
+		if !full {
+			brief := *e
+			brief.Snapshot = nil
+			brief.Changes = nil
+			e = &brief
+		}
+		filtered = append(filtered, e)
+	}
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[len(filtered)-limit:]
+	}
+
+	return map[string]interface{}{
+		"feature": feature,
+		"source":  "file",
+		"events":  filtered,
+		"count":   len(filtered),
+	}, nil
+}
+
+// dbEvents queries the pipeline events mirrored for the current project
+func dbEvents(feature, stage, eventType, session string, limit int) (map[string]interface{}, error) {
+	conn, err := db.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	events, err := db.ListPipelineEvents(conn, db.PipelineEventFilter{
+		Project:     projectPath(),
+		FeatureName: feature,
+		Stage:       stage,
+		SessionID:   session,
+		EventType:   eventType,
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"feature": feature,
+		"source":  "db",
+		"events":  events,
+		"count":   len(events),
+	}, nil
+}
+
+// --- pipeline replay ---
+
+func pipelineReplayCmd() *cobra.Command {
+	var feature string
+	var until int
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Rebuild a feature's status.json from its event log",
+		Long: `Rebuild status.json from events.jsonl: start from the snapshot the log
+begins with and apply every later event in order. Use it to recover a
+corrupted or hand-mangled status.json.
+
+--dry-run prints the rebuilt status without writing it; --until SEQ shows the
+pipeline as it was after event SEQ (dry run only).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if until > 0 && !dryRun {
+				return fmt.Errorf("--until requires --dry-run; rewinding status.json would desynchronize it from its log")
+			}
+
+			path := statusPath(feature)
+			unlock, err := pipeline.Lock(path)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			events, err := pipeline.ReadEvents(pipeline.EventsPath(path))
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				return fmt.Errorf("no events recorded for %s", feature)
+			}
+			if until > 0 {
+				n := 0
+				for n < len(events) && events[n].Seq <= until {
+					n++
+				}
+				events = events[:n]
+			}
+
+			status, err := pipeline.Replay(events)
+			if err != nil {
+				return err
+			}
+			if current, err := pipeline.Load(path); err == nil && current.Revision > status.Revision && !dryRun {
+				return fmt.Errorf("events.jsonl ends at revision %d but status.json is at revision %d; replaying would drop the unlogged changes (use --dry-run to inspect)", status.Revision, current.Revision)
+			}
+			if !dryRun {
+				if err := pipeline.Save(path, status); err != nil {
+					return err
+				}
+			}
+
+			result := map[string]interface{}{
+				"status":   "replayed",
+				"feature":  feature,
+				"dry_run":  dryRun,
+				"events":   len(events),
+				"revision": status.Revision,
+				"pipeline": status,
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (required)")
+	cmd.Flags().IntVar(&until, "until", 0, "Replay only events up to this sequence number")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the rebuilt status without writing it")
+	cmd.MarkFlagRequired("feature")
+
+	return cmd
+}
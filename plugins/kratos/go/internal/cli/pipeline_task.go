@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+// pipelineTaskCmd returns the 'pipeline task' command group
+func pipelineTaskCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task",
+		Short: "Track stage-9 implementation tasks",
+		Long: `Maintain the tasks array of the implementation stage.
+
+Tasks link to tasks/NN-*.md files in the feature folder. When the last task
+is marked done the implementation stage completes automatically.`,
+	}
+
+	cmd.AddCommand(pipelineTaskAddCmd())
+	cmd.AddCommand(pipelineTaskStartCmd())
+	cmd.AddCommand(pipelineTaskDoneCmd())
+	cmd.AddCommand(pipelineTaskListCmd())
+
+	return cmd
+}
+
+// taskFlags registers the flags shared by every task command
+func taskFlags(cmd *cobra.Command, feature, stage *string) {
+	cmd.Flags().StringVar(feature, "feature", "", "Feature name (required)")
+	cmd.Flags().StringVar(stage, "stage", pipeline.TaskStage, "Stage that owns the tasks")
+	cmd.MarkFlagRequired("feature")
+}
+
+// updateTasks locks a feature, applies fn to the task stage and saves the result
+// expectRevision is checked as in 'pipeline update --expect-revision'
+func updateTasks(feature, stageID string, expectRevision int, fn func(status *pipeline.Status, stage *pipeline.Stage, dir, ts string) error) (*pipeline.Status, error) {
+	path := statusPath(feature)
+	sessionID := activeSessionID()
+	status, events, err := pipeline.Update(path, expectRevision, func(status *pipeline.Status) error {
+		stage, err := status.Stage(stageID)
+		if err != nil {
+			return err
+		}
+
+		ts := now()
+		history := len(status.History)
+		if err := fn(status, stage, filepath.Dir(path), ts); err != nil {
+			return err
+		}
+		stampSession(status, history, sessionID)
+		status.Updated = ts
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mirrorFeature(path, status, events)
+	linkSessionFeature(sessionID, feature)
+	return status, nil
+}
+
+// writeTasks prints the tasks and progress of a stage as JSON
+func writeTasks(w io.Writer, status *pipeline.Status, stageID string) error {
+	stage, err := status.Stage(stageID)
+	if err != nil {
+		return err
+	}
+	tasks := stage.Tasks
+	if tasks == nil {
+		tasks = []*pipeline.Task{}
+	}
+
+	result := map[string]interface{}{
+		"feature":      status.Feature,
+		"stage":        stageID,
+		"stage_status": stage.Status,
+		"tasks":        tasks,
+		"progress":     stage.TaskProgress(),
+	}
+
+	return json.NewEncoder(w).Encode(result)
+}
+
+// --- pipeline task add ---
+
+func pipelineTaskAddCmd() *cobra.Command {
+	var feature, stageID, id, title, file string
+	var scan bool
+	var expectRevision int
+
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add an implementation task",
+		Long: `Add a task to the implementation stage.
+
+The ID defaults to the next two-digit number and the file to the matching
+tasks/NN-*.md in the feature folder. --scan instead registers every
+tasks/NN-*.md file that is not tracked yet, titled by its first heading.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !scan && title == "" {
+				return fmt.Errorf("--title is required unless --scan is set")
+			}
+
+			status, err := updateTasks(feature, stageID, expectRevision, func(status *pipeline.Status, stage *pipeline.Stage, dir, ts string) error {
+				if scan {
+					for _, task := range pipeline.ScanTasks(dir) {
+						if existing, err := stage.Task(task.ID); err == nil {
+							if existing.File == "" {
+								existing.File = task.File
+							}
+							continue
+						}
+						if err := stage.AddTask(task); err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+
+				task := &pipeline.Task{ID: id, Title: title, File: file}
+				if task.ID == "" {
+					task.ID = stage.NextTaskID()
+				}
+				if task.File == "" {
+					task.File = pipeline.FindTaskFile(dir, task.ID)
+				}
+				return stage.AddTask(task)
+			})
+			if err != nil {
+				return err
+			}
+
+			return writeTasks(cmd.OutOrStdout(), status, stageID)
+		},
+	}
+
+	taskFlags(cmd, &feature, &stageID)
+	expectRevisionFlag(cmd, &expectRevision)
+	cmd.Flags().StringVar(&id, "id", "", "Task ID (defaults to the next number, e.g. 03)")
+	cmd.Flags().StringVar(&title, "title", "", "Task title")
+	cmd.Flags().StringVar(&file, "file", "", "Task file relative to the feature folder (defaults to tasks/NN-*.md)")
+	cmd.Flags().BoolVar(&scan, "scan", false, "Add every untracked tasks/NN-*.md file")
+
+	return cmd
+}
+
+// --- pipeline task start ---
+
+func pipelineTaskStartCmd() *cobra.Command {
+	var feature, stageID string
+	var expectRevision int
+
+	cmd := &cobra.Command{
+		Use:   "start <task-id>...",
+		Short: "Mark tasks in progress",
+		Long: `Mark tasks in progress.
+
+Starting a task starts its stage if it has not started yet; the stage gate
+must be met.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := updateTasks(feature, stageID, expectRevision, func(status *pipeline.Status, stage *pipeline.Stage, dir, ts string) error {
+				if from := stage.Status; from == pipeline.StatusPending || from == pipeline.StatusReady {
+					if unmet := status.CheckGate(stageID); len(unmet) > 0 {
+						return fmt.Errorf("gate for %s not met: %s", stageID, strings.Join(unmet, "; "))
+					}
+					stage.Status = pipeline.StatusInProgress
+					stage.Started = ts
+					status.CurrentStage = stageID
+					status.History = append(status.History, &pipeline.HistoryEntry{
+						Timestamp: ts,
+						Stage:     stageID,
+						Action:    "started",
+						From:      from,
+						To:        pipeline.StatusInProgress,
+						Agent:     stage.Agent,
+					})
+				}
+
+				for _, id := range args {
+					task, err := stage.SetTaskStatus(id, pipeline.StatusInProgress, ts)
+					if err != nil {
+						return err
+					}
+					status.History = append(status.History, &pipeline.HistoryEntry{
+						Timestamp: ts,
+						Stage:     stageID,
+						Action:    "task-started",
+						Notes:     fmt.Sprintf("task %s: %s", task.ID, task.Title),
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return writeTasks(cmd.OutOrStdout(), status, stageID)
+		},
+	}
+
+	taskFlags(cmd, &feature, &stageID)
+	expectRevisionFlag(cmd, &expectRevision)
+
+	return cmd
+}
+
+// --- pipeline task done ---
+
+func pipelineTaskDoneCmd() *cobra.Command {
+	var feature, stageID string
+	var expectRevision int
+
+	cmd := &cobra.Command{
+		Use:   "done <task-id>... | all",
+		Short: "Mark tasks complete",
+		Long: `Mark tasks complete. Completing a task twice keeps its first timestamp.
+
+When every task is complete the stage is marked complete; a stage that has
+not started must have its gate met.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := updateTasks(feature, stageID, expectRevision, func(status *pipeline.Status, stage *pipeline.Stage, dir, ts string) error {
+				ids := args
+				if len(args) == 1 && args[0] == "all" {
+					ids = nil
+					for _, task := range stage.Tasks {
+						ids = append(ids, task.ID)
+					}
+				}
+
+				for _, id := range ids {
+					task, err := stage.Task(id)
+					if err != nil {
+						return err
+					}
+					if task.Status == pipeline.StatusComplete {
+						continue
+					}
+					if _, err := stage.SetTaskStatus(id, pipeline.StatusComplete, ts); err != nil {
+						return err
+					}
+					status.History = append(status.History, &pipeline.HistoryEntry{
+						Timestamp: ts,
+						Stage:     stageID,
+						Action:    "task-completed",
+						Notes:     fmt.Sprintf("task %s: %s", task.ID, task.Title),
+					})
+				}
+
+				if stage.TasksDone() && stage.Status != pipeline.StatusComplete {
+					// A stage that has not started completes only once its gate is met
+					if stage.Status != pipeline.StatusInProgress && stage.Status != pipeline.StatusReady {
+						if unmet := status.CheckGate(stageID); len(unmet) > 0 {
+							return fmt.Errorf("gate for %s not met: %s", stageID, strings.Join(unmet, "; "))
+						}
+					}
+					notes := fmt.Sprintf("all %d tasks complete", len(stage.Tasks))
+					if err := status.CompleteStage(stageID, ts, notes); err != nil {
+						return err
+					}
+					status.RecordInputs(stageID, dir)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return writeTasks(cmd.OutOrStdout(), status, stageID)
+		},
+	}
+
+	taskFlags(cmd, &feature, &stageID)
+	expectRevisionFlag(cmd, &expectRevision)
+
+	return cmd
+}
+
+// --- pipeline task list ---
+
+func pipelineTaskListCmd() *cobra.Command {
+	var feature, stageID string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List implementation tasks and progress",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := pipeline.Load(statusPath(feature))
+			if err != nil {
+				return err
+			}
+
+			return writeTasks(cmd.OutOrStdout(), status, stageID)
+		},
+	}
+
+	taskFlags(cmd, &feature, &stageID)
+
+	return cmd
+}
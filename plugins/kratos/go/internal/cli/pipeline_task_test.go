@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTaskCmd executes a 'pipeline task' subcommand and decodes its JSON output
+func runTaskCmd(t *testing.T, args ...string) (map[string]interface{}, error) {
+	t.Helper()
+	cmd := pipelineTaskCmd()
+	cmd.SetArgs(args)
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	return result, nil
+}
+
+// readyForImplementation initializes a feature with every stage before 9 finished
+func readyForImplementation(t *testing.T) string {
+	t.Helper()
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+	for _, id := range []string{"1-prd", "2-prd-review", "5-tech-spec", "6-spec-review-pm", "7-spec-review-sa", "8-test-plan"} {
+		status.Stages[id].Status = pipeline.StatusComplete
+	}
+	status.Stages["2-prd-review"].Verdict = "approved"
+	status.Stages["6-spec-review-pm"].Verdict = "approved"
+	status.Stages["7-spec-review-sa"].Verdict = "sound"
+	require.NoError(t, pipeline.Save(path, status))
+
+	return filepath.Dir(path)
+}
+
+func TestPipelineTaskLifecycle(t *testing.T) {
+	featureDir := readyForImplementation(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(featureDir, "tasks"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "tasks", "01-user-model.md"), []byte("# Create user model\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "tasks", "02-login.md"), []byte("# Login endpoint\n"), 0o644))
+
+	result, err := runTaskCmd(t, "add", "--feature", "test-feat", "--scan")
+	require.NoError(t, err)
+	tasks := result["tasks"].([]interface{})
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "tasks/01-user-model.md", tasks[0].(map[string]interface{})["file"])
+
+	result, err = runTaskCmd(t, "add", "--feature", "test-feat", "--title", "Wire config")
+	require.NoError(t, err)
+	third := result["tasks"].([]interface{})[2].(map[string]interface{})
+	assert.Equal(t, "03", third["id"])
+	assert.Equal(t, "Wire config", third["title"])
+
+	// Starting a task starts the stage
+	result, err = runTaskCmd(t, "start", "--feature", "test-feat", "01")
+	require.NoError(t, err)
+	assert.Equal(t, "in-progress", result["stage_status"])
+
+	result, err = runTaskCmd(t, "done", "--feature", "test-feat", "01", "02")
+	require.NoError(t, err)
+	assert.Equal(t, "in-progress", result["stage_status"])
+	assert.Equal(t, map[string]interface{}{"total": float64(3), "complete": float64(2)}, result["progress"])
+
+	// An unknown ID fails the whole batch
+	_, err = runTaskCmd(t, "done", "--feature", "test-feat", "03", "09")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown task: 09")
+
+	result, err = runTaskCmd(t, "done", "--feature", "test-feat", "all")
+	require.NoError(t, err)
+	assert.Equal(t, "complete", result["stage_status"])
+
+	status, err := pipeline.Load(filepath.Join(featureDir, "status.json"))
+	require.NoError(t, err)
+	last := status.History[len(status.History)-1]
+	assert.Equal(t, "completed", last.Action)
+	assert.Equal(t, "all 3 tasks complete", last.Notes)
+	assert.Equal(t, "9-implementation", status.CurrentStage)
+
+	result, err = runTaskCmd(t, "list", "--feature", "test-feat")
+	require.NoError(t, err)
+	assert.Len(t, result["tasks"], 3)
+}
+
+func TestPipelineTaskStartEnforcesGate(t *testing.T) {
+	setupFeatureTest(t)
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+
+	_, err := runTaskCmd(t, "add", "--feature", "test-feat", "--title", "Schema")
+	require.NoError(t, err)
+
+	_, err = runTaskCmd(t, "start", "--feature", "test-feat", "01")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gate for 9-implementation not met")
+}
+
+func TestPipelineTaskDoneEnforcesGate(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+
+	_, err := runTaskCmd(t, "add", "--feature", "test-feat", "--title", "Schema")
+	require.NoError(t, err)
+
+	// Completing every task of a pending stage does not skip its gate
+	_, err = runTaskCmd(t, "done", "--feature", "test-feat", "01")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gate for 9-implementation not met")
+
+	status, err := pipeline.Load(filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json"))
+	require.NoError(t, err)
+	assert.Equal(t, pipeline.StatusPending, status.Stages["9-implementation"].Status)
+	assert.Equal(t, pipeline.StatusPending, status.Stages["9-implementation"].Tasks[0].Status)
+}
+
+func TestPipelineTaskAddRequiresTitle(t *testing.T) {
+	setupFeatureTest(t)
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+
+	_, err := runTaskCmd(t, "add", "--feature", "test-feat")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--title is required")
+}
@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,40 +31,21 @@ func TestPipelineInit(t *testing.T) {
 
 	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
 
-	// Directly call init with a known path (bypass gitRoot)
-	ts := now()
-	status := map[string]interface{}{
-		"feature":     "test-feat",
-		"description": "A test feature",
-		"priority":    "P2",
-		"created":     ts,
-		"updated":     ts,
-		"stage":       "1-prd",
-		"pipeline": map[string]interface{}{
-			"1-prd": map[string]interface{}{
-				"status":  "in-progress",
-				"started": ts,
-			},
-		},
-		"documents": map[string]interface{}{},
-		"history":   []interface{}{},
-	}
-
-	err := writeStatusJSON(path, status)
-	require.NoError(t, err)
+	// Directly save a new pipeline with a known path (bypass gitRoot)
+	require.NoError(t, pipeline.Save(path, pipeline.New("test-feat", "A test feature", "P2", now())))
 
 	// Verify file exists
-	_, err = os.Stat(path)
+	_, err := os.Stat(path)
 	require.NoError(t, err)
 
 	// Verify content
-	read, err := readStatusJSON(path)
+	read, err := pipeline.Load(path)
 	require.NoError(t, err)
-	assert.Equal(t, "test-feat", read["feature"])
-	assert.Equal(t, "A test feature", read["description"])
-	assert.Equal(t, "P2", read["priority"])
-	assert.Equal(t, "1-prd", read["stage"])
-	assert.NotEmpty(t, read["created"])
+	assert.Equal(t, "test-feat", read.Feature)
+	assert.Equal(t, "A test feature", read.Description)
+	assert.Equal(t, "P2", read.Priority)
+	assert.Equal(t, "1-prd", read.CurrentStage)
+	assert.NotEmpty(t, read.Created)
 }
 
 func TestPipelineUpdate(t *testing.T) {
@@ -71,57 +55,30 @@ func TestPipelineUpdate(t *testing.T) {
 	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
 
 	// Create initial status
-	initial := map[string]interface{}{
-		"feature": "test-feat",
-		"stage":   "1-prd",
-		"updated": now(),
-		"pipeline": map[string]interface{}{
-			"1-prd": map[string]interface{}{
-				"status":    "in-progress",
-				"started":   now(),
-				"completed": nil,
-			},
-		},
-		"documents": map[string]interface{}{},
-		"history":   []interface{}{},
-	}
-	err := writeStatusJSON(path, initial)
-	require.NoError(t, err)
+	require.NoError(t, pipeline.Save(path, pipeline.New("test-feat", "A test feature", "P2", now())))
 
 	// Read, update, write (simulating pipelineUpdate logic)
-	statusJSON, err := readStatusJSON(path)
+	status, err := pipeline.Load(path)
 	require.NoError(t, err)
 
 	ts := now()
-	pipeline := statusJSON["pipeline"].(map[string]interface{})
-	stageMap := pipeline["1-prd"].(map[string]interface{})
-
-	stageMap["status"] = "complete"
-	stageMap["completed"] = ts
-	statusJSON["updated"] = ts
-	statusJSON["stage"] = "1-prd"
-
-	history := statusJSON["history"].([]interface{})
-	statusJSON["history"] = append(history, map[string]interface{}{
-		"timestamp": ts,
-		"stage":     "1-prd",
-		"action":    "status changed from 'in-progress' to 'complete'",
+	prd := status.Stages["1-prd"]
+	prd.Status = pipeline.StatusComplete
+	prd.Completed = ts
+	status.Updated = ts
+	status.History = append(status.History, &pipeline.HistoryEntry{
+		Timestamp: ts,
+		Stage:     "1-prd",
+		Action:    "status changed from 'in-progress' to 'complete'",
 	})
-
-	err = writeStatusJSON(path, statusJSON)
-	require.NoError(t, err)
+	require.NoError(t, pipeline.Save(path, status))
 
 	// Verify
-	result, err := readStatusJSON(path)
+	result, err := pipeline.Load(path)
 	require.NoError(t, err)
-
-	pipelineResult := result["pipeline"].(map[string]interface{})
-	prdResult := pipelineResult["1-prd"].(map[string]interface{})
-	assert.Equal(t, "complete", prdResult["status"])
-	assert.NotNil(t, prdResult["completed"])
-
-	historyResult := result["history"].([]interface{})
-	assert.Len(t, historyResult, 1)
+	assert.Equal(t, pipeline.StatusComplete, result.Stages["1-prd"].Status)
+	assert.NotEmpty(t, result.Stages["1-prd"].Completed)
+	assert.Len(t, result.History, 1)
 }
 
 func TestPipelineGet(t *testing.T) {
@@ -129,39 +86,28 @@ func TestPipelineGet(t *testing.T) {
 	defer cleanup()
 
 	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+	require.NoError(t, pipeline.Save(path, pipeline.New("test-feat", "", "", now())))
 
-	status := map[string]interface{}{
-		"feature": "test-feat",
-		"stage":   "1-prd",
-	}
-	err := writeStatusJSON(path, status)
-	require.NoError(t, err)
-
-	result, err := readStatusJSON(path)
+	result, err := pipeline.Load(path)
 	require.NoError(t, err)
-	assert.Equal(t, "test-feat", result["feature"])
+	assert.Equal(t, "test-feat", result.Feature)
 }
 
-func TestWriteStatusJSONAtomic(t *testing.T) {
+func TestSaveStatusAtomic(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "status.json")
 
-	status := map[string]interface{}{"key": "value"}
-	err := writeStatusJSON(path, status)
-	require.NoError(t, err)
+	require.NoError(t, pipeline.Save(path, pipeline.New("test-feat", "value", "", now())))
 
 	// Verify no temp file remains
-	_, err = os.Stat(path + ".tmp")
-	assert.True(t, os.IsNotExist(err))
-
-	// Verify content
-	data, err := os.ReadFile(path)
+	leftovers, err := filepath.Glob(filepath.Join(tmpDir, "*.tmp"))
 	require.NoError(t, err)
+	assert.Empty(t, leftovers)
 
-	var result map[string]interface{}
-	err = json.Unmarshal(data, &result)
+	// Verify content
+	result, err := pipeline.Load(path)
 	require.NoError(t, err)
-	assert.Equal(t, "value", result["key"])
+	assert.Equal(t, "value", result.Description)
 }
 
 func TestNowReturnsRFC3339(t *testing.T) {
@@ -170,3 +116,386 @@ func TestNowReturnsRFC3339(t *testing.T) {
 	// RFC3339 contains 'T' separator and timezone
 	assert.Contains(t, ts, "T")
 }
+
+func TestPipelineInitAndUpdateCanonical(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "docs/prd.md", false, pipeline.NoRevision))
+
+	status, err := pipeline.Load(filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "1-prd", status.CurrentStage)
+	assert.False(t, status.Legacy)
+
+	prd := status.Stages["1-prd"]
+	assert.Equal(t, "complete", prd.Status)
+	assert.Equal(t, "athena", prd.Agent)
+	assert.Equal(t, []string{"docs/prd.md", "prd.md"}, prd.Documents)
+
+	require.Len(t, status.History, 1)
+	entry := status.History[0]
+	assert.Equal(t, "completed", entry.Action)
+	assert.Equal(t, "status changed from 'in-progress' to 'complete'", entry.Notes)
+}
+
+func TestPipelineUpdateUnknownStage(t *testing.T) {
+	setupFeatureTest(t)
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	err := pipelineUpdate("test-feat", "99-nope", "complete", "", "", "", false, pipeline.NoRevision)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown stage")
+}
+
+func TestPipelineMigrateCmd(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+
+	legacyPath := filepath.Join(tmpDir, ".claude", "feature", "legacy", "status.json")
+	require.NoError(t, pipeline.WriteJSON(legacyPath, map[string]interface{}{
+		"feature": "legacy",
+		"stage":   "1-prd",
+		"pipeline": map[string]interface{}{
+			"1-prd":        map[string]interface{}{"status": "in-progress", "assignee": "athena", "document": "prd.md"},
+			"2-prd-review": map[string]interface{}{"status": "blocked", "assignee": "athena"},
+		},
+		"documents": map[string]interface{}{},
+		"history":   []interface{}{},
+	}))
+	require.NoError(t, pipelineInit("canonical", "Already canonical", "P2"))
+
+	// Dry run reports without rewriting
+	dryCmd := pipelineMigrateCmd()
+	dryCmd.SetArgs([]string{"--dry-run"})
+	var dryOutput bytes.Buffer
+	dryCmd.SetOut(&dryOutput)
+	require.NoError(t, dryCmd.Execute())
+
+	var dryResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(dryOutput.Bytes(), &dryResult))
+	assert.Equal(t, float64(1), dryResult["migrated"])
+	legacy, err := pipeline.Load(legacyPath)
+	require.NoError(t, err)
+	assert.True(t, legacy.Legacy)
+
+	// Migrate rewrites the legacy file only
+	cmd := pipelineMigrateCmd()
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	migrated, err := pipeline.Load(legacyPath)
+	require.NoError(t, err)
+	assert.False(t, migrated.Legacy)
+	assert.Equal(t, "1-prd", migrated.CurrentStage)
+	assert.Equal(t, "pending", migrated.Stages["2-prd-review"].Status)
+
+	// Nothing left to migrate
+	again := pipelineMigrateCmd()
+	var againOutput bytes.Buffer
+	again.SetOut(&againOutput)
+	require.NoError(t, again.Execute())
+
+	var againResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(againOutput.Bytes(), &againResult))
+	assert.Equal(t, float64(0), againResult["migrated"])
+}
+
+func TestPipelineUpdateEnforcesGate(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+
+	// 9-implementation requires 8-test-plan
+	err := pipelineUpdate("test-feat", "9-implementation", "in-progress", "", "", "", false, pipeline.NoRevision)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "8-test-plan is pending, not complete")
+
+	// 5-tech-spec requires an approved PRD review
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "complete", "", "", "", false, pipeline.NoRevision))
+	err = pipelineUpdate("test-feat", "5-tech-spec", "in-progress", "", "", "", false, pipeline.NoRevision)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2-prd-review has no verdict")
+
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", status.Stages["5-tech-spec"].Status)
+
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "complete", "", "approved", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "5-tech-spec", "in-progress", "", "", "", false, pipeline.NoRevision))
+}
+
+func TestPipelineUpdateForceLogsHistory(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, pipelineUpdate("test-feat", "9-implementation", "in-progress", "", "", "", true, pipeline.NoRevision))
+
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "in-progress", status.Stages["9-implementation"].Status)
+
+	require.Len(t, status.History, 2)
+	forced := status.History[0]
+	assert.Equal(t, "gate-forced", forced.Action)
+	assert.Equal(t, "9-implementation", forced.Stage)
+	assert.Contains(t, forced.Notes, "8-test-plan is pending, not complete")
+	assert.Equal(t, "started", status.History[1].Action)
+}
+
+func TestPipelineNextCmd(t *testing.T) {
+	setupFeatureTest(t)
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "complete", "", "revisions", "", false, pipeline.NoRevision))
+
+	cmd := pipelineNextCmd()
+	cmd.SetArgs([]string{"--feature", "test-feat"})
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	// The revisions verdict reopened the PRD; the review feeds the rewrite
+	assert.Equal(t, "continue", result["action"])
+	assert.Equal(t, "1-prd", result["stage"])
+	assert.Equal(t, "athena", result["agent"])
+	assert.Equal(t, []interface{}{"prd-review.md"}, result["inputs"])
+	assert.Contains(t, result["reason"], "revisions")
+}
+
+func TestPipelineUpdateRevisionRollback(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "complete", "", "approved", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "5-tech-spec", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "6-spec-review-pm", "complete", "", "approved", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "7-spec-review-sa", "complete", "", "unsound", "", false, pipeline.NoRevision))
+
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+
+	spec := status.Stages["5-tech-spec"]
+	assert.Equal(t, "in-progress", spec.Status)
+	assert.Empty(t, spec.Completed)
+	assert.Equal(t, 1, spec.Revisions)
+	assert.Equal(t, "5-tech-spec", status.CurrentStage)
+
+	// Both spec reviews run again against the revised spec
+	for _, id := range []string{"6-spec-review-pm", "7-spec-review-sa"} {
+		assert.Equal(t, "pending", status.Stages[id].Status, id)
+		assert.Empty(t, status.Stages[id].Verdict, id)
+	}
+	assert.Equal(t, "complete", status.Stages["2-prd-review"].Status)
+
+	last := status.History[len(status.History)-1]
+	assert.Equal(t, "revision-requested", last.Action)
+	assert.Equal(t, "7-spec-review-sa", last.Stage)
+	assert.Equal(t, "unsound", last.Verdict)
+	assert.Contains(t, last.Notes, "reopened 5-tech-spec (revision 1)")
+	assert.Contains(t, last.Notes, "reset 6-spec-review-pm, 7-spec-review-sa")
+}
+
+func TestPipelineUpdateKeepsBlockedStatus(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+	status.PipelineStatus = pipeline.StatusBlocked
+	require.NoError(t, pipeline.Save(path, status))
+
+	// Updating a stage does not unblock the pipeline
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "in-progress", "", "", "", false, pipeline.NoRevision))
+	status, err = pipeline.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "in-progress", status.Stages["1-prd"].Status)
+	assert.Equal(t, pipeline.StatusBlocked, status.PipelineStatus)
+}
+
+func TestPipelineCheckCmd(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	featureDir := filepath.Join(tmpDir, ".claude", "feature", "test-feat")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "prd.md"), []byte("# PRD"), 0o644))
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "prd-review.md"), []byte("# Review"), 0o644))
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "complete", "", "approved", "", false, pipeline.NoRevision))
+
+	run := func() (map[string]interface{}, error) {
+		cmd := pipelineCheckCmd()
+		cmd.SetArgs([]string{"--feature", "test-feat"})
+		var output bytes.Buffer
+		cmd.SetOut(&output)
+		err := cmd.Execute()
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+		return result, err
+	}
+
+	result, err := run()
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result["status"])
+
+	// Completing the review recorded the PRD hash; editing the PRD makes it stale
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "prd.md"), []byte("# PRD v2"), 0o644))
+	result, err = run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 document issue")
+	assert.Equal(t, "conflicts", result["status"])
+
+	issue := result["features"].([]interface{})[0].(map[string]interface{})["issues"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "stale", issue["kind"])
+	assert.Equal(t, "2-prd-review", issue["stage"])
+	assert.Equal(t, "prd.md", issue["document"])
+}
+
+func TestPipelineInitTemplate(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+
+	require.NoError(t, pipelineInitTemplate("crash", "Crash on login", "P0", "bugfix", "power"))
+	status, err := pipeline.Load(filepath.Join(tmpDir, ".claude", "feature", "crash", "status.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "bugfix", status.Template)
+	assert.Equal(t, "power", status.Mode)
+	assert.Equal(t, "1-diagnosis", status.CurrentStage)
+	assert.Equal(t, "hades", status.Stages["1-diagnosis"].Agent)
+
+	err = pipelineInitTemplate("other", "Other", "P2", "bugfix", "turbo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid mode: turbo")
+
+	err = pipelineInitTemplate("other", "Other", "P2", "nope", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown pipeline template: nope")
+
+	// Project templates are listed alongside the built-ins
+	dir := pipeline.TemplateDir(tmpDir)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs.yml"), []byte("stages:\n  - id: 1-write\n    agent: clio\n"), 0o644))
+
+	cmd := pipelineTemplatesCmd()
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, float64(4), result["count"])
+	docs := result["templates"].([]interface{})[2].(map[string]interface{})
+	assert.Equal(t, "docs", docs["name"])
+	assert.Equal(t, filepath.Join(dir, "docs.yml"), docs["source"])
+}
+
+func TestPipelineUpdateExpectRevision(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "", false, 0))
+
+	// A second agent that read the file before the first update is refused
+	err := pipelineUpdate("test-feat", "2-prd-review", "in-progress", "", "", "", false, 0)
+	require.ErrorIs(t, err, pipeline.ErrRevisionConflict)
+
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "in-progress", "", "", "", false, 1))
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Revision)
+
+	// A refused gate does not bump the revision
+	err = pipelineUpdate("test-feat", "5-tech-spec", "in-progress", "", "", "", false, pipeline.NoRevision)
+	require.Error(t, err)
+	status, err = pipeline.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Revision)
+}
+
+func TestPipelineLogAndReplay(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	path := filepath.Join(tmpDir, ".claude", "feature", "test-feat", "status.json")
+
+	require.NoError(t, pipelineInit("test-feat", "A test feature", "P2"))
+	require.NoError(t, pipelineUpdate("test-feat", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineUpdate("test-feat", "2-prd-review", "complete", "", "approved", "", false, pipeline.NoRevision))
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	run := func(cmd *cobra.Command, args ...string) map[string]interface{} {
+		t.Helper()
+		cmd.SetArgs(args)
+		var output bytes.Buffer
+		cmd.SetOut(&output)
+		require.NoError(t, cmd.Execute())
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+		return result
+	}
+
+	result := run(pipelineLogCmd(), "--feature", "test-feat")
+	assert.Equal(t, float64(3), result["count"])
+	events := result["events"].([]interface{})
+	first := events[0].(map[string]interface{})
+	assert.Equal(t, "created", first["type"])
+	assert.NotContains(t, first, "snapshot")
+	last := events[2].(map[string]interface{})
+	assert.Equal(t, "completed", last["type"])
+	assert.Equal(t, "2-prd-review", last["stage"])
+	assert.Equal(t, "pending", last["from"])
+	assert.Equal(t, "complete", last["to"])
+	assert.Equal(t, "approved", last["verdict"])
+
+	result = run(pipelineLogCmd(), "--feature", "test-feat", "--stage", "2-prd-review", "--full")
+	assert.Equal(t, float64(1), result["count"])
+	assert.Contains(t, result["events"].([]interface{})[0], "changes")
+
+	// Events are mirrored into the database
+	result = run(pipelineLogCmd(), "--source", "db", "--type", "completed")
+	assert.Equal(t, float64(2), result["count"])
+
+	// A corrupted status.json is rebuilt from the log
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+	result = run(pipelineReplayCmd(), "--feature", "test-feat")
+	assert.Equal(t, "replayed", result["status"])
+	assert.Equal(t, float64(2), result["revision"])
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+
+	// --until shows an earlier state without writing it
+	result = run(pipelineReplayCmd(), "--feature", "test-feat", "--until", "1", "--dry-run")
+	assert.Equal(t, float64(0), result["revision"])
+	after, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+
+	cmd := pipelineReplayCmd()
+	cmd.SetArgs([]string{"--feature", "test-feat", "--until", "1"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	require.Error(t, cmd.Execute())
+
+	// A status.json ahead of its log is not rewound
+	status, err := pipeline.Load(path)
+	require.NoError(t, err)
+	status.Revision++
+	require.NoError(t, pipeline.Save(path, status))
+	cmd = pipelineReplayCmd()
+	cmd.SetArgs([]string{"--feature", "test-feat"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status.json is at revision 3")
+}
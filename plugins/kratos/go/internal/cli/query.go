@@ -3,9 +3,13 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
 )
 
 // QueryCmd returns the 'query' command for querying Kratos data
@@ -15,13 +19,15 @@ func QueryCmd() *cobra.Command {
 		Short: "Query Kratos memory database",
 		Long: `Query sessions, steps, and other data from the Kratos memory system.
 
-Supports filtering by status, project, and full-text search.`,
+Supports filtering by status, project, and ranked full-text search.`,
 	}
 
 	// Add subcommands
 	cmd.AddCommand(QuerySessionsCmd())
 	cmd.AddCommand(QueryStepsCmd())
 	cmd.AddCommand(QuerySearchCmd())
+	cmd.AddCommand(QueryFilesCmd())
+	cmd.AddCommand(QueryHotFilesCmd())
 	cmd.AddCommand(QueryCountCmd())
 
 	return cmd
@@ -52,7 +58,7 @@ Can filter by status, project, or get recent sessions with a limit.`,
 			if status != "" {
 				sessions, err = db.GetSessionsByStatus(conn, status)
 			} else if project != "" {
-				sessions, err = db.GetSessionsByProject(conn, project)
+				sessions, err = db.GetSessionsByProject(conn, projectKey(project))
 			} else {
 				sessions, err = db.GetRecentSessions(conn, limit)
 			}
@@ -71,7 +77,7 @@ Can filter by status, project, or get recent sessions with a limit.`,
 
 	cmd.Flags().IntVar(&limit, "limit", 10, "Number of recent sessions to return")
 	cmd.Flags().StringVar(&status, "status", "", "Filter by status (active, completed, abandoned)")
-	cmd.Flags().StringVar(&project, "project", "", "Filter by project path")
+	cmd.Flags().StringVar(&project, "project", "", "Filter by project")
 
 	return cmd
 }
@@ -111,35 +117,250 @@ Returns steps in chronological order.`,
 
 // QuerySearchCmd returns the 'query search' command
 func QuerySearchCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "search <term>",
-		Short: "Full-text search across sessions",
-		Long: `Search sessions by project, feature name, or summary.
+	var filter db.SearchFilter
+	var since, until string
 
-Uses full-text search to find matching sessions.`,
+	cmd := &cobra.Command{
+		Use:   "search <term>",
+		Short: "Full-text search across steps, decisions and sessions",
+		Long: `Ranked full-text search over step actions and context, decision
+questions and rationales, and session summaries.
+
+Results are ordered by bm25 relevance and include a snippet with the
+matching terms highlighted. Each word is prefix-matched, so "retry" also
+finds "retries".
+
+Examples:
+  kratos query search "retry policy"
+  kratos query search cache --project my-app --agent apollo --since 30d
+  kratos query search auth --source decision --since 2025-01-01 --until 2025-03-31`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchTerm := args[0]
 
+			var err error
+			if filter.Since, err = parseTimeFlag(since, false); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseTimeFlag(until, true); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
 			conn, err := db.GetConnection()
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 
-			sessions, err := db.SearchSessions(conn, searchTerm)
+			filter.Project = projectKey(filter.Project)
+			results, err := db.Search(conn, searchTerm, filter)
 			if err != nil {
-				return fmt.Errorf("failed to search sessions: %w", err)
+				return fmt.Errorf("failed to search: %w", err)
+			}
+
+			// Distinct sessions referenced by the hits, in rank order
+			sessions := []*models.Session{}
+			seen := map[string]bool{}
+			for _, r := range results {
+				if seen[r.SessionID] {
+					continue
+				}
+				seen[r.SessionID] = true
+				if session, err := db.GetSession(conn, r.SessionID); err == nil {
+					sessions = append(sessions, session)
+				}
 			}
 
 			result := map[string]interface{}{
 				"query":    searchTerm,
+				"results":  results,
+				"count":    len(results),
 				"sessions": sessions,
 			}
 
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
 		},
 	}
+
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Filter by project")
+	cmd.Flags().StringVar(&filter.FeatureName, "feature", "", "Filter by feature name")
+	cmd.Flags().StringVar(&filter.AgentName, "agent", "", "Filter by agent name (excludes session results)")
+	cmd.Flags().StringVar(&filter.StepType, "type", "", "Filter by step type, e.g. agent_spawn, file_modify, decision (excludes session results)")
+	cmd.Flags().StringSliceVar(&filter.Sources, "source", nil, "Restrict to sources: step, decision, session (repeatable or comma-separated)")
+	cmd.Flags().StringVar(&since, "since", "", "Only results at or after this time (YYYY-MM-DD, RFC3339, or relative like 7d, 12h, 2w)")
+	cmd.Flags().StringVar(&until, "until", "", "Only results at or before this time (YYYY-MM-DD is inclusive of that day)")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 20, "Maximum number of results (0 for all)")
+
+	return cmd
+}
+
+// parseTimeFlag parses a date flag into Unix epoch ms
+// Accepts YYYY-MM-DD, RFC3339, or a relative age such as 7d, 12h or 2w.
+// An empty value returns 0 (unbounded). With endOfDay, a bare date
+// resolves to the last millisecond of that day so ranges are inclusive.
+func parseTimeFlag(value string, endOfDay bool) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+		return t.UnixMilli(), nil
+	}
+
+	if len(value) >= 2 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			var unit time.Duration
+			switch value[len(value)-1] {
+			case 'h':
+				unit = time.Hour
+			case 'd':
+				unit = 24 * time.Hour
+			case 'w':
+				unit = 7 * 24 * time.Hour
+			}
+			if unit != 0 {
+				return time.Now().Add(-time.Duration(n) * unit).UnixMilli(), nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unrecognized time %q (use YYYY-MM-DD, RFC3339, or 7d/12h/2w)", value)
+}
+
+// QueryFilesCmd returns the 'query files' command
+func QueryFilesCmd() *cobra.Command {
+	var filter db.FileChangeFilter
+	var since, until string
+	var days int
+
+	cmd := &cobra.Command{
+		Use:   "files",
+		Short: "Query the file change journal",
+		Long: `List recorded file changes, newest first.
+
+Examples:
+  kratos query files --feature auth --agent ares
+  kratos query files --path src/auth/ --days 7
+  kratos query files --session <session_id>`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := fileChangeTimeRange(&filter, since, until, days); err != nil {
+				return err
+			}
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			filter.Project = projectKey(filter.Project)
+			changes, err := db.ListFileChanges(conn, filter)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"files": changes,
+				"count": len(changes),
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	addFileChangeFlags(cmd, &filter, &since, &until, &days)
+	cmd.Flags().IntVar(&filter.Limit, "limit", 50, "Maximum number of changes to return (0 for all)")
+
+	return cmd
+}
+
+// QueryHotFilesCmd returns the 'query hot-files' command
+func QueryHotFilesCmd() *cobra.Command {
+	var filter db.FileChangeFilter
+	var since, until string
+	var days int
+
+	cmd := &cobra.Command{
+		Use:   "hot-files",
+		Short: "Show the most frequently changed files",
+		Long: `Aggregate the file change journal per file: number of changes, sessions,
+and lines added/removed. Accepts the same filters as 'query files'.
+
+Example:
+  kratos query hot-files --project my-app --days 30 --limit 10`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := fileChangeTimeRange(&filter, since, until, days); err != nil {
+				return err
+			}
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			filter.Project = projectKey(filter.Project)
+			files, err := db.HotFiles(conn, filter)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"files": files,
+				"count": len(files),
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	addFileChangeFlags(cmd, &filter, &since, &until, &days)
+	cmd.Flags().IntVar(&filter.Limit, "limit", 20, "Maximum number of files to return (0 for all)")
+
+	return cmd
+}
+
+// addFileChangeFlags registers the filters shared by 'query files' and 'query hot-files'
+func addFileChangeFlags(cmd *cobra.Command, filter *db.FileChangeFilter, since, until *string, days *int) {
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Filter by project")
+	cmd.Flags().StringVar(&filter.SessionID, "session", "", "Filter by session ID")
+	cmd.Flags().StringVar(&filter.FeatureName, "feature", "", "Filter by feature name")
+	cmd.Flags().StringVar(&filter.AgentName, "agent", "", "Filter by agent name")
+	cmd.Flags().StringVar(&filter.PathPrefix, "path", "", "Filter by file path prefix")
+	cmd.Flags().IntVar(days, "days", 0, "Only changes from the last N days")
+	cmd.Flags().StringVar(since, "since", "", "Only changes at or after this time (YYYY-MM-DD, RFC3339, or relative like 7d)")
+	cmd.Flags().StringVar(until, "until", "", "Only changes at or before this time (YYYY-MM-DD is inclusive of that day)")
+}
+
+// fileChangeTimeRange resolves --days/--since/--until into the filter
+func fileChangeTimeRange(filter *db.FileChangeFilter, since, until string, days int) error {
+	if days < 0 {
+		return fmt.Errorf("invalid --days: must not be negative")
+	}
+	if days > 0 {
+		if since != "" {
+			return fmt.Errorf("--days and --since cannot be combined")
+		}
+		since = strconv.Itoa(days) + "d"
+	}
+
+	var err error
+	if filter.Since, err = parseTimeFlag(since, false); err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	if filter.Until, err = parseTimeFlag(until, true); err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	return nil
 }
 
 // QueryCountCmd returns the 'query count' command
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -260,3 +261,161 @@ func TestQueryCountCmd(t *testing.T) {
 	json.Unmarshal(output2.Bytes(), &result2)
 	assert.Equal(t, float64(3), result2["count"])
 }
+
+// TestQuerySearchCmd_RankedResults tests that search returns ranked step and decision hits with filters
+func TestQuerySearchCmd_RankedResults(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	sessionID := startTestSession(t, "/test/project", "sync")
+
+	agentCmd := StepRecordAgentCmd()
+	agentCmd.SetArgs([]string{sessionID, "hephaestus", "opus", "Draft retry policy section"})
+	agentCmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, agentCmd.Execute())
+
+	decisionCmd := DecisionRecordCmd()
+	decisionCmd.SetArgs([]string{sessionID, "Retry policy", "Exponential backoff", "--agent", "apollo"})
+	decisionCmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, decisionCmd.Execute())
+
+	cmd := QuerySearchCmd()
+	cmd.SetArgs([]string{"retry", "--source", "decision", "--since", "1d"})
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, float64(1), result["count"])
+
+	hit := result["results"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "decision", hit["source"])
+	assert.Equal(t, "Retry policy", hit["title"])
+	assert.Contains(t, hit["snippet"], "**Retry**")
+
+	sessions := result["sessions"].([]interface{})
+	require.Len(t, sessions, 1)
+	assert.Equal(t, sessionID, sessions[0].(map[string]interface{})["session_id"])
+
+	// Agent filter narrows step hits
+	agentFiltered := QuerySearchCmd()
+	agentFiltered.SetArgs([]string{"retry", "--agent", "hephaestus"})
+	var agentOutput bytes.Buffer
+	agentFiltered.SetOut(&agentOutput)
+	require.NoError(t, agentFiltered.Execute())
+
+	var agentResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(agentOutput.Bytes(), &agentResult))
+	assert.Equal(t, float64(1), agentResult["count"])
+}
+
+// TestQuerySearchCmd_InvalidSince tests that an unparseable date is rejected
+func TestQuerySearchCmd_InvalidSince(t *testing.T) {
+	cmd := QuerySearchCmd()
+	cmd.SetArgs([]string{"x", "--since", "last tuesday"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --since")
+}
+
+// TestParseTimeFlag tests the accepted date formats
+func TestParseTimeFlag(t *testing.T) {
+	v, err := parseTimeFlag("", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), v)
+
+	start, err := parseTimeFlag("2025-03-01", false)
+	require.NoError(t, err)
+	end, err := parseTimeFlag("2025-03-01", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(24*60*60*1000-1), end-start)
+
+	rfc, err := parseTimeFlag("2025-03-01T10:00:00Z", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1740823200000), rfc)
+
+	week, err := parseTimeFlag("1w", false)
+	require.NoError(t, err)
+	day, err := parseTimeFlag("1d", false)
+	require.NoError(t, err)
+	assert.Less(t, week, day)
+
+	_, err = parseTimeFlag("7y", false)
+	assert.Error(t, err)
+}
+
+// TestParseTimeFlag_DST tests that the end of a 23-hour day stops before the next day
+func TestParseTimeFlag_DST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	local := time.Local
+	time.Local = loc
+	defer func() { time.Local = local }()
+
+	end, err := parseTimeFlag("2025-03-09", true)
+	require.NoError(t, err)
+	next, err := parseTimeFlag("2025-03-10", false)
+	require.NoError(t, err)
+	assert.Equal(t, next-1, end)
+}
+
+// Test: query files and hot-files over the file change journal
+func TestQueryFilesCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	sessionID := startTestSession(t, "/test/project", "auth")
+
+	for _, args := range [][]string{
+		{sessionID, "Edit", "src/auth/login.go", "--agent", "ares", "--added", "10", "--removed", "2"},
+		{sessionID, "Edit", "src/auth/login.go", "--agent", "ares", "--added", "3", "--removed", "1"},
+		{sessionID, "Write", "docs/tech-spec.md", "--agent", "hephaestus"},
+	} {
+		cmd := StepRecordFileCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, cmd.Execute())
+	}
+
+	// Files touched by Ares for the feature
+	filesCmd := QueryFilesCmd()
+	filesCmd.SetArgs([]string{"--feature", "auth", "--agent", "ares", "--days", "1"})
+	var filesOutput bytes.Buffer
+	filesCmd.SetOut(&filesOutput)
+	require.NoError(t, filesCmd.Execute())
+
+	var filesResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(filesOutput.Bytes(), &filesResult))
+	assert.Equal(t, float64(2), filesResult["count"])
+
+	// Hot files
+	hotCmd := QueryHotFilesCmd()
+	hotCmd.SetArgs([]string{"--path", "src/"})
+	var hotOutput bytes.Buffer
+	hotCmd.SetOut(&hotOutput)
+	require.NoError(t, hotCmd.Execute())
+
+	var hotResult map[string]interface{}
+	require.NoError(t, json.Unmarshal(hotOutput.Bytes(), &hotResult))
+	require.Equal(t, float64(1), hotResult["count"])
+	hot := hotResult["files"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "src/auth/login.go", hot["file_path"])
+	assert.Equal(t, float64(2), hot["changes"])
+	assert.Equal(t, float64(13), hot["lines_added"])
+}
+
+// Test: query files rejects --days combined with --since
+func TestQueryFilesCmd_DaysAndSince(t *testing.T) {
+	cmd := QueryFilesCmd()
+	cmd.SetArgs([]string{"--days", "7", "--since", "2025-01-01"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	assert.Error(t, cmd.Execute())
+}
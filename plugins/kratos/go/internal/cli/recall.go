@@ -13,6 +13,7 @@ func RecallCmd() *cobra.Command {
 	var global bool
 	var incomplete bool
 	var limit int
+	var feature string
 
 	cmd := &cobra.Command{
 		Use:   "recall [project]",
@@ -22,7 +23,11 @@ func RecallCmd() *cobra.Command {
 Examples:
   kratos recall /path/to/project          # Get last session for project
   kratos recall --global                  # Get recent sessions across all projects
-  kratos recall /path/to/project --incomplete  # Get incomplete features`,
+  kratos recall /path/to/project --incomplete  # Get incomplete features
+  kratos recall my-project --feature auth      # Who did each stage of a feature
+
+The stage history lists each stage completion of the last session's feature
+(or --feature) with the session, agent and model that did it.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			conn, err := db.GetConnection()
@@ -47,7 +52,7 @@ Examples:
 			if len(args) == 0 {
 				return fmt.Errorf("project path required when --global not specified")
 			}
-			project := args[0]
+			project := projectKey(args[0])
 
 			// Get incomplete features
 			if incomplete {
@@ -66,6 +71,18 @@ Examples:
 			}
 			result["last_session"] = lastSession
 
+			// Stage history of the feature being worked on
+			if feature == "" && lastSession != nil && lastSession.FeatureName != nil {
+				feature = *lastSession.FeatureName
+			}
+			if feature != "" {
+				history, err := db.GetStageHistory(conn, project, feature)
+				if err == nil {
+					result["feature"] = feature
+					result["stage_history"] = history
+				}
+			}
+
 			// If there's a last session, also check for incomplete features
 			if lastSession != nil {
 				incompleteFeatures, err := db.GetIncompleteFeatures(conn, project)
@@ -81,6 +98,7 @@ Examples:
 	cmd.Flags().BoolVar(&global, "global", false, "Show recent sessions across all projects")
 	cmd.Flags().BoolVar(&incomplete, "incomplete", false, "Show only incomplete features")
 	cmd.Flags().IntVar(&limit, "limit", 5, "Number of recent sessions to show (for --global)")
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature whose stage history to show (default: the last session's feature)")
 
 	return cmd
 }
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/routing"
+	"github.com/spf13/cobra"
+)
+
+// RoutingCmd returns the 'routing' command group
+func RoutingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "routing",
+		Short: "Inspect the keyword routing of the prompt-submit hook",
+		Long: `Inspect the rules the prompt-submit hook matches prompts against.
+
+Each rule has a keyword (its name), aliases and intent phrases, all matched
+case-insensitively as whole words, negative patterns (regexes removed from
+the prompt before matching) and the context it injects. A matched prompt
+also gets the project state (active feature, stage, next agent, latest
+decisions, open todos) within context_budget tokens; a negative budget turns
+it off. Layers are applied in order, each replacing rules of the same name:
+
+  built-in                              every Kratos agent
+  ~/.kratos/routing.json                or $KRATOS_ROUTING
+  .claude/kratos/routing.json           in the repository
+  .claude/kratos/routing.yaml           in the repository
+
+Example .claude/kratos/routing.yaml:
+
+  exclude: ["\\bkratos\\s+cosplay\\b"]
+  context_budget: 500
+  rules:
+    - name: hermes
+      phrases: ["review this", "look over my diff"]
+    - name: prometheus
+      exclude: ["\\bprometheus\\s+(metrics|alerts?)\\b"]
+    - name: mimir
+      disabled: true
+    - name: ship it
+      inject: Run the pre-ship audit with Cassandra before merging.`,
+	}
+
+	cmd.AddCommand(RoutingCheckCmd())
+	cmd.AddCommand(RoutingShowCmd())
+
+	return cmd
+}
+
+// RoutingCheckCmd reports the rules a prompt matches
+func RoutingCheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check <prompt>",
+		Short: "Show the rules a prompt matches and the context it would inject",
+		Long: `Show the rules a prompt matches and the context the prompt-submit hook
+would inject: the rules' context and the project state, using the project's
+active memory session.`,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			router, err := routing.Load(routingPaths()...)
+			if err != nil {
+				return fmt.Errorf("failed to load routing: %w", err)
+			}
+
+			matches := router.Match(sanitizePrompt(strings.Join(args, " ")))
+			result := map[string]any{"matches": matches}
+			if len(matches) > 0 {
+				result["context"] = buildInjectionContext(matches)
+				result["memory"] = memoryContext("", router.ContextBudget(), time.Now())
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+}
+
+// RoutingShowCmd prints the effective routing config
+func RoutingShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective routing rules and the files they were read from",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := routingPaths()
+			cfg, err := routing.LoadConfig(paths...)
+			if err != nil {
+				return fmt.Errorf("failed to load routing: %w", err)
+			}
+
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(struct {
+				Paths []string `json:"paths"`
+				*routing.Config
+			}{paths, cfg})
+		},
+	}
+}
+
+// routingPaths returns the user's and the current repository's routing files
+func routingPaths() []string {
+	return append([]string{routing.UserPath()}, routing.ProjectPaths(gitRoot())...)
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingCmds(t *testing.T) {
+	tmpDir := setupFeatureTest(t)
+	t.Setenv("KRATOS_ROUTING", filepath.Join(tmpDir, "missing.json"))
+
+	result := runJSON(t, RoutingCheckCmd(), "please", "ask", "Hermes")
+	matches := result["matches"].([]interface{})
+	require.Len(t, matches, 1)
+	assert.Equal(t, "hermes", matches[0].(map[string]interface{})["rule"])
+	assert.Contains(t, result["context"], `Matched rule(s): hermes (keyword "Hermes")`)
+
+	// Ordinary prompts are not routed by the built-in rules
+	result = runJSON(t, RoutingCheckCmd(), "please review this")
+	assert.Empty(t, result["matches"])
+
+	// The repository's rules are layered over the built-in ones
+	dir := filepath.Join(tmpDir, ".claude", "kratos")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "routing.yaml"),
+		[]byte("rules:\n  - name: hermes\n    disabled: true\n  - name: ship it\n    inject: Run Cassandra first.\n"), 0644))
+
+	result = runJSON(t, RoutingCheckCmd(), "ask hermes and ship it")
+	matches = result["matches"].([]interface{})
+	require.Len(t, matches, 1)
+	assert.Equal(t, "ship it", matches[0].(map[string]interface{})["rule"])
+	assert.Contains(t, result["context"], "Run Cassandra first.")
+	assert.NotContains(t, result["context"], "kratos:auto")
+
+	result = runJSON(t, RoutingShowCmd())
+	assert.Len(t, result["paths"], 3)
+	assert.Contains(t, result["inject"], "kratos:auto")
+}
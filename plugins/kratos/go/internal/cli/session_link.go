@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+)
+
+// activeSessionID returns the session pipeline writes are attributed to
+// KRATOS_SESSION_ID wins; otherwise the project's active session. Empty if none
+func activeSessionID() string {
+	if id := os.Getenv("KRATOS_SESSION_ID"); id != "" {
+		return id
+	}
+
+	conn, err := db.GetConnection()
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+
+	session, err := db.GetActiveSession(conn, projectPath())
+	if err != nil || session == nil {
+		return ""
+	}
+	return session.SessionID
+}
+
+// stampSession attributes the history entries added since index from to a session
+func stampSession(status *pipeline.Status, from int, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	for _, entry := range status.History[from:] {
+		if entry.SessionID == "" {
+			entry.SessionID = sessionID
+		}
+	}
+}
+
+// linkSessionFeature records feature as the session's feature if it has none yet
+func linkSessionFeature(sessionID, feature string) {
+	if sessionID == "" {
+		return
+	}
+
+	conn, err := db.GetConnection()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if _, err := db.SetSessionFeature(conn, sessionID, feature); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to link session to feature: %v\n", err)
+	}
+}
+
+// resolveAgentStage finds the pipeline stage an agent spawned in session works on
+// The session's feature is used if it has one; otherwise the feature in the repo
+// where the agent has a running stage (most recently updated first), which then
+// becomes the session's feature. Returns nil if no feature is in flight.
+// Lookup failures are reported to logf
+func resolveAgentStage(conn *sql.DB, session *models.Session, agent string, logf func(format string, args ...any)) (*int64, string) {
+	var status *pipeline.Status
+	if session.FeatureName != nil {
+		s, err := pipeline.Load(statusPath(*session.FeatureName))
+		if err != nil {
+			logf("resolveAgentStage: %v", err)
+			return nil, ""
+		}
+		status = s
+	} else {
+		status = findAgentFeature(gitRoot(), agent)
+		if status == nil {
+			return nil, ""
+		}
+		if _, err := db.SetSessionFeature(conn, session.SessionID, status.Feature); err != nil {
+			logf("resolveAgentStage: %v", err)
+		}
+	}
+
+	stageID := status.AgentStage(agent)
+	n := int64(pipeline.StageNumber(stageID))
+	if n < 0 {
+		return nil, stageID
+	}
+	return &n, stageID
+}
+
+// quietLog discards diagnostics, for commands whose stderr the user reads
+func quietLog(format string, args ...any) {}
+
+// findAgentFeature returns the unfinished feature under root the agent most likely works on
+// Features where the agent has a running stage rank first; ties go to the latest update
+func findAgentFeature(root, agent string) *pipeline.Status {
+	matches, err := filepath.Glob(pipeline.Path(root, "*"))
+	if err != nil {
+		return nil
+	}
+
+	var best *pipeline.Status
+	bestRank := 0
+	for _, path := range matches {
+		status, err := pipeline.Load(path)
+		if err != nil || status.Finished() || status.PipelineStatus == pipeline.StatusComplete {
+			continue
+		}
+		if status.Feature == "" {
+			status.Feature = filepath.Base(filepath.Dir(path))
+		}
+
+		rank := 1
+		if stage := status.Stages[status.AgentStage(agent)]; stage != nil && strings.EqualFold(stage.Agent, agent) &&
+			(stage.Status == pipeline.StatusInProgress || stage.Status == pipeline.StatusReady) {
+			rank = 2
+		}
+		if rank > bestRank || (rank == bestRank && status.Updated > best.Updated) {
+			best, bestRank = status, rank
+		}
+	}
+	return best
+}
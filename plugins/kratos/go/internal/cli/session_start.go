@@ -18,11 +18,13 @@ func SessionStartCmd() *cobra.Command {
 		Short: "Start a new Kratos session",
 		Long: `Start a new Kratos development session for a project.
 
+The project may be a name or a path; a path is stored as an absolute path,
+the key features mirrored from that repository use.
 Optionally specify a feature name to track feature-specific work.
 Only one active session per project is allowed.`,
 		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			project := args[0]
+			project := projectKey(args[0])
 			var featureName *string
 			if len(args) == 2 {
 				featureName = &args[1]
@@ -73,7 +75,7 @@ func SessionActiveCmd() *cobra.Command {
 Returns the session details if one is active, otherwise returns null.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			project := args[0]
+			project := projectKey(args[0])
 
 			conn, err := db.GetConnection()
 			if err != nil {
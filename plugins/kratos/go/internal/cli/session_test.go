@@ -170,3 +170,15 @@ func TestSessionEndCmd(t *testing.T) {
 	assert.Equal(t, "completed", result["status"])
 	assert.NotNil(t, result["ended_at"])
 }
+
+// TestProjectKey tests that project paths stay distinct while names are kept as given
+func TestProjectKey(t *testing.T) {
+	assert.Equal(t, "my-project", projectKey("my-project"))
+	assert.Equal(t, "", projectKey(""))
+	assert.Equal(t, filepath.FromSlash("/work/api"), projectKey("/work/api/"))
+	assert.NotEqual(t, projectKey("/work/api"), projectKey("/oss/api"))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, cwd, projectKey("."))
+}
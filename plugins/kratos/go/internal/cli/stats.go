@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/formatter"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pricing"
+	"github.com/spf13/cobra"
+)
+
+// StatsCmd returns the 'stats' command, time and effort analytics of the pipelines
+func StatsCmd() *cobra.Command {
+	var format, since, until string
+	var allProjects bool
+	var filter db.StatsFilter
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show where pipeline time and effort is spent",
+		Long: `Aggregate pipeline events and agent spawns recorded in the memory database.
+
+Reports, per project (or per feature with --group-by feature):
+  - cycle time of each stage, from its start to its completion
+  - time and spawns of each agent and model
+  - sessions, completed stages and revisions of each feature
+  - stages and features completed within the window, per week
+
+A stage reworked after a revision counts once per completion. Run
+'kratos feature sync' first to import pipelines recorded before the
+database mirrored them.
+
+Examples:
+  kratos stats
+  kratos stats --since 30d --group-by feature
+  kratos stats --all-projects --format csv > stats.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if filter.Since, err = parseTimeFlag(since, false); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseTimeFlag(until, true); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			if allProjects {
+				filter.Project = ""
+			} else if filter.Project == "" {
+				filter.Project = projectPath()
+			} else {
+				filter.Project = projectKey(filter.Project)
+			}
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			stats, err := db.Stats(conn, filter)
+			if err != nil {
+				return fmt.Errorf("failed to compute stats: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				return json.NewEncoder(out).Encode(stats)
+			case "csv":
+				csv, err := formatter.FormatStatsCSV(stats)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(out, csv)
+			case "table":
+				fmt.Fprint(out, formatter.FormatStats(stats))
+			default:
+				return fmt.Errorf("invalid format: %s (use table, json or csv)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json, csv")
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Project name or path (defaults to the current repository)")
+	cmd.Flags().BoolVar(&allProjects, "all-projects", false, "Report on every project in the database")
+	cmd.Flags().StringVar(&filter.FeatureName, "feature", "", "Only this feature")
+	cmd.Flags().StringVar(&filter.GroupBy, "group-by", db.GroupByProject, "Group by project or feature")
+	cmd.Flags().StringVar(&since, "since", "", "Only work completed at or after this time (YYYY-MM-DD, RFC3339, or relative like 7d, 12h, 2w)")
+	cmd.Flags().StringVar(&until, "until", "", "Only work completed at or before this time (YYYY-MM-DD is inclusive of that day)")
+
+	cmd.AddCommand(statsCostCmd())
+
+	return cmd
+}
+
+// statsCostCmd returns the 'stats cost' subcommand, token spend of agent spawns
+func statsCostCmd() *cobra.Command {
+	var format, since, until, pricingPath string
+	var allProjects bool
+	var filter db.CostFilter
+
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Show token usage and spend by feature, agent, model and day",
+		Long: `Price the token usage recorded with each agent spawn and total it by
+feature, agent, model and day.
+
+Usage comes from the Task tool result (recorded by the PostToolUse hook, or
+'step record-agent --input-tokens ...'). Prices are USD per million tokens;
+built-in prices of the opus, sonnet and haiku families are overridden by a
+JSON table such as {"opus": {"input": 15, "output": 75, "cache_write": 18.75,
+"cache_read": 1.5}} in --pricing (default ~/.kratos/pricing.json, or
+KRATOS_PRICING). A model matches its exact entry, else the longest entry
+contained in its name.
+
+Examples:
+  kratos stats cost
+  kratos stats cost --since 7d --feature auth
+  kratos stats cost --all-projects --format csv > cost.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if filter.Since, err = parseTimeFlag(since, false); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseTimeFlag(until, true); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			if allProjects {
+				filter.Project = ""
+			} else if filter.Project == "" {
+				filter.Project = projectPath()
+			} else {
+				filter.Project = projectKey(filter.Project)
+			}
+
+			prices, err := pricing.Load(pricingPath)
+			if err != nil {
+				return err
+			}
+
+			conn, err := db.GetConnection()
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			report, err := db.Cost(conn, filter, prices)
+			if err != nil {
+				return fmt.Errorf("failed to compute cost: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				return json.NewEncoder(out).Encode(report)
+			case "csv":
+				csv, err := formatter.FormatCostCSV(report)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(out, csv)
+			case "table":
+				fmt.Fprint(out, formatter.FormatCost(report))
+			default:
+				return fmt.Errorf("invalid format: %s (use table, json or csv)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json, csv")
+	cmd.Flags().StringVar(&filter.Project, "project", "", "Project name or path (defaults to the current repository)")
+	cmd.Flags().BoolVar(&allProjects, "all-projects", false, "Report on every project in the database")
+	cmd.Flags().StringVar(&filter.FeatureName, "feature", "", "Only spawns of sessions on this feature")
+	cmd.Flags().StringVar(&since, "since", "", "Only spawns at or after this time (YYYY-MM-DD, RFC3339, or relative like 7d, 12h, 2w)")
+	cmd.Flags().StringVar(&until, "until", "", "Only spawns at or before this time (YYYY-MM-DD is inclusive of that day)")
+	cmd.Flags().StringVar(&pricingPath, "pricing", pricing.Path(), "JSON pricing table overriding the built-in prices")
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCmd(t *testing.T) {
+	setupFeatureTest(t)
+	require.NoError(t, pipelineInit("auth", "Login flow", "P1"))
+	require.NoError(t, pipelineUpdate("auth", "1-prd", "complete", "", "", "", false, pipeline.NoRevision))
+	require.NoError(t, pipelineInit("billing", "Invoices", "P2"))
+
+	result := runJSON(t, StatsCmd(), "--format", "json", "--group-by", "feature")
+	assert.Equal(t, "feature", result["group_by"])
+	groups := result["groups"].([]interface{})
+	require.Len(t, groups, 1)
+	group := groups[0].(map[string]interface{})
+	assert.Equal(t, "demo-repo", group["project"])
+	assert.Equal(t, "auth", group["feature"])
+	stages := group["stages"].([]interface{})
+	require.Len(t, stages, 1)
+	assert.Equal(t, "1-prd", stages[0].(map[string]interface{})["stage"])
+	assert.Equal(t, float64(1), stages[0].(map[string]interface{})["completions"])
+
+	// Nothing completed in another project
+	result = runJSON(t, StatsCmd(), "--format", "json", "--project", "elsewhere")
+	assert.Empty(t, result["groups"])
+
+	cmd := StatsCmd()
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	cmd.SetArgs([]string{"--format", "csv"})
+	require.NoError(t, cmd.Execute())
+	assert.True(t, strings.HasPrefix(output.String(), "project,feature,metric"))
+	assert.Contains(t, output.String(), "demo-repo,,stage,1-prd,,1,")
+
+	cmd = StatsCmd()
+	cmd.SetOut(&output)
+	cmd.SetArgs([]string{"--format", "xml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestStatsCostCmd(t *testing.T) {
+	setupFeatureTest(t)
+	t.Setenv("KRATOS_PRICING", t.TempDir()+"/pricing.json")
+	require.NoError(t, InitCmd().Execute())
+	sessionID := runJSON(t, SessionStartCmd(), "demo-repo")["session_id"].(string)
+
+	// Usage from the Task result of the PostToolUse payload, duration overridden by its flag
+	record := StepRecordAgentCmd()
+	record.SetIn(strings.NewReader(`{"tool_name": "Task", "tool_response": {"totalDurationMs": 1000,
+		"usage": {"input_tokens": 100000, "output_tokens": 20000, "cache_creation_input_tokens": 0, "cache_read_input_tokens": 0}}}`))
+	result := runJSON(t, record, sessionID, "athena", "opus", "Write PRD", "--hook-payload", "--duration-ms", "60000")
+	assert.Equal(t, map[string]interface{}{
+		"input_tokens": float64(100000), "output_tokens": float64(20000),
+		"cache_creation_tokens": float64(0), "cache_read_tokens": float64(0), "duration_ms": float64(60000),
+	}, result["usage"])
+
+	result = runJSON(t, StepRecordAgentCmd(), sessionID, "ares", "sonnet", "Implement", "--input-tokens", "1000000")
+	assert.Equal(t, float64(1000000), result["usage"].(map[string]interface{})["input_tokens"])
+	result = runJSON(t, StepRecordAgentCmd(), sessionID, "hermes", "sonnet", "Review")
+	assert.Nil(t, result["usage"])
+
+	report := runJSON(t, StatsCmd(), "cost", "--format", "json")
+	total := report["total"].(map[string]interface{})
+	assert.Equal(t, float64(2), total["spawns"])
+	assert.Equal(t, 6.0, total["cost_usd"])
+	assert.Equal(t, float64(1), report["unmetered_spawns"])
+	assert.Len(t, report["by_agent"], 2)
+	assert.Len(t, report["by_day"], 1)
+
+	cmd := StatsCmd()
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	cmd.SetArgs([]string{"cost"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, output.String(), "Total: $6.00 over 2 spawn(s)")
+}
@@ -1,11 +1,20 @@
 package cli
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/db"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pipeline"
 )
 
 // StepCmd returns the 'step' command
@@ -25,28 +34,68 @@ func StepCmd() *cobra.Command {
 
 // StepRecordAgentCmd records an agent spawn
 func StepRecordAgentCmd() *cobra.Command {
-	return &cobra.Command{
+	var stageFlag string
+	var hookPayload bool
+	var tokens models.TokenUsage
+
+	cmd := &cobra.Command{
 		Use:   "record-agent <session_id> <agent_name> <agent_model> <action>",
 		Short: "Record an agent spawn step",
-		Args:  cobra.ExactArgs(4),
+		Long: `Record an agent spawn step.
+
+The session is the memory session with that ID, or the one started for that
+Claude Code session ID; it is never guessed from the project.
+
+The pipeline stage is resolved from status.json: the stage the agent runs in
+the session's feature, or, for a session without a feature, in the feature
+where the agent has a running stage (which then becomes the session's
+feature). --stage overrides the lookup.
+
+Token usage and duration are taken from the flags, or with --hook-payload
+from the Task tool result of a PostToolUse payload read on stdin; flags
+override the payload. 'kratos stats cost' prices them.`,
+		Args: cobra.ExactArgs(4),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			sessionID := args[0]
 			agentName := args[1]
 			agentModel := args[2]
 			action := args[3]
 
+			usage, err := agentUsage(cmd, hookPayload, &tokens)
+			if err != nil {
+				return err
+			}
+
 			conn, err := db.GetConnection()
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 
-			if err := db.RecordAgentSpawn(conn, sessionID, agentName, agentModel, action); err != nil {
+			session, err := stepSession(conn, sessionID)
+			if err != nil {
+				return err
+			}
+			sessionID = session.SessionID
+
+			var stage *int64
+			stageID := stageFlag
+			if stageFlag != "" {
+				n := int64(pipeline.StageNumber(stageFlag))
+				if n < 0 {
+					return fmt.Errorf("invalid stage: %s (use a stage ID like 7-spec-review-sa or its number)", stageFlag)
+				}
+				stage = &n
+			} else {
+				stage, stageID = resolveAgentStage(conn, session, agentName, quietLog)
+			}
+
+			if err := db.RecordAgentSpawn(conn, sessionID, agentName, agentModel, action, stage, usage); err != nil {
 				return fmt.Errorf("failed to record agent spawn: %w", err)
 			}
 
-			// Get updated step count
-			session, err := db.GetSession(conn, sessionID)
+			// Get updated step count and feature
+			session, err = db.GetSession(conn, sessionID)
 			if err != nil {
 				return err
 			}
@@ -55,40 +104,165 @@ func StepRecordAgentCmd() *cobra.Command {
 				"status":      "success",
 				"step_number": session.TotalSteps,
 			}
+			if session.FeatureName != nil {
+				result["feature"] = *session.FeatureName
+			}
+			if stageID != "" {
+				result["pipeline_stage"] = stageID
+			}
+			if usage != nil {
+				result["usage"] = usage
+			}
 
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
 		},
 	}
+
+	cmd.Flags().StringVar(&stageFlag, "stage", "", "Pipeline stage the agent works on (default: resolved from status.json)")
+	cmd.Flags().Int64Var(&tokens.InputTokens, "input-tokens", 0, "Input tokens used by the agent")
+	cmd.Flags().Int64Var(&tokens.OutputTokens, "output-tokens", 0, "Output tokens produced by the agent")
+	cmd.Flags().Int64Var(&tokens.CacheCreationTokens, "cache-creation-tokens", 0, "Tokens written to the prompt cache")
+	cmd.Flags().Int64Var(&tokens.CacheReadTokens, "cache-read-tokens", 0, "Tokens read from the prompt cache")
+	cmd.Flags().Int64Var(&tokens.DurationMs, "duration-ms", 0, "Wall time of the agent run in milliseconds")
+	cmd.Flags().BoolVar(&hookPayload, "hook-payload", false, "Read a PostToolUse payload on stdin and take usage from its Task result")
+
+	return cmd
+}
+
+// stepSession returns the session with a memory session ID, or the one of a Claude Code session ID
+func stepSession(conn *sql.DB, sessionID string) (*models.Session, error) {
+	session, err := db.GetSessionByClaudeID(conn, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		return session, nil
+	}
+	return db.GetSession(conn, sessionID)
 }
 
 // StepRecordFileCmd records a file change
 func StepRecordFileCmd() *cobra.Command {
-	return &cobra.Command{
+	var changeType, oldPath, description, agent, feature string
+	var added, removed int64
+	var numstat bool
+
+	cmd := &cobra.Command{
 		Use:   "record-file <session_id> <action> <file_path>",
 		Short: "Record a file modification step",
-		Args:  cobra.ExactArgs(3),
+		Long: `Record a file modification as a session step and in the file_changes journal.
+
+The change type is derived from the action (Write, Edit, created, deleted,
+renamed, ...) unless --type is given. --numstat fills in added/removed
+lines from the file's uncommitted changes (git diff --numstat HEAD); an
+untracked file counts all of its lines as added.
+
+Example:
+  kratos step record-file <session_id> Edit src/auth/login.go --agent ares --numstat`,
+		Args: cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			sessionID := args[0]
 			action := args[1]
 			filePath := args[2]
 
+			change := &models.FileChange{
+				SessionID:  sessionID,
+				FilePath:   filePath,
+				ChangeType: changeType,
+			}
+			if oldPath != "" {
+				change.OldPath = &oldPath
+			}
+			if description != "" {
+				change.Description = &description
+			}
+			if agent != "" {
+				change.AgentName = &agent
+			}
+			if feature != "" {
+				change.FeatureName = &feature
+			}
+			if cmd.Flags().Changed("added") {
+				change.LinesAdded = &added
+			}
+			if cmd.Flags().Changed("removed") {
+				change.LinesRemoved = &removed
+			}
+			if numstat && change.LinesAdded == nil && change.LinesRemoved == nil {
+				change.LinesAdded, change.LinesRemoved = gitNumstat(filePath)
+			}
+
 			conn, err := db.GetConnection()
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 
-			if err := db.RecordFileChange(conn, sessionID, action, filePath); err != nil {
+			if err := db.RecordFileChangeDetail(conn, action, change); err != nil {
 				return fmt.Errorf("failed to record file change: %w", err)
 			}
 
 			result := map[string]interface{}{
-				"status": "success",
+				"status":      "success",
+				"file_change": change,
 			}
 
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
 		},
 	}
+
+	cmd.Flags().StringVar(&changeType, "type", "", "Change type: created, modified, deleted, renamed (default: derived from action)")
+	cmd.Flags().StringVar(&oldPath, "old-path", "", "Previous path for renames")
+	cmd.Flags().StringVar(&description, "description", "", "What changed")
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent that made the change (e.g. ares)")
+	cmd.Flags().StringVar(&feature, "feature", "", "Feature name (defaults to the session's feature)")
+	cmd.Flags().Int64Var(&added, "added", 0, "Lines added")
+	cmd.Flags().Int64Var(&removed, "removed", 0, "Lines removed")
+	cmd.Flags().BoolVar(&numstat, "numstat", false, "Compute added/removed lines with git diff --numstat")
+
+	return cmd
+}
+
+// gitNumstat returns the lines added and removed in a file's uncommitted changes
+// Untracked files count every line as added; nil means the counts are unknown
+// (not in a git repository, binary file, or git unavailable)
+func gitNumstat(filePath string) (*int64, *int64) {
+	dir := filepath.Dir(filePath)
+
+	out, err := exec.Command("git", "-C", dir, "diff", "--numstat", "HEAD", "--", filePath).Output()
+	if err != nil {
+		return nil, nil
+	}
+
+	if line := strings.TrimSpace(string(out)); line != "" {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, nil
+		}
+		added, errA := strconv.ParseInt(fields[0], 10, 64)
+		removed, errR := strconv.ParseInt(fields[1], 10, 64)
+		if errA != nil || errR != nil {
+			return nil, nil // binary files report "-"
+		}
+		return &added, &removed
+	}
+
+	// No diff: either unchanged or untracked
+	if exec.Command("git", "-C", dir, "ls-files", "--error-unmatch", "--", filePath).Run() == nil {
+		zero := int64(0)
+		return &zero, &zero
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil
+	}
+	added := int64(bytes.Count(data, []byte("\n")))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		added++
+	}
+	removed := int64(0)
+	return &added, &removed
 }
 
 // StepListCmd lists all steps for a session
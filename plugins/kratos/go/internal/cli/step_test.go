@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -190,3 +191,80 @@ func TestStepMultipleRecords(t *testing.T) {
 	assert.Len(t, steps, 3)
 	assert.Equal(t, float64(3), result["count"])
 }
+
+// Test: step record-file with journal flags
+func TestStepRecordFileCmd_Journal(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("KRATOS_MEMORY_DB", filepath.Join(tmpDir, "test.db"))
+	defer os.Unsetenv("KRATOS_MEMORY_DB")
+
+	sessionID := startTestSession(t, "/test/project", "auth")
+
+	cmd := StepRecordFileCmd()
+	cmd.SetArgs([]string{
+		sessionID, "mv", "src/new.go",
+		"--old-path", "src/old.go",
+		"--agent", "ares",
+		"--added", "4",
+		"--removed", "0",
+	})
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	require.NoError(t, cmd.Execute())
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, "success", result["status"])
+	change := result["file_change"].(map[string]interface{})
+	assert.Equal(t, "renamed", change["change_type"])
+	assert.Equal(t, "src/old.go", change["old_path"])
+	assert.Equal(t, "ares", change["agent_name"])
+	assert.Equal(t, "auth", change["feature_name"])
+	assert.Equal(t, float64(4), change["lines_added"])
+	assert.Equal(t, float64(0), change["lines_removed"])
+}
+
+// Test: gitNumstat counts tracked diffs and untracked files
+func TestGitNumstat(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+
+	tracked := filepath.Join(repo, "tracked.txt")
+	require.NoError(t, os.WriteFile(tracked, []byte("a\nb\nc\n"), 0o644))
+	git("add", "tracked.txt")
+	git("commit", "-q", "-m", "init")
+
+	// Unchanged tracked file
+	added, removed := gitNumstat(tracked)
+	require.NotNil(t, added)
+	assert.Equal(t, int64(0), *added)
+	assert.Equal(t, int64(0), *removed)
+
+	// Modified tracked file: one line replaced, one added
+	require.NoError(t, os.WriteFile(tracked, []byte("a\nB\nc\nd\n"), 0o644))
+	added, removed = gitNumstat(tracked)
+	require.NotNil(t, added)
+	assert.Equal(t, int64(2), *added)
+	assert.Equal(t, int64(1), *removed)
+
+	// Untracked file counts every line as added
+	untracked := filepath.Join(repo, "new.txt")
+	require.NoError(t, os.WriteFile(untracked, []byte("x\ny"), 0o644))
+	added, removed = gitNumstat(untracked)
+	require.NotNil(t, added)
+	assert.Equal(t, int64(2), *added)
+	assert.Equal(t, int64(0), *removed)
+
+	// Outside a repository the counts are unknown
+	added, removed = gitNumstat(filepath.Join(t.TempDir(), "loose.txt"))
+	assert.Nil(t, added)
+	assert.Nil(t, removed)
+}
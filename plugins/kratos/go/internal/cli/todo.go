@@ -29,6 +29,27 @@ func getProject() string {
 	return "default"
 }
 
+// projectPath returns the project key sessions and features share:
+// KRATOS_PROJECT, else the absolute path of the git repository or directory
+func projectPath() string {
+	if p := os.Getenv("KRATOS_PROJECT"); p != "" {
+		return p
+	}
+	return projectKey(gitRoot())
+}
+
+// projectKey returns the canonical form of a project given on the command line
+// A path is made absolute and cleaned; a bare name is kept as given
+func projectKey(project string) string {
+	if !strings.ContainsAny(project, `/\`) && project != "." && project != ".." {
+		return project
+	}
+	if abs, err := filepath.Abs(project); err == nil {
+		return abs
+	}
+	return filepath.Clean(project)
+}
+
 // TodoCmd returns the 'todo' subcommand
 func TodoCmd() *cobra.Command {
 	cmd := &cobra.Command{
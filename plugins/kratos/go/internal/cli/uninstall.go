@@ -79,6 +79,7 @@ func removeHooksFromSettings(settingsFile string) error {
 		delete(hooks, "SessionStart")
 		delete(hooks, "PostToolUse")
 		delete(hooks, "Stop")
+		delete(hooks, "SessionEnd")
 
 		// Remove empty hooks object
 		if len(hooks) == 0 {
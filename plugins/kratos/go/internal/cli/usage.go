@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// taskResult is the part of a Task tool result that reports the agent's usage
+type taskResult struct {
+	TotalDurationMs *int64 `json:"totalDurationMs"`
+	DurationMs      *int64 `json:"duration_ms"`
+	Usage           *struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// parseTaskUsage extracts token usage and duration from a PostToolUse payload
+// of the Task tool. It returns nil when the result does not report usage,
+// e.g. a failed run or an older Claude Code version
+func parseTaskUsage(payload []byte) (*models.TokenUsage, error) {
+	var hook struct {
+		ToolResponse json.RawMessage `json:"tool_response"`
+	}
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		return nil, fmt.Errorf("failed to parse hook payload: %w", err)
+	}
+
+	var result taskResult
+	if len(hook.ToolResponse) == 0 || json.Unmarshal(hook.ToolResponse, &result) != nil {
+		return nil, nil
+	}
+	if result.Usage == nil && result.TotalDurationMs == nil && result.DurationMs == nil {
+		return nil, nil
+	}
+
+	usage := &models.TokenUsage{}
+	if u := result.Usage; u != nil {
+		usage.InputTokens = u.InputTokens
+		usage.OutputTokens = u.OutputTokens
+		usage.CacheCreationTokens = u.CacheCreationInputTokens
+		usage.CacheReadTokens = u.CacheReadInputTokens
+	}
+	if result.TotalDurationMs != nil {
+		usage.DurationMs = *result.TotalDurationMs
+	} else if result.DurationMs != nil {
+		usage.DurationMs = *result.DurationMs
+	}
+	return usage, nil
+}
+
+// agentUsage returns the usage of 'step record-agent': the Task result of the
+// hook payload on stdin when hookPayload is set, with the usage flags that
+// were given (flagged holds their values) taking precedence
+func agentUsage(cmd *cobra.Command, hookPayload bool, flagged *models.TokenUsage) (*models.TokenUsage, error) {
+	var usage *models.TokenUsage
+	if hookPayload {
+		payload, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hook payload: %w", err)
+		}
+		if usage, err = parseTaskUsage(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	overrides := map[string]func(u *models.TokenUsage){
+		"input-tokens":          func(u *models.TokenUsage) { u.InputTokens = flagged.InputTokens },
+		"output-tokens":         func(u *models.TokenUsage) { u.OutputTokens = flagged.OutputTokens },
+		"cache-creation-tokens": func(u *models.TokenUsage) { u.CacheCreationTokens = flagged.CacheCreationTokens },
+		"cache-read-tokens":     func(u *models.TokenUsage) { u.CacheReadTokens = flagged.CacheReadTokens },
+		"duration-ms":           func(u *models.TokenUsage) { u.DurationMs = flagged.DurationMs },
+	}
+	for flag, apply := range overrides {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		if usage == nil {
+			usage = &models.TokenUsage{}
+		}
+		apply(usage)
+	}
+	return usage, nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaskUsage(t *testing.T) {
+	payload := `{
+		"hook_event_name": "PostToolUse",
+		"tool_name": "Task",
+		"tool_input": {"subagent_type": "athena", "description": "Write PRD"},
+		"tool_response": {
+			"content": [{"type": "text", "text": "done"}],
+			"totalDurationMs": 93000,
+			"totalTokens": 41000,
+			"usage": {"input_tokens": 12, "output_tokens": 3400, "cache_creation_input_tokens": 9000, "cache_read_input_tokens": 28588}
+		}
+	}`
+	usage, err := parseTaskUsage([]byte(payload))
+	require.NoError(t, err)
+	assert.Equal(t, &models.TokenUsage{InputTokens: 12, OutputTokens: 3400, CacheCreationTokens: 9000, CacheReadTokens: 28588, DurationMs: 93000}, usage)
+
+	// A result without usage, or a plain-text one, reports nothing
+	for _, response := range []string{`{"content": []}`, `"Agent failed"`, `null`} {
+		usage, err = parseTaskUsage([]byte(`{"tool_name": "Task", "tool_response": ` + response + `}`))
+		require.NoError(t, err)
+		assert.Nil(t, usage, response)
+	}
+
+	usage, err = parseTaskUsage([]byte(`{"tool_response": {"duration_ms": 500}}`))
+	require.NoError(t, err)
+	assert.Equal(t, &models.TokenUsage{DurationMs: 500}, usage)
+
+	_, err = parseTaskUsage([]byte(`not json`))
+	assert.Error(t, err)
+}
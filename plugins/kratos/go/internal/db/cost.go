@@ -0,0 +1,153 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pricing"
+)
+
+// CostFilter narrows Cost
+// Since/Until are Unix epoch ms (0 = unbounded) and select agent spawns by timestamp
+type CostFilter struct {
+	Project     string
+	FeatureName string
+	Since       int64
+	Until       int64
+}
+
+// Cost prices the token usage of agent spawns and totals it by feature,
+// agent, model and day
+// A spawn belongs to its session's feature; days are local calendar dates
+func Cost(db *sql.DB, filter CostFilter, prices pricing.Table) (*models.CostReport, error) {
+	query := `
+		SELECT st.timestamp, COALESCE(st.agent_name, ''), COALESCE(st.agent_model, ''),
+		       COALESCE(se.feature_name, ''),
+		       st.input_tokens, st.output_tokens, st.cache_creation_tokens, st.cache_read_tokens, st.duration_ms
+		FROM steps st
+		LEFT JOIN sessions se ON se.session_id = st.session_id
+		WHERE st.step_type = 'agent_spawn'
+	`
+	var args []interface{}
+	if filter.Project != "" {
+		query += " AND se.project = ?"
+		args = append(args, filter.Project)
+	}
+	if filter.FeatureName != "" {
+		query += " AND se.feature_name = ?"
+		args = append(args, filter.FeatureName)
+	}
+	if filter.Since > 0 {
+		query += " AND st.timestamp >= ?"
+		args = append(args, filter.Since)
+	}
+	if filter.Until > 0 {
+		query += " AND st.timestamp <= ?"
+		args = append(args, filter.Until)
+	}
+	query += " ORDER BY st.timestamp ASC, st.id ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent usage: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.CostReport{
+		Since:   filter.Since,
+		Until:   filter.Until,
+		Project: filter.Project,
+		Total:   &models.CostLine{Name: "total"},
+	}
+	features, agents, modelLines, days := costLines{}, costLines{}, costLines{}, costLines{}
+	unpriced := map[string]bool{}
+
+	for rows.Next() {
+		var ts int64
+		var agent, model, feature string
+		var columns [5]sql.NullInt64
+		if err := rows.Scan(&ts, &agent, &model, &feature,
+			&columns[0], &columns[1], &columns[2], &columns[3], &columns[4]); err != nil {
+			return nil, fmt.Errorf("failed to scan agent usage: %w", err)
+		}
+		usage := scanUsage(columns)
+		if usage == nil {
+			report.UnmeteredSpawns++
+			continue
+		}
+
+		cost, priced := 0.0, true
+		if price, ok := prices.Lookup(model); ok {
+			cost = price.Cost(usage)
+		} else {
+			priced = false
+			unpriced[model] = true
+		}
+
+		day := time.UnixMilli(ts).Format("2006-01-02")
+		for _, line := range []*models.CostLine{
+			report.Total, features.get(feature), agents.get(agent), modelLines.get(model), days.get(day),
+		} {
+			line.Spawns++
+			line.Add(usage)
+			line.CostUSD += cost
+			if !priced {
+				line.Unpriced++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Total.CostUSD = roundCost(report.Total.CostUSD)
+	report.ByFeature = features.byCost()
+	report.ByAgent = agents.byCost()
+	report.ByModel = modelLines.byCost()
+	report.ByDay = days.byName()
+	for model := range unpriced {
+		report.UnpricedModels = append(report.UnpricedModels, model)
+	}
+	sort.Strings(report.UnpricedModels)
+	return report, nil
+}
+
+// costLines indexes the lines of one breakdown by name
+type costLines map[string]*models.CostLine
+
+// get returns the named line, creating it on first use
+func (c costLines) get(name string) *models.CostLine {
+	line, ok := c[name]
+	if !ok {
+		line = &models.CostLine{Name: name}
+		c[name] = line
+	}
+	return line
+}
+
+// byCost returns the lines most expensive first, then by name
+func (c costLines) byCost() []*models.CostLine {
+	lines := c.byName()
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].CostUSD > lines[j].CostUSD })
+	return lines
+}
+
+// byName returns the lines sorted by name, with costs rounded
+func (c costLines) byName() []*models.CostLine {
+	lines := make([]*models.CostLine, 0, len(c))
+	for _, line := range c {
+		line.CostUSD = roundCost(line.CostUSD)
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Name < lines[j].Name })
+	return lines
+}
+
+// roundCost rounds a USD amount to a hundredth of a cent
+func roundCost(usd float64) float64 {
+	return math.Round(usd*1e4) / 1e4
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/pricing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCost(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	day1 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local).UnixMilli()
+	day2 := time.Date(2026, 3, 3, 10, 0, 0, 0, time.Local).UnixMilli()
+
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s1", Project: "app", FeatureName: stringPtr("auth"), StartedAt: day1, Status: "active"}))
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s2", Project: "app", FeatureName: stringPtr("billing"), StartedAt: day2, Status: "active"}))
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s3", Project: "other", StartedAt: day2, Status: "active"}))
+
+	spawn := func(sessionID, agent, model string, ts int64, usage *models.TokenUsage) {
+		t.Helper()
+		require.NoError(t, CreateStep(db, &models.Step{
+			SessionID: sessionID, StepNumber: ts, StepType: "agent_spawn", Timestamp: ts,
+			AgentName: &agent, AgentModel: &model, Action: "spawn " + agent, Usage: usage,
+		}))
+	}
+	spawn("s1", "athena", "opus", day1, &models.TokenUsage{InputTokens: 100000, OutputTokens: 20000, DurationMs: 60000})
+	spawn("s1", "ares", "sonnet", day1+1, &models.TokenUsage{InputTokens: 1000000, CacheReadTokens: 1000000})
+	spawn("s1", "hermes", "sonnet", day1+2, nil)
+	spawn("s2", "ares", "local-llm", day2, &models.TokenUsage{OutputTokens: 500})
+	spawn("s3", "ares", "sonnet", day2, &models.TokenUsage{InputTokens: 1000000})
+
+	report, err := Cost(db, CostFilter{Project: "app"}, pricing.Default())
+	require.NoError(t, err)
+
+	// opus: 0.1M in * $15 + 0.02M out * $75 = 3.0; sonnet: 1M in * $3 + 1M cache read * $0.30 = 3.3
+	assert.Equal(t, 3, report.Total.Spawns)
+	assert.Equal(t, 6.3, report.Total.CostUSD)
+	assert.Equal(t, int64(1100000), report.Total.InputTokens)
+	assert.Equal(t, 1, report.Total.Unpriced)
+	assert.Equal(t, 1, report.UnmeteredSpawns)
+	assert.Equal(t, []string{"local-llm"}, report.UnpricedModels)
+
+	require.Len(t, report.ByFeature, 2)
+	assert.Equal(t, "auth", report.ByFeature[0].Name)
+	assert.Equal(t, 6.3, report.ByFeature[0].CostUSD)
+	assert.Equal(t, int64(60000), report.ByFeature[0].DurationMs)
+
+	require.Len(t, report.ByAgent, 2)
+	assert.Equal(t, "ares", report.ByAgent[0].Name)
+	assert.Equal(t, 2, report.ByAgent[0].Spawns)
+	assert.Equal(t, 3.3, report.ByAgent[0].CostUSD)
+
+	require.Len(t, report.ByModel, 3)
+	assert.Equal(t, "sonnet", report.ByModel[0].Name)
+	assert.Equal(t, "local-llm", report.ByModel[2].Name)
+
+	require.Len(t, report.ByDay, 2)
+	assert.Equal(t, "2026-03-02", report.ByDay[0].Name)
+	assert.Equal(t, 6.3, report.ByDay[0].CostUSD)
+	assert.Equal(t, "2026-03-03", report.ByDay[1].Name)
+
+	// Window and feature filters
+	report, err = Cost(db, CostFilter{Since: day2}, pricing.Default())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Total.Spawns)
+	assert.Equal(t, 3.0, report.Total.CostUSD)
+
+	report, err = Cost(db, CostFilter{FeatureName: "auth", Until: day1}, pricing.Default())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Total.Spawns)
+	assert.Equal(t, "athena", report.ByAgent[0].Name)
+}
@@ -19,9 +19,23 @@ func GetDBPath() string {
 	return filepath.Join(home, ".kratos", "memory.db")
 }
 
-// GetConnection establishes a connection to the SQLite database
-// Automatically creates the directory if it doesn't exist
+// GetConnection establishes a connection to the SQLite database and applies
+// any pending migrations, so every command sees the current schema
 func GetConnection() (*sql.DB, error) {
+	db, err := OpenConnection()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return db, nil
+}
+
+// OpenConnection establishes a connection to the SQLite database as is
+// Automatically creates the directory if it doesn't exist
+func OpenConnection() (*sql.DB, error) {
 	dbPath := GetDBPath()
 
 	// Ensure directory exists
@@ -40,6 +54,7 @@ func GetConnection() (*sql.DB, error) {
 		"PRAGMA journal_mode = WAL",
 		"PRAGMA synchronous = NORMAL",
 		"PRAGMA foreign_keys = ON",
+		"PRAGMA busy_timeout = 5000",
 	}
 
 	for _, pragma := range pragmas {
@@ -0,0 +1,265 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// decisionColumns is the column list shared by every decision SELECT
+const decisionColumns = `
+	d.id, d.session_id, d.step_id, d.feature_name, d.agent_name, d.timestamp,
+	d.decision_type, d.question, d.choice, d.alternatives, d.rationale, d.impact`
+
+// DecisionFilter narrows the result of ListDecisions
+// Empty fields are ignored
+type DecisionFilter struct {
+	Project      string
+	FeatureName  string
+	SessionID    string
+	DecisionType string
+	AgentName    string
+	Limit        int
+}
+
+// RecordDecision inserts a decision and a matching "decision" step in the session timeline
+// Both rows are written in one transaction, so a failed decision leaves no
+// orphan step. The decision's ID, StepID and Timestamp are filled in on success
+func RecordDecision(db *sql.DB, decision *models.Decision) error {
+	if decision.Timestamp == 0 {
+		decision.Timestamp = time.Now().UnixMilli()
+	}
+	if decision.DecisionType == "" {
+		decision.DecisionType = "implementation"
+	}
+
+	var alternatives *string
+	if len(decision.Alternatives) > 0 {
+		data, err := json.Marshal(decision.Alternatives)
+		if err != nil {
+			return fmt.Errorf("failed to encode alternatives: %w", err)
+		}
+		s := string(data)
+		alternatives = &s
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stepID := decision.StepID
+	if stepID == nil {
+		id, err := recordDecisionStep(tx, decision)
+		if err != nil {
+			return err
+		}
+		stepID = &id
+	}
+
+	query := `
+		INSERT INTO decisions (
+			session_id, step_id, feature_name, agent_name, timestamp,
+			decision_type, question, choice, alternatives, rationale, impact
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(query,
+		decision.SessionID,
+		stepID,
+		decision.FeatureName,
+		decision.AgentName,
+		decision.Timestamp,
+		decision.DecisionType,
+		decision.Question,
+		decision.Choice,
+		alternatives,
+		decision.Rationale,
+		decision.Impact,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record decision: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit decision: %w", err)
+	}
+
+	decision.ID = id
+	decision.StepID = stepID
+	return nil
+}
+
+// recordDecisionStep adds a "decision" step so the choice shows up in the session timeline
+func recordDecisionStep(tx *sql.Tx, decision *models.Decision) (int64, error) {
+	var stepNum int64
+	err := tx.QueryRow("SELECT COALESCE(MAX(step_number), 0) + 1 FROM steps WHERE session_id = ?", decision.SessionID).Scan(&stepNum)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get step number: %w", err)
+	}
+
+	choice := decision.Choice
+	step := &models.Step{
+		SessionID:  decision.SessionID,
+		StepNumber: stepNum,
+		StepType:   "decision",
+		Timestamp:  decision.Timestamp,
+		AgentName:  decision.AgentName,
+		Action:     decision.Question,
+		Target:     decision.FeatureName,
+		Result:     &choice,
+		Context:    decision.Rationale,
+	}
+
+	if err := createStep(tx, step); err != nil {
+		return 0, err
+	}
+	if err := incrementSessionSteps(tx, decision.SessionID); err != nil {
+		return 0, err
+	}
+
+	return step.ID, nil
+}
+
+// GetDecision retrieves a single decision by ID
+func GetDecision(db *sql.DB, id int64) (*models.Decision, error) {
+	query := `SELECT ` + decisionColumns + ` FROM decisions d WHERE d.id = ?`
+
+	rows, err := db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decision: %w", err)
+	}
+	defer rows.Close()
+
+	decisions, err := scanDecisions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(decisions) == 0 {
+		return nil, fmt.Errorf("decision %d not found", id)
+	}
+
+	return decisions[0], nil
+}
+
+// ListDecisions returns decisions matching the filter, newest first
+func ListDecisions(db *sql.DB, filter DecisionFilter) ([]*models.Decision, error) {
+	query := `SELECT ` + decisionColumns + ` FROM decisions d`
+	var where []string
+	var args []interface{}
+
+	if filter.Project != "" {
+		query += ` JOIN sessions s ON s.session_id = d.session_id`
+		where = append(where, "s.project = ?")
+		args = append(args, filter.Project)
+	}
+	if filter.FeatureName != "" {
+		where = append(where, "d.feature_name = ?")
+		args = append(args, filter.FeatureName)
+	}
+	if filter.SessionID != "" {
+		where = append(where, "d.session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if filter.DecisionType != "" {
+		where = append(where, "d.decision_type = ?")
+		args = append(args, filter.DecisionType)
+	}
+	if filter.AgentName != "" {
+		where = append(where, "d.agent_name = ?")
+		args = append(args, filter.AgentName)
+	}
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY d.timestamp DESC, d.id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list decisions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDecisions(rows)
+}
+
+// SearchDecisions performs a full-text search over question, choice, rationale and impact
+// Results are ordered by relevance
+func SearchDecisions(db *sql.DB, searchTerm string, limit int) ([]*models.Decision, error) {
+	match := ftsQuery(searchTerm)
+	if match == "" {
+		return []*models.Decision{}, nil
+	}
+
+	query := `
+		SELECT ` + decisionColumns + `
+		FROM decisions_fts
+		JOIN decisions d ON d.id = decisions_fts.rowid
+		WHERE decisions_fts MATCH ?
+		ORDER BY bm25(decisions_fts), d.timestamp DESC
+		LIMIT ?
+	`
+
+	rows, err := db.Query(query, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search decisions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDecisions(rows)
+}
+
+// scanDecisions is a helper function to scan multiple decision rows
+func scanDecisions(rows *sql.Rows) ([]*models.Decision, error) {
+	decisions := []*models.Decision{}
+	for rows.Next() {
+		d := &models.Decision{}
+		var alternatives sql.NullString
+		err := rows.Scan(
+			&d.ID,
+			&d.SessionID,
+			&d.StepID,
+			&d.FeatureName,
+			&d.AgentName,
+			&d.Timestamp,
+			&d.DecisionType,
+			&d.Question,
+			&d.Choice,
+			&alternatives,
+			&d.Rationale,
+			&d.Impact,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan decision: %w", err)
+		}
+		if alternatives.Valid && alternatives.String != "" {
+			if err := json.Unmarshal([]byte(alternatives.String), &d.Alternatives); err != nil {
+				// Legacy rows may hold plain text rather than a JSON array
+				d.Alternatives = []string{alternatives.String}
+			}
+		}
+		decisions = append(decisions, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return decisions, nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDecision builds a decision with the given question and choice
+func newDecision(sessionID, feature, agent, question, choice string) *models.Decision {
+	return &models.Decision{
+		SessionID:    sessionID,
+		FeatureName:  &feature,
+		AgentName:    &agent,
+		DecisionType: "architecture",
+		Question:     question,
+		Choice:       choice,
+	}
+}
+
+// TestRecordDecision tests recording a decision and reading it back
+func TestRecordDecision(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	err := CreateSession(db, &models.Session{
+		SessionID: "sess-1",
+		Project:   "/test/project",
+		StartedAt: time.Now().UnixMilli(),
+		Status:    "active",
+	})
+	require.NoError(t, err)
+
+	rationale := "Already operated in production"
+	decision := newDecision("sess-1", "auth", "apollo", "Session storage", "Postgres")
+	decision.Alternatives = []string{"Redis", "JWT only"}
+	decision.Rationale = &rationale
+
+	err = RecordDecision(db, decision)
+	require.NoError(t, err)
+	assert.Greater(t, decision.ID, int64(0))
+	assert.NotZero(t, decision.Timestamp)
+	require.NotNil(t, decision.StepID)
+
+	got, err := GetDecision(db, decision.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Session storage", got.Question)
+	assert.Equal(t, "Postgres", got.Choice)
+	assert.Equal(t, []string{"Redis", "JWT only"}, got.Alternatives)
+	assert.Equal(t, "apollo", *got.AgentName)
+	assert.Equal(t, rationale, *got.Rationale)
+
+	// A matching decision step is added to the timeline
+	steps, err := GetStepsForSession(db, "sess-1")
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "decision", steps[0].StepType)
+	assert.Equal(t, *decision.StepID, steps[0].ID)
+
+	session, err := GetSession(db, "sess-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), session.TotalSteps)
+}
+
+// TestRecordDecision_DefaultType tests that an empty type defaults to implementation
+func TestRecordDecision_DefaultType(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "sess-1", Project: "/p", StartedAt: 1, Status: "active"}))
+
+	decision := &models.Decision{SessionID: "sess-1", Question: "q", Choice: "c"}
+	require.NoError(t, RecordDecision(db, decision))
+
+	got, err := GetDecision(db, decision.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "implementation", got.DecisionType)
+	assert.Nil(t, got.Alternatives)
+}
+
+// TestRecordDecision_RollsBackStep tests that a failed decision insert leaves no orphan step
+func TestRecordDecision_RollsBackStep(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "sess-1", Project: "/p", StartedAt: 1, Status: "active"}))
+
+	_, err := db.Exec(`CREATE TRIGGER fail_decision BEFORE INSERT ON decisions BEGIN SELECT RAISE(ABORT, 'boom'); END`)
+	require.NoError(t, err)
+
+	decision := &models.Decision{SessionID: "sess-1", Question: "q", Choice: "c"}
+	err = RecordDecision(db, decision)
+	require.Error(t, err)
+	assert.Nil(t, decision.StepID)
+
+	steps, err := GetStepsForSession(db, "sess-1")
+	require.NoError(t, err)
+	assert.Empty(t, steps)
+
+	session, err := GetSession(db, "sess-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), session.TotalSteps)
+}
+
+// TestGetDecision_NotFound tests retrieving a missing decision
+func TestGetDecision_NotFound(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	_, err := GetDecision(db, 42)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+// TestListDecisions_Filters tests filtering by project, feature, agent and type
+func TestListDecisions_Filters(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	now := time.Now().UnixMilli()
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "a", Project: "/p/a", StartedAt: now, Status: "active"}))
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "b", Project: "/p/b", StartedAt: now, Status: "active"}))
+
+	d1 := newDecision("a", "auth", "themis", "Token format", "JWT")
+	d1.Timestamp = now - 2000
+	d2 := newDecision("a", "billing", "apollo", "Queue", "SQS")
+	d2.Timestamp = now - 1000
+	d3 := newDecision("b", "auth", "apollo", "Cache", "Redis")
+	d3.DecisionType = "trade_off"
+	d3.Timestamp = now
+	for _, d := range []*models.Decision{d1, d2, d3} {
+		require.NoError(t, RecordDecision(db, d))
+	}
+
+	all, err := ListDecisions(db, DecisionFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "Cache", all[0].Question, "newest first")
+
+	byProject, err := ListDecisions(db, DecisionFilter{Project: "/p/a"})
+	require.NoError(t, err)
+	assert.Len(t, byProject, 2)
+
+	byFeature, err := ListDecisions(db, DecisionFilter{FeatureName: "auth"})
+	require.NoError(t, err)
+	assert.Len(t, byFeature, 2)
+
+	byAgent, err := ListDecisions(db, DecisionFilter{AgentName: "apollo", FeatureName: "auth"})
+	require.NoError(t, err)
+	require.Len(t, byAgent, 1)
+	assert.Equal(t, "Cache", byAgent[0].Question)
+
+	byType, err := ListDecisions(db, DecisionFilter{DecisionType: "trade_off"})
+	require.NoError(t, err)
+	assert.Len(t, byType, 1)
+
+	limited, err := ListDecisions(db, DecisionFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
+
+// TestSearchDecisions tests full-text search over decisions
+func TestSearchDecisions(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s", Project: "/p", StartedAt: 1, Status: "active"}))
+
+	rationale := "Retries must not hammer the upstream API"
+	d1 := newDecision("s", "sync", "themis", "Retry policy", "Exponential backoff")
+	d1.Rationale = &rationale
+	d2 := newDecision("s", "sync", "apollo", "Database", "SQLite")
+	require.NoError(t, RecordDecision(db, d1))
+	require.NoError(t, RecordDecision(db, d2))
+
+	results, err := SearchDecisions(db, "retry", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Retry policy", results[0].Question)
+
+	// Matches in rationale too
+	results, err = SearchDecisions(db, "upstream", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Punctuation does not break the FTS parser
+	results, err = SearchDecisions(db, `what's "sqlite"?`, 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Empty query returns nothing
+	results, err = SearchDecisions(db, "   ", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// MaxFeatureStage is the highest pipeline stage with a completion column
+const MaxFeatureStage = 11
+
+// featureColumns is the column list shared by every feature SELECT
+var featureColumns = `
+	id, feature_name, project, created_at, updated_at, current_stage,
+	current_stage_name, status, priority, status_path, ` + stageColumns() + `,
+	prd_path, tech_spec_path, test_plan_path, implementation_notes_path,
+	description, total_sessions, total_agents_used`
+
+// FeatureFilter narrows the result of ListFeatures
+// Empty fields are ignored
+type FeatureFilter struct {
+	Project string
+	Status  string
+	Limit   int
+}
+
+// stageColumns returns "stage_0_completed, ..., stage_N_completed"
+func stageColumns() string {
+	cols := make([]string, 0, MaxFeatureStage+1)
+	for i := 0; i <= MaxFeatureStage; i++ {
+		cols = append(cols, fmt.Sprintf("stage_%d_completed", i))
+	}
+	return strings.Join(cols, ", ")
+}
+
+// UpsertFeature inserts or replaces the mirrored state of a feature
+// Features are keyed by (project, feature_name); created_at is kept from the
+// first insert and session/agent totals are recomputed from the sessions table
+func UpsertFeature(db *sql.DB, feature *models.Feature) error {
+	if feature.Status == "" {
+		feature.Status = "in_progress"
+	}
+
+	stages := make([]interface{}, MaxFeatureStage+1)
+	for i := range stages {
+		if ts, ok := feature.StagesCompleted[i]; ok {
+			stages[i] = ts
+		}
+	}
+
+	var updates []string
+	for _, col := range []string{
+		"updated_at", "current_stage", "current_stage_name", "status", "priority",
+		"status_path", "prd_path", "tech_spec_path", "test_plan_path",
+		"implementation_notes_path", "description",
+	} {
+		updates = append(updates, col+" = excluded."+col)
+	}
+	for i := 0; i <= MaxFeatureStage; i++ {
+		col := fmt.Sprintf("stage_%d_completed", i)
+		updates = append(updates, col+" = excluded."+col)
+	}
+
+	query := `
+		INSERT INTO features (
+			feature_name, project, created_at, updated_at, current_stage,
+			current_stage_name, status, priority, status_path, ` + stageColumns() + `,
+			prd_path, tech_spec_path, test_plan_path, implementation_notes_path, description
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?` + strings.Repeat(", ?", MaxFeatureStage+1) + `, ?, ?, ?, ?, ?)
+		ON CONFLICT (project, feature_name) DO UPDATE SET ` + strings.Join(updates, ", ")
+
+	args := []interface{}{
+		feature.FeatureName,
+		feature.Project,
+		feature.CreatedAt,
+		feature.UpdatedAt,
+		feature.CurrentStage,
+		feature.CurrentStageName,
+		feature.Status,
+		feature.Priority,
+		feature.StatusPath,
+	}
+	args = append(args, stages...)
+	args = append(args,
+		feature.PRDPath,
+		feature.TechSpecPath,
+		feature.TestPlanPath,
+		feature.ImplementationNotesPath,
+		feature.Description,
+	)
+
+	if _, err := db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to upsert feature: %w", err)
+	}
+
+	// Sessions and features share the canonical project key
+	_, err := db.Exec(`
+		UPDATE features SET
+			total_sessions = (
+				SELECT COUNT(*) FROM sessions s
+				WHERE s.feature_name = features.feature_name
+				  AND s.project = features.project
+			),
+			total_agents_used = (
+				SELECT COUNT(DISTINCT st.agent_name) FROM steps st
+				JOIN sessions s ON s.session_id = st.session_id
+				WHERE st.agent_name IS NOT NULL
+				  AND s.feature_name = features.feature_name
+				  AND s.project = features.project
+			)
+		WHERE project = ? AND feature_name = ?
+	`, feature.Project, feature.FeatureName)
+	if err != nil {
+		return fmt.Errorf("failed to update feature totals: %w", err)
+	}
+
+	stored, err := GetFeature(db, feature.Project, feature.FeatureName)
+	if err != nil {
+		return err
+	}
+	*feature = *stored
+	return nil
+}
+
+// GetFeature retrieves a feature by name
+// An empty project matches any project, preferring the most recently updated
+func GetFeature(db *sql.DB, project, featureName string) (*models.Feature, error) {
+	query := `SELECT ` + featureColumns + ` FROM features WHERE feature_name = ?`
+	args := []interface{}{featureName}
+	if project != "" {
+		query += " AND project = ?"
+		args = append(args, project)
+	}
+	query += " ORDER BY updated_at DESC LIMIT 1"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature: %w", err)
+	}
+	defer rows.Close()
+
+	features, err := scanFeatures(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("feature not found: %s", featureName)
+	}
+
+	return features[0], nil
+}
+
+// ListFeatures returns features matching the filter, most recently updated first
+func ListFeatures(db *sql.DB, filter FeatureFilter) ([]*models.Feature, error) {
+	query := `SELECT ` + featureColumns + ` FROM features`
+	var where []string
+	var args []interface{}
+
+	if filter.Project != "" {
+		where = append(where, "project = ?")
+		args = append(args, filter.Project)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY updated_at DESC, id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list features: %w", err)
+	}
+	defer rows.Close()
+
+	return scanFeatures(rows)
+}
+
+// scanFeatures is a helper function to scan multiple feature rows
+func scanFeatures(rows *sql.Rows) ([]*models.Feature, error) {
+	features := []*models.Feature{}
+	for rows.Next() {
+		f := &models.Feature{StagesCompleted: map[int]int64{}}
+		stages := make([]sql.NullInt64, MaxFeatureStage+1)
+
+		dest := []interface{}{
+			&f.ID,
+			&f.FeatureName,
+			&f.Project,
+			&f.CreatedAt,
+			&f.UpdatedAt,
+			&f.CurrentStage,
+			&f.CurrentStageName,
+			&f.Status,
+			&f.Priority,
+			&f.StatusPath,
+		}
+		for i := range stages {
+			dest = append(dest, &stages[i])
+		}
+		dest = append(dest,
+			&f.PRDPath,
+			&f.TechSpecPath,
+			&f.TestPlanPath,
+			&f.ImplementationNotesPath,
+			&f.Description,
+			&f.TotalSessions,
+			&f.TotalAgentsUsed,
+		)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan feature: %w", err)
+		}
+		for i, ts := range stages {
+			if ts.Valid {
+				f.StagesCompleted[i] = ts.Int64
+			}
+		}
+		features = append(features, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return features, nil
+}
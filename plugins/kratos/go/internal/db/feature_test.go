@@ -0,0 +1,179 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFeature builds a feature at the given stage
+func newFeature(project, name string, stage int) *models.Feature {
+	now := time.Now().UnixMilli()
+	return &models.Feature{
+		FeatureName:     name,
+		Project:         project,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		CurrentStage:    stage,
+		StagesCompleted: map[int]int64{},
+	}
+}
+
+// TestUpsertFeature_Insert tests inserting a feature and reading it back
+func TestUpsertFeature_Insert(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	stageName := "2-prd-review"
+	prd := "/repo/.claude/feature/auth/prd.md"
+	feature := newFeature("repo", "auth", 2)
+	feature.CurrentStageName = &stageName
+	feature.PRDPath = &prd
+	feature.StagesCompleted[1] = 1707738000000
+
+	require.NoError(t, UpsertFeature(db, feature))
+	assert.Greater(t, feature.ID, int64(0))
+	assert.Equal(t, "in_progress", feature.Status)
+
+	got, err := GetFeature(db, "repo", "auth")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.CurrentStage)
+	assert.Equal(t, stageName, *got.CurrentStageName)
+	assert.Equal(t, prd, *got.PRDPath)
+	assert.Equal(t, map[int]int64{1: 1707738000000}, got.StagesCompleted)
+}
+
+// TestUpsertFeature_Update tests that a second upsert replaces state but keeps created_at
+func TestUpsertFeature_Update(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	first := newFeature("repo", "auth", 1)
+	first.CreatedAt = 1000
+	first.StagesCompleted[1] = 2000
+	require.NoError(t, UpsertFeature(db, first))
+
+	second := newFeature("repo", "auth", 11)
+	second.Status = "completed"
+	second.StagesCompleted[11] = 3000
+	require.NoError(t, UpsertFeature(db, second))
+
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, int64(1000), second.CreatedAt)
+	assert.Equal(t, 11, second.CurrentStage)
+	assert.Equal(t, "completed", second.Status)
+	// Stage columns mirror the latest state; stage 1 is no longer recorded
+	assert.Equal(t, map[int]int64{11: 3000}, second.StagesCompleted)
+}
+
+// TestUpsertFeature_SameNameDifferentProjects tests that names are scoped by project
+func TestUpsertFeature_SameNameDifferentProjects(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	require.NoError(t, UpsertFeature(db, newFeature("repo-a", "auth", 1)))
+	require.NoError(t, UpsertFeature(db, newFeature("repo-b", "auth", 5)))
+
+	features, err := ListFeatures(db, FeatureFilter{})
+	require.NoError(t, err)
+	assert.Len(t, features, 2)
+
+	got, err := GetFeature(db, "repo-a", "auth")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.CurrentStage)
+}
+
+// TestUpsertFeature_Totals tests that session and agent totals come from sessions and steps
+func TestUpsertFeature_Totals(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	feature := "auth"
+	sessions := map[string]string{"sess-1": "/work/repo", "sess-2": "/work/repo", "sess-3": "/oss/repo"}
+	for id, project := range sessions {
+		err := CreateSession(db, &models.Session{
+			SessionID:   id,
+			Project:     project,
+			FeatureName: &feature,
+			StartedAt:   time.Now().UnixMilli(),
+			Status:      "completed",
+		})
+		require.NoError(t, err)
+	}
+	for i, agent := range []string{"athena", "hephaestus", "athena"} {
+		agent := agent
+		err := CreateStep(db, &models.Step{
+			SessionID:  "sess-1",
+			StepNumber: int64(i + 1),
+			StepType:   "agent_spawn",
+			Timestamp:  time.Now().UnixMilli(),
+			AgentName:  &agent,
+			Action:     "spawn",
+		})
+		require.NoError(t, err)
+	}
+
+	// A repository that only shares the directory name is not counted
+	f := newFeature("/work/repo", "auth", 5)
+	require.NoError(t, UpsertFeature(db, f))
+	assert.Equal(t, int64(2), f.TotalSessions)
+	assert.Equal(t, int64(2), f.TotalAgentsUsed)
+}
+
+// TestGetFeature_AnyProject tests lookup without a project
+func TestGetFeature_AnyProject(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	older := newFeature("repo-a", "auth", 1)
+	older.UpdatedAt = 1000
+	require.NoError(t, UpsertFeature(db, older))
+
+	newer := newFeature("repo-b", "auth", 3)
+	newer.UpdatedAt = 2000
+	require.NoError(t, UpsertFeature(db, newer))
+
+	got, err := GetFeature(db, "", "auth")
+	require.NoError(t, err)
+	assert.Equal(t, "repo-b", got.Project)
+}
+
+// TestGetFeature_NotFound tests the error for a missing feature
+func TestGetFeature_NotFound(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	_, err := GetFeature(db, "", "missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "feature not found")
+}
+
+// TestListFeatures_Filters tests project, status and limit filters
+func TestListFeatures_Filters(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	a := newFeature("repo-a", "one", 1)
+	a.UpdatedAt = 1000
+	b := newFeature("repo-a", "two", 11)
+	b.UpdatedAt = 2000
+	b.Status = "completed"
+	c := newFeature("repo-b", "three", 4)
+	c.UpdatedAt = 3000
+	for _, f := range []*models.Feature{a, b, c} {
+		require.NoError(t, UpsertFeature(db, f))
+	}
+
+	all, err := ListFeatures(db, FeatureFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "three", all[0].FeatureName)
+
+	byProject, err := ListFeatures(db, FeatureFilter{Project: "repo-a"})
+	require.NoError(t, err)
+	assert.Len(t, byProject, 2)
+
+	active, err := ListFeatures(db, FeatureFilter{Status: "in_progress"})
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	limited, err := ListFeatures(db, FeatureFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
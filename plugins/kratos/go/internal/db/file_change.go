@@ -0,0 +1,262 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// Change types recorded in file_changes
+const (
+	ChangeCreated  = "created"
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+	ChangeRenamed  = "renamed"
+)
+
+// fileChangeColumns is the column list shared by every file change SELECT
+const fileChangeColumns = `
+	fc.id, fc.session_id, fc.step_id, fc.timestamp, fc.file_path, fc.change_type,
+	fc.old_path, fc.description, fc.lines_added, fc.lines_removed, fc.agent_name,
+	COALESCE(fc.feature_name, s.feature_name)`
+
+// FileChangeFilter narrows file change queries
+// Empty fields are ignored; Since/Until are Unix epoch ms (0 = unbounded)
+type FileChangeFilter struct {
+	Project     string
+	SessionID   string
+	FeatureName string
+	AgentName   string
+	PathPrefix  string
+	Since       int64
+	Until       int64
+	Limit       int
+}
+
+// RecordFileChangeDetail records a file_modify step and its structured file_changes row
+// ChangeType defaults from the action (Write, Edit, created, ...) and FeatureName
+// from the session; ID, StepID and Timestamp are filled in on success
+func RecordFileChangeDetail(db *sql.DB, action string, change *models.FileChange) error {
+	if change.Timestamp == 0 {
+		change.Timestamp = time.Now().UnixMilli()
+	}
+	if change.ChangeType == "" {
+		change.ChangeType = changeTypeFor(action)
+	}
+	if change.FeatureName == nil {
+		session, err := GetSession(db, change.SessionID)
+		if err != nil {
+			return err
+		}
+		change.FeatureName = session.FeatureName
+	}
+
+	var stepNum int64
+	err := db.QueryRow("SELECT COALESCE(MAX(step_number), 0) + 1 FROM steps WHERE session_id = ?", change.SessionID).Scan(&stepNum)
+	if err != nil {
+		return fmt.Errorf("failed to get step number: %w", err)
+	}
+
+	filePath := change.FilePath
+	step := &models.Step{
+		SessionID:  change.SessionID,
+		StepNumber: stepNum,
+		StepType:   "file_modify",
+		Timestamp:  change.Timestamp,
+		AgentName:  change.AgentName,
+		Action:     action,
+		Target:     &filePath,
+		Context:    change.Description,
+	}
+
+	if err := CreateStep(db, step); err != nil {
+		return err
+	}
+	if err := IncrementSessionSteps(db, change.SessionID); err != nil {
+		return err
+	}
+	change.StepID = &step.ID
+
+	query := `
+		INSERT INTO file_changes (
+			session_id, step_id, timestamp, file_path, change_type, old_path,
+			description, lines_added, lines_removed, agent_name, feature_name
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.Exec(query,
+		change.SessionID,
+		change.StepID,
+		change.Timestamp,
+		change.FilePath,
+		change.ChangeType,
+		change.OldPath,
+		change.Description,
+		change.LinesAdded,
+		change.LinesRemoved,
+		change.AgentName,
+		change.FeatureName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record file change: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	change.ID = id
+	return nil
+}
+
+// changeTypeFor maps a tool name or free-form action to a change type
+func changeTypeFor(action string) string {
+	switch strings.ToLower(action) {
+	case "created", "create", "new", "add", "added":
+		return ChangeCreated
+	case "deleted", "delete", "remove", "removed", "rm":
+		return ChangeDeleted
+	case "renamed", "rename", "move", "moved", "mv":
+		return ChangeRenamed
+	default:
+		return ChangeModified
+	}
+}
+
+// fileChangeWhere builds the WHERE clause for a file change filter
+// The query must alias file_changes as fc and join sessions as s
+func fileChangeWhere(filter FileChangeFilter) (string, []interface{}) {
+	var where []string
+	var args []interface{}
+
+	if filter.Project != "" {
+		where = append(where, "s.project = ?")
+		args = append(args, filter.Project)
+	}
+	if filter.SessionID != "" {
+		where = append(where, "fc.session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if filter.FeatureName != "" {
+		where = append(where, "COALESCE(fc.feature_name, s.feature_name) = ?")
+		args = append(args, filter.FeatureName)
+	}
+	if filter.AgentName != "" {
+		where = append(where, "fc.agent_name = ?")
+		args = append(args, filter.AgentName)
+	}
+	if filter.PathPrefix != "" {
+		// substr avoids LIKE treating _ and % in paths as wildcards
+		where = append(where, "substr(fc.file_path, 1, length(?)) = ?")
+		args = append(args, filter.PathPrefix, filter.PathPrefix)
+	}
+	if filter.Since > 0 {
+		where = append(where, "fc.timestamp >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Until > 0 {
+		where = append(where, "fc.timestamp <= ?")
+		args = append(args, filter.Until)
+	}
+
+	if len(where) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+// ListFileChanges returns file changes matching the filter, newest first
+func ListFileChanges(db *sql.DB, filter FileChangeFilter) ([]*models.FileChange, error) {
+	where, args := fileChangeWhere(filter)
+	query := `SELECT ` + fileChangeColumns + `
+		FROM file_changes fc
+		LEFT JOIN sessions s ON s.session_id = fc.session_id` + where + `
+		ORDER BY fc.timestamp DESC, fc.id DESC`
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []*models.FileChange{}
+	for rows.Next() {
+		c := &models.FileChange{}
+		err := rows.Scan(
+			&c.ID,
+			&c.SessionID,
+			&c.StepID,
+			&c.Timestamp,
+			&c.FilePath,
+			&c.ChangeType,
+			&c.OldPath,
+			&c.Description,
+			&c.LinesAdded,
+			&c.LinesRemoved,
+			&c.AgentName,
+			&c.FeatureName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return changes, nil
+}
+
+// HotFiles returns the most frequently changed files matching the filter
+// Files are ordered by number of changes, then by total lines touched
+func HotFiles(db *sql.DB, filter FileChangeFilter) ([]*models.HotFile, error) {
+	where, args := fileChangeWhere(filter)
+	query := `
+		SELECT fc.file_path,
+		       COUNT(*) AS changes,
+		       COUNT(DISTINCT fc.session_id),
+		       COALESCE(SUM(fc.lines_added), 0) AS added,
+		       COALESCE(SUM(fc.lines_removed), 0) AS removed,
+		       MAX(fc.timestamp)
+		FROM file_changes fc
+		LEFT JOIN sessions s ON s.session_id = fc.session_id` + where + `
+		GROUP BY fc.file_path
+		ORDER BY changes DESC, added + removed DESC, fc.file_path ASC`
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate hot files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []*models.HotFile{}
+	for rows.Next() {
+		f := &models.HotFile{}
+		if err := rows.Scan(&f.FilePath, &f.Changes, &f.Sessions, &f.LinesAdded, &f.LinesRemoved, &f.LastChanged); err != nil {
+			return nil, fmt.Errorf("failed to scan hot file: %w", err)
+		}
+		files = append(files, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecordFileChangeDetail tests that a file change writes both a step and a journal row
+func TestRecordFileChangeDetail(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	feature := "auth"
+	err := CreateSession(db, &models.Session{
+		SessionID:   "sess-1",
+		Project:     "/test/project",
+		FeatureName: &feature,
+		StartedAt:   time.Now().UnixMilli(),
+		Status:      "active",
+	})
+	require.NoError(t, err)
+
+	agent := "ares"
+	added, removed := int64(12), int64(3)
+	change := &models.FileChange{
+		SessionID:    "sess-1",
+		FilePath:     "src/auth/login.go",
+		AgentName:    &agent,
+		LinesAdded:   &added,
+		LinesRemoved: &removed,
+	}
+	require.NoError(t, RecordFileChangeDetail(db, "Edit", change))
+	assert.Greater(t, change.ID, int64(0))
+	require.NotNil(t, change.StepID)
+	assert.Equal(t, ChangeModified, change.ChangeType)
+	require.NotNil(t, change.FeatureName)
+	assert.Equal(t, "auth", *change.FeatureName, "feature defaults to the session's feature")
+
+	steps, err := GetStepsForSession(db, "sess-1")
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "file_modify", steps[0].StepType)
+	assert.Equal(t, "ares", *steps[0].AgentName)
+
+	changes, err := ListFileChanges(db, FileChangeFilter{SessionID: "sess-1"})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "src/auth/login.go", changes[0].FilePath)
+	assert.Equal(t, int64(12), *changes[0].LinesAdded)
+	assert.Equal(t, int64(3), *changes[0].LinesRemoved)
+	assert.Equal(t, *change.StepID, *changes[0].StepID)
+}
+
+// TestRecordFileChange_WritesJournal tests the legacy entry point also fills file_changes
+func TestRecordFileChange_WritesJournal(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	err := CreateSession(db, &models.Session{
+		SessionID: "sess-1",
+		Project:   "/test/project",
+		StartedAt: time.Now().UnixMilli(),
+		Status:    "active",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, RecordFileChange(db, "sess-1", "created", "docs/prd.md"))
+
+	changes, err := ListFileChanges(db, FileChangeFilter{})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeCreated, changes[0].ChangeType)
+	assert.Nil(t, changes[0].LinesAdded)
+}
+
+// TestChangeTypeFor tests mapping actions to change types
+func TestChangeTypeFor(t *testing.T) {
+	assert.Equal(t, ChangeModified, changeTypeFor("Write"))
+	assert.Equal(t, ChangeModified, changeTypeFor("Edit"))
+	assert.Equal(t, ChangeCreated, changeTypeFor("created"))
+	assert.Equal(t, ChangeDeleted, changeTypeFor("Delete"))
+	assert.Equal(t, ChangeRenamed, changeTypeFor("mv"))
+}
+
+// TestListFileChanges_Filters tests feature, agent, path prefix and time filters
+func TestListFileChanges_Filters(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	for _, s := range []struct{ id, feature string }{{"sess-1", "auth"}, {"sess-2", "billing"}} {
+		feature := s.feature
+		err := CreateSession(db, &models.Session{
+			SessionID:   s.id,
+			Project:     "/test/project",
+			FeatureName: &feature,
+			StartedAt:   time.Now().UnixMilli(),
+			Status:      "active",
+		})
+		require.NoError(t, err)
+	}
+
+	ares, hephaestus := "ares", "hephaestus"
+	old := time.Now().Add(-10 * 24 * time.Hour).UnixMilli()
+	entries := []*models.FileChange{
+		{SessionID: "sess-1", FilePath: "src/auth/login.go", AgentName: &ares},
+		{SessionID: "sess-1", FilePath: "src/auth/token.go", AgentName: &ares, Timestamp: old},
+		{SessionID: "sess-1", FilePath: "docs/tech-spec.md", AgentName: &hephaestus},
+		{SessionID: "sess-2", FilePath: "src/billing/invoice.go", AgentName: &ares},
+	}
+	for _, e := range entries {
+		require.NoError(t, RecordFileChangeDetail(db, "Edit", e))
+	}
+
+	byFeatureAgent, err := ListFileChanges(db, FileChangeFilter{FeatureName: "auth", AgentName: "ares"})
+	require.NoError(t, err)
+	assert.Len(t, byFeatureAgent, 2)
+
+	byPrefix, err := ListFileChanges(db, FileChangeFilter{PathPrefix: "src/"})
+	require.NoError(t, err)
+	assert.Len(t, byPrefix, 3)
+
+	recent, err := ListFileChanges(db, FileChangeFilter{Since: time.Now().Add(-24 * time.Hour).UnixMilli()})
+	require.NoError(t, err)
+	assert.Len(t, recent, 3)
+
+	// An underscore in the prefix is not a wildcard
+	none, err := ListFileChanges(db, FileChangeFilter{PathPrefix: "src_"})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+// TestHotFiles tests aggregating changes per file
+func TestHotFiles(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	for _, id := range []string{"sess-1", "sess-2"} {
+		err := CreateSession(db, &models.Session{
+			SessionID: id,
+			Project:   "/test/project",
+			StartedAt: time.Now().UnixMilli(),
+			Status:    "active",
+		})
+		require.NoError(t, err)
+	}
+
+	record := func(sessionID, path string, added, removed int64) {
+		require.NoError(t, RecordFileChangeDetail(db, "Edit", &models.FileChange{
+			SessionID:    sessionID,
+			FilePath:     path,
+			LinesAdded:   &added,
+			LinesRemoved: &removed,
+		}))
+	}
+	record("sess-1", "main.go", 10, 2)
+	record("sess-2", "main.go", 5, 1)
+	record("sess-2", "main.go", 1, 1)
+	record("sess-1", "util.go", 100, 0)
+
+	files, err := HotFiles(db, FileChangeFilter{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "main.go", files[0].FilePath)
+	assert.Equal(t, int64(3), files[0].Changes)
+	assert.Equal(t, int64(2), files[0].Sessions)
+	assert.Equal(t, int64(16), files[0].LinesAdded)
+	assert.Equal(t, int64(4), files[0].LinesRemoved)
+
+	limited, err := HotFiles(db, FileChangeFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
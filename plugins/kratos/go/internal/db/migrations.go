@@ -2,18 +2,277 @@ package db
 
 import (
 	"database/sql"
-	_ "embed"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // schemaSQL embeds the schema.sql file at compile time
 // This ensures the Go binary can initialize the database without external files
 // Note: This is a copy of ../../../memory/schema.sql maintained for Go embedding
+//
 //go:embed schema.sql
 var schemaSQL string
 
-// InitDB initializes the database schema by executing the embedded schema.sql
+// migrationFS embeds the numbered up-migrations applied on top of schema.sql
+// Files are named NNNN_description.sql; the numeric prefix is the schema version
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+// baselineVersion is the schema version created by schema.sql
+const baselineVersion = 1
+
+// migrationLogSQL creates the table recording which migrations were applied and when
+const migrationLogSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TEXT DEFAULT (datetime('now'))
+)`
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	SQL     string `json:"-"`
+}
+
+// AppliedMigration is a row of the schema_migrations log
+type AppliedMigration struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	AppliedAt string `json:"applied_at"`
+}
+
+// InitDB initializes the database schema and applies all pending migrations
 // This function is idempotent - it can be safely called multiple times
 func InitDB(db *sql.DB) error {
-	_, err := db.Exec(schemaSQL)
+	_, err := Migrate(db)
 	return err
 }
+
+// Migrate creates the baseline schema if needed and applies every embedded
+// migration newer than the recorded schema version, returning the ones applied
+func Migrate(db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return applyMigrations(db, migrations)
+}
+
+// Migrations returns the embedded migrations sorted by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrations := []Migration{}
+	seen := map[int]string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: missing numeric prefix", name)
+		}
+		if version <= baselineVersion {
+			return nil, fmt.Errorf("invalid migration %s: version must be greater than %d", name, baselineVersion)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, name)
+		}
+		seen[version] = name
+
+		data, err := migrationFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    strings.TrimSuffix(name, ".sql"),
+			SQL:     string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LatestVersion returns the schema version the binary expects after migrating
+func LatestVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return baselineVersion, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// SchemaVersion returns the version recorded in schema_version
+// Returns 0 for a database that has never been initialized
+func SchemaVersion(db *sql.DB) (int, error) {
+	var exists int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'",
+	).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check schema_version: %w", err)
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	var version int
+	err = db.QueryRow("SELECT version FROM schema_version WHERE id = 1").Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// PendingMigrations returns the embedded migrations not yet applied to db
+func PendingMigrations(db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// applyMigrations runs the baseline schema and then each migration newer than
+// the current version, one transaction per migration
+func applyMigrations(db *sql.DB, migrations []Migration) ([]Migration, error) {
+	if _, err := db.Exec(schemaSQL); err != nil {
+		return nil, fmt.Errorf("failed to apply baseline schema: %w", err)
+	}
+	if _, err := db.Exec(migrationLogSQL); err != nil {
+		return nil, fmt.Errorf("failed to create migration log: %w", err)
+	}
+
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+		current = m.Version
+	}
+
+	return applied, nil
+}
+
+// applyMigration executes a single migration and advances schema_version atomically
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %s: %w", m.Name, err)
+	}
+	defer tx.Rollback()
+
+	// Take the write lock before reading the version, so a migration another
+	// process applied in the meantime is seen instead of run twice
+	if _, err := tx.Exec("UPDATE schema_version SET version = version WHERE id = 1"); err != nil {
+		return fmt.Errorf("failed to lock schema_version for %s: %w", m.Name, err)
+	}
+	var current int
+	if err := tx.QueryRow("SELECT version FROM schema_version WHERE id = 1").Scan(&current); err != nil {
+		return fmt.Errorf("failed to get schema version for %s: %w", m.Name, err)
+	}
+	if current >= m.Version {
+		return nil
+	}
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("migration %s failed: %w", m.Name, err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE schema_version SET version = ?, upgraded_at = datetime('now') WHERE id = 1",
+		m.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations (version, name) VALUES (?, ?)",
+		m.Version, m.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log migration %s: %w", m.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", m.Name, err)
+	}
+	return nil
+}
+
+// AppliedMigrations returns the migration log recorded in schema_migrations
+func AppliedMigrations(db *sql.DB) ([]AppliedMigration, error) {
+	var exists int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+
+	applied := []AppliedMigration{}
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := db.Query("SELECT version, name, applied_at FROM schema_migrations ORDER BY version ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := AppliedMigration{}
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,5 @@
+-- Record which agent made a decision (themis, apollo, hephaestus, ...)
+ALTER TABLE decisions ADD COLUMN agent_name TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_decisions_agent ON decisions(agent_name);
+CREATE INDEX IF NOT EXISTS idx_decisions_timestamp ON decisions(timestamp DESC);
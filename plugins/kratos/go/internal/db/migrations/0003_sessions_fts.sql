@@ -0,0 +1,33 @@
+-- Full-text index over session metadata and summaries
+CREATE VIRTUAL TABLE IF NOT EXISTS sessions_fts USING fts5(
+    project,
+    feature_name,
+    initial_request,
+    summary,
+    content='sessions',
+    content_rowid='id'
+);
+
+-- Backfill from existing rows
+INSERT INTO sessions_fts(sessions_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS sessions_ai AFTER INSERT ON sessions BEGIN
+    INSERT INTO sessions_fts(rowid, project, feature_name, initial_request, summary)
+    VALUES (new.id, new.project, new.feature_name, new.initial_request, new.summary);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sessions_ad AFTER DELETE ON sessions BEGIN
+    INSERT INTO sessions_fts(sessions_fts, rowid, project, feature_name, initial_request, summary)
+    VALUES ('delete', old.id, old.project, old.feature_name, old.initial_request, old.summary);
+END;
+
+CREATE TRIGGER IF NOT EXISTS sessions_au AFTER UPDATE OF project, feature_name, initial_request, summary ON sessions BEGIN
+    INSERT INTO sessions_fts(sessions_fts, rowid, project, feature_name, initial_request, summary)
+    VALUES ('delete', old.id, old.project, old.feature_name, old.initial_request, old.summary);
+    INSERT INTO sessions_fts(rowid, project, feature_name, initial_request, summary)
+    VALUES (new.id, new.project, new.feature_name, new.initial_request, new.summary);
+END;
+
+-- Rebuild step and decision indexes in case rows predate their triggers
+INSERT INTO steps_fts(steps_fts) VALUES ('rebuild');
+INSERT INTO decisions_fts(decisions_fts) VALUES ('rebuild');
@@ -0,0 +1,64 @@
+-- Mirror the 11-stage pipeline from status.json into features
+-- Feature names are only unique within a project, so the table is rebuilt
+-- with a composite key and the columns status.json carries
+CREATE TABLE features_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    feature_name TEXT NOT NULL,
+    project TEXT NOT NULL,
+    created_at INTEGER NOT NULL,               -- Unix epoch ms
+    updated_at INTEGER NOT NULL,               -- Unix epoch ms
+    current_stage INTEGER DEFAULT 0,           -- 0-11
+    current_stage_name TEXT,                   -- e.g. 5-tech-spec
+    status TEXT DEFAULT 'in_progress',         -- in_progress, completed, abandoned
+    priority TEXT,                             -- P0-P3
+    status_path TEXT,                          -- Absolute path to status.json
+
+    -- Stage completion tracking
+    stage_0_completed INTEGER,                 -- Unix epoch ms
+    stage_1_completed INTEGER,
+    stage_2_completed INTEGER,
+    stage_3_completed INTEGER,
+    stage_4_completed INTEGER,
+    stage_5_completed INTEGER,
+    stage_6_completed INTEGER,
+    stage_7_completed INTEGER,
+    stage_8_completed INTEGER,
+    stage_9_completed INTEGER,
+    stage_10_completed INTEGER,
+    stage_11_completed INTEGER,
+
+    -- Documents created
+    prd_path TEXT,
+    tech_spec_path TEXT,
+    test_plan_path TEXT,
+    implementation_notes_path TEXT,
+
+    -- Summary
+    description TEXT,
+    total_sessions INTEGER DEFAULT 0,
+    total_agents_used INTEGER DEFAULT 0,
+
+    UNIQUE (project, feature_name)
+);
+
+INSERT INTO features_new (
+    id, feature_name, project, created_at, updated_at, current_stage, status,
+    stage_0_completed, stage_1_completed, stage_2_completed, stage_3_completed,
+    stage_4_completed, stage_5_completed, stage_6_completed, stage_7_completed,
+    stage_8_completed, prd_path, tech_spec_path, test_plan_path,
+    implementation_notes_path, description, total_sessions, total_agents_used
+)
+SELECT
+    id, feature_name, project, created_at, updated_at, current_stage, status,
+    stage_0_completed, stage_1_completed, stage_2_completed, stage_3_completed,
+    stage_4_completed, stage_5_completed, stage_6_completed, stage_7_completed,
+    stage_8_completed, prd_path, tech_spec_path, test_plan_path,
+    implementation_notes_path, description, total_sessions, total_agents_used
+FROM features;
+
+DROP TABLE features;
+ALTER TABLE features_new RENAME TO features;
+
+CREATE INDEX IF NOT EXISTS idx_features_project ON features(project);
+CREATE INDEX IF NOT EXISTS idx_features_status ON features(status);
+CREATE INDEX IF NOT EXISTS idx_features_updated ON features(updated_at DESC);
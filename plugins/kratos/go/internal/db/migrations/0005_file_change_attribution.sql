@@ -0,0 +1,7 @@
+-- Attribute file changes to the agent and feature that made them
+ALTER TABLE file_changes ADD COLUMN agent_name TEXT;
+ALTER TABLE file_changes ADD COLUMN feature_name TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_file_changes_timestamp ON file_changes(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_file_changes_agent ON file_changes(agent_name);
+CREATE INDEX IF NOT EXISTS idx_file_changes_feature ON file_changes(feature_name);
@@ -0,0 +1,23 @@
+-- Typed pipeline events mirrored from each feature's events.jsonl
+CREATE TABLE IF NOT EXISTS pipeline_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project TEXT NOT NULL,
+    feature_name TEXT NOT NULL,
+    seq INTEGER NOT NULL,                      -- Position in events.jsonl
+    revision INTEGER NOT NULL DEFAULT 0,       -- status.json revision after the write
+    timestamp INTEGER NOT NULL,                -- Unix epoch ms
+    event_type TEXT NOT NULL,                  -- created, started, completed, gate-forced, ...
+    stage TEXT,
+    from_status TEXT,
+    to_status TEXT,
+    verdict TEXT,
+    agent_name TEXT,
+    session_id TEXT,
+    reason TEXT,
+
+    UNIQUE (project, feature_name, seq)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pipeline_events_feature ON pipeline_events(project, feature_name, seq);
+CREATE INDEX IF NOT EXISTS idx_pipeline_events_session ON pipeline_events(session_id);
+CREATE INDEX IF NOT EXISTS idx_pipeline_events_timestamp ON pipeline_events(timestamp DESC);
@@ -0,0 +1,6 @@
+-- Token usage and wall time of agent spawns, reported by the Task tool result
+ALTER TABLE steps ADD COLUMN input_tokens INTEGER;
+ALTER TABLE steps ADD COLUMN output_tokens INTEGER;
+ALTER TABLE steps ADD COLUMN cache_creation_tokens INTEGER;
+ALTER TABLE steps ADD COLUMN cache_read_tokens INTEGER;
+ALTER TABLE steps ADD COLUMN duration_ms INTEGER;
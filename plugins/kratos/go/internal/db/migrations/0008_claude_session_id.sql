@@ -0,0 +1,3 @@
+-- Claude Code session each Kratos session was started for, from the hook payloads
+ALTER TABLE sessions ADD COLUMN claude_session_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_sessions_claude_session ON sessions(claude_session_id);
@@ -99,11 +99,14 @@ func TestInitDB_CreatesSchemaVersion(t *testing.T) {
 	err := InitDB(db)
 	require.NoError(t, err)
 
-	// Verify schema_version table exists and has correct value
+	// Verify schema_version table exists and was advanced to the latest migration
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+
 	var version int
 	err = db.QueryRow("SELECT version FROM schema_version WHERE id = 1").Scan(&version)
 	require.NoError(t, err)
-	assert.Equal(t, 1, version, "Schema version should be 1")
+	assert.Equal(t, latest, version, "Schema version should match the latest migration")
 }
 
 // TestInitDB_CreatesTriggers tests that FTS triggers are created
@@ -121,3 +124,127 @@ func TestInitDB_CreatesTriggers(t *testing.T) {
 	require.NoError(t, err)
 	assert.Greater(t, count, 0, "Should have at least one trigger for FTS")
 }
+
+// testMigrations returns a pair of migrations used to exercise the engine
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 2, Name: "0002_add_session_tags", SQL: "ALTER TABLE sessions ADD COLUMN tags TEXT;"},
+		{Version: 3, Name: "0003_add_tags_index", SQL: "CREATE INDEX idx_sessions_tags ON sessions(tags);"},
+	}
+}
+
+// TestMigrations_EmbeddedAreOrdered tests that embedded migrations parse and are strictly increasing
+func TestMigrations_EmbeddedAreOrdered(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+
+	prev := baselineVersion
+	for _, m := range migrations {
+		assert.Greater(t, m.Version, prev, "migration %s out of order", m.Name)
+		assert.NotEmpty(t, m.SQL, "migration %s is empty", m.Name)
+		prev = m.Version
+	}
+}
+
+// TestSchemaVersion_Uninitialized tests that a fresh database reports version 0
+func TestSchemaVersion_Uninitialized(t *testing.T) {
+	db := NewTestDB(t)
+
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+}
+
+// TestApplyMigrations_AdvancesVersion tests that migrations run and advance schema_version
+func TestApplyMigrations_AdvancesVersion(t *testing.T) {
+	db := NewTestDB(t)
+
+	applied, err := applyMigrations(db, testMigrations())
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	// Column from migration 2 exists
+	_, err = db.Exec("UPDATE sessions SET tags = 'x'")
+	require.NoError(t, err)
+
+	// Migration log records both
+	log, err := AppliedMigrations(db)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, "0002_add_session_tags", log[0].Name)
+	assert.Equal(t, 3, log[1].Version)
+}
+
+// TestApplyMigrations_Idempotent tests that already-applied migrations are skipped
+func TestApplyMigrations_Idempotent(t *testing.T) {
+	db := NewTestDB(t)
+
+	_, err := applyMigrations(db, testMigrations())
+	require.NoError(t, err)
+
+	// ALTER TABLE ADD COLUMN would fail if migration 2 ran again
+	applied, err := applyMigrations(db, testMigrations())
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+// TestApplyMigrations_UpgradesExistingDatabase tests upgrading a v1 database created from schema.sql alone
+func TestApplyMigrations_UpgradesExistingDatabase(t *testing.T) {
+	db := NewTestDB(t)
+
+	// Simulate a database created by an older binary
+	_, err := db.Exec(schemaSQL)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO sessions (session_id, project, started_at) VALUES ('old', '/p', 1)")
+	require.NoError(t, err)
+
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	_, err = applyMigrations(db, testMigrations())
+	require.NoError(t, err)
+
+	// Existing rows survive and see the new column
+	var tags *string
+	err = db.QueryRow("SELECT tags FROM sessions WHERE session_id = 'old'").Scan(&tags)
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+}
+
+// TestApplyMigrations_FailureRollsBack tests that a failing migration leaves the version untouched
+func TestApplyMigrations_FailureRollsBack(t *testing.T) {
+	db := NewTestDB(t)
+
+	migrations := []Migration{
+		{Version: 2, Name: "0002_ok", SQL: "ALTER TABLE sessions ADD COLUMN tags TEXT;"},
+		{Version: 3, Name: "0003_broken", SQL: "CREATE TABLE half_done (id INTEGER); SELECT * FROM no_such_table;"},
+	}
+
+	applied, err := applyMigrations(db, migrations)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "0003_broken")
+	assert.Len(t, applied, 1)
+
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version, "failed migration must not advance the version")
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "partial migration must be rolled back")
+}
+
+// TestPendingMigrations_NoneAfterInit tests that InitDB leaves nothing pending
+func TestPendingMigrations_NoneAfterInit(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	pending, err := PendingMigrations(db)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
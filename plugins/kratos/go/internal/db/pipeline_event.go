@@ -0,0 +1,150 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// pipelineEventColumns is the column list shared by every pipeline event SELECT
+const pipelineEventColumns = `
+	id, project, feature_name, seq, revision, timestamp, event_type, stage,
+	from_status, to_status, verdict, agent_name, session_id, reason`
+
+// PipelineEventFilter narrows ListPipelineEvents
+// Empty fields are ignored; Since is Unix epoch ms (0 = unbounded)
+type PipelineEventFilter struct {
+	Project     string
+	FeatureName string
+	Stage       string
+	SessionID   string
+	EventType   string
+	Since       int64
+	Limit       int
+}
+
+// InsertPipelineEvents mirrors events into pipeline_events
+// Events are keyed by (project, feature_name, seq), so re-mirroring a log is a no-op
+func InsertPipelineEvents(db *sql.DB, events []*models.PipelineEvent) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO pipeline_events (
+			project, feature_name, seq, revision, timestamp, event_type, stage,
+			from_status, to_status, verdict, agent_name, session_id, reason
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare pipeline event insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		result, err := stmt.Exec(
+			e.Project,
+			e.FeatureName,
+			e.Seq,
+			e.Revision,
+			e.Timestamp,
+			e.EventType,
+			e.Stage,
+			e.FromStatus,
+			e.ToStatus,
+			e.Verdict,
+			e.AgentName,
+			e.SessionID,
+			e.Reason,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert pipeline event: %w", err)
+		}
+		if id, err := result.LastInsertId(); err == nil {
+			e.ID = id
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pipeline events: %w", err)
+	}
+	return nil
+}
+
+// ListPipelineEvents returns events matching the filter in log order
+// With a limit, the most recent events are returned
+func ListPipelineEvents(db *sql.DB, filter PipelineEventFilter) ([]*models.PipelineEvent, error) {
+	var where []string
+	var args []interface{}
+
+	for _, cond := range []struct{ col, value string }{
+		{"project", filter.Project},
+		{"feature_name", filter.FeatureName},
+		{"stage", filter.Stage},
+		{"session_id", filter.SessionID},
+		{"event_type", filter.EventType},
+	} {
+		if cond.value != "" {
+			where = append(where, cond.col+" = ?")
+			args = append(args, cond.value)
+		}
+	}
+	if filter.Since > 0 {
+		where = append(where, "timestamp >= ?")
+		args = append(args, filter.Since)
+	}
+
+	query := `SELECT ` + pipelineEventColumns + ` FROM pipeline_events`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*models.PipelineEvent{}
+	for rows.Next() {
+		e := &models.PipelineEvent{}
+		err := rows.Scan(
+			&e.ID,
+			&e.Project,
+			&e.FeatureName,
+			&e.Seq,
+			&e.Revision,
+			&e.Timestamp,
+			&e.EventType,
+			&e.Stage,
+			&e.FromStatus,
+			&e.ToStatus,
+			&e.Verdict,
+			&e.AgentName,
+			&e.SessionID,
+			&e.Reason,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pipeline event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pipeline events: %w", err)
+	}
+
+	// Oldest first, like the log itself
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
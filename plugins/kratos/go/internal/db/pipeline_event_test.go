@@ -0,0 +1,70 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipelineEvent returns an event of feature auth in project app
+func newPipelineEvent(seq int64, eventType, stage string, ts int64) *models.PipelineEvent {
+	return &models.PipelineEvent{
+		Project:     "app",
+		FeatureName: "auth",
+		Seq:         seq,
+		Revision:    seq - 1,
+		Timestamp:   ts,
+		EventType:   eventType,
+		Stage:       &stage,
+	}
+}
+
+func TestInsertPipelineEvents_Idempotent(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	events := []*models.PipelineEvent{
+		newPipelineEvent(1, "created", "1-prd", 1000),
+		newPipelineEvent(2, "completed", "1-prd", 2000),
+	}
+	require.NoError(t, InsertPipelineEvents(db, events))
+	assert.NotZero(t, events[0].ID)
+
+	// Mirroring the same log again adds nothing
+	require.NoError(t, InsertPipelineEvents(db, events))
+	listed, err := ListPipelineEvents(db, PipelineEventFilter{FeatureName: "auth"})
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "created", listed[0].EventType)
+	assert.Equal(t, "1-prd", *listed[1].Stage)
+}
+
+func TestListPipelineEvents_Filters(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	session := "sess-1"
+	completed := newPipelineEvent(3, "completed", "2-prd-review", 3000)
+	completed.SessionID = &session
+	require.NoError(t, InsertPipelineEvents(db, []*models.PipelineEvent{
+		newPipelineEvent(1, "created", "1-prd", 1000),
+		newPipelineEvent(2, "completed", "1-prd", 2000),
+		completed,
+	}))
+
+	bySession, err := ListPipelineEvents(db, PipelineEventFilter{SessionID: "sess-1"})
+	require.NoError(t, err)
+	require.Len(t, bySession, 1)
+	assert.Equal(t, int64(3), bySession[0].Seq)
+
+	byType, err := ListPipelineEvents(db, PipelineEventFilter{EventType: "completed", Since: 2500})
+	require.NoError(t, err)
+	assert.Len(t, byType, 1)
+
+	// The limit keeps the most recent events, still in log order
+	latest, err := ListPipelineEvents(db, PipelineEventFilter{Project: "app", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, latest, 2)
+	assert.Equal(t, int64(2), latest[0].Seq)
+	assert.Equal(t, int64(3), latest[1].Seq)
+}
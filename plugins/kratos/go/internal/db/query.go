@@ -64,20 +64,23 @@ func GetSessionsByProject(db *sql.DB, project string) ([]*models.Session, error)
 	return scanSessions(rows)
 }
 
-// SearchSessions performs a LIKE search across project, feature_name, and summary fields
+// SearchSessions performs a full-text search across project, feature_name, and summary fields
 func SearchSessions(db *sql.DB, searchTerm string) ([]*models.Session, error) {
+	match := ftsQuery(searchTerm)
+	if match == "" {
+		return []*models.Session{}, nil
+	}
+
 	query := `
 		SELECT s.id, s.session_id, s.project, s.feature_name, s.started_at, s.ended_at,
 		       s.status, s.summary, s.total_steps, s.total_agents_spawned
-		FROM sessions s
-		WHERE s.project LIKE ?
-		   OR s.feature_name LIKE ?
-		   OR s.summary LIKE ?
+		FROM sessions_fts
+		JOIN sessions s ON s.id = sessions_fts.rowid
+		WHERE sessions_fts MATCH ?
 		ORDER BY s.started_at DESC
 	`
 
-	searchPattern := "%" + searchTerm + "%"
-	rows, err := db.Query(query, searchPattern, searchPattern, searchPattern)
+	rows, err := db.Query(query, match)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search sessions: %w", err)
 	}
@@ -88,3 +88,66 @@ func GetSessionContext(db *sql.DB, sessionID string) (*models.SessionContext, er
 func GetRecentSessionsGlobal(db *sql.DB, limit int) ([]*models.Session, error) {
 	return GetRecentSessions(db, limit)
 }
+
+// GetStageHistory returns every completion of a feature's stages, oldest first
+// Completions come from pipeline_events; the agent and model are taken from the
+// agent_spawn step recorded for that stage in the same session, if any
+func GetStageHistory(db *sql.DB, project, featureName string) ([]*models.StageWork, error) {
+	// spawn picks a column of the latest agent_spawn step for the event's stage
+	// and session, optionally only the event's own agent
+	spawn := func(column, agentClause string) string {
+		return fmt.Sprintf(`(
+			SELECT s.%s FROM steps s
+			WHERE s.session_id = e.session_id AND s.step_type = 'agent_spawn'
+			  AND s.pipeline_stage = CAST(e.stage AS INTEGER)%s
+			ORDER BY s.step_number DESC
+			LIMIT 1
+		)`, column, agentClause)
+	}
+	sameAgent := " AND lower(s.agent_name) = lower(e.agent_name)"
+
+	query := fmt.Sprintf(`
+		SELECT e.stage, e.session_id, e.verdict, e.timestamp,
+		       COALESCE(%s, %s, e.agent_name),
+		       COALESCE(%s, %s)
+		FROM pipeline_events e
+		WHERE e.project = ? AND e.feature_name = ? AND e.event_type = 'completed'
+		ORDER BY e.seq ASC
+	`, spawn("agent_name", sameAgent), spawn("agent_name", ""), spawn("agent_model", sameAgent), spawn("agent_model", ""))
+
+	rows, err := db.Query(query, project, featureName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*models.StageWork{}
+	for rows.Next() {
+		w := &models.StageWork{}
+		if err := rows.Scan(&w.Stage, &w.SessionID, &w.Verdict, &w.CompletedAt, &w.AgentName, &w.AgentModel); err != nil {
+			return nil, fmt.Errorf("failed to scan stage history: %w", err)
+		}
+		w.Summary = describeStageWork(w)
+		history = append(history, w)
+	}
+
+	return history, rows.Err()
+}
+
+// describeStageWork renders a completion as one recall line
+func describeStageWork(w *models.StageWork) string {
+	line := fmt.Sprintf("stage %s was done", w.Stage)
+	if w.SessionID != nil {
+		line += " in session " + *w.SessionID
+	}
+	if w.AgentName != nil {
+		line += " by " + *w.AgentName
+		if w.AgentModel != nil {
+			line += "/" + *w.AgentModel
+		}
+	}
+	if w.Verdict != nil {
+		line += " (" + *w.Verdict + ")"
+	}
+	return line
+}
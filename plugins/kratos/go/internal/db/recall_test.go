@@ -218,3 +218,49 @@ func TestGetRecentSessionsGlobal(t *testing.T) {
 	assert.Equal(t, "proj-a-2", recent[0].SessionID) // Most recent
 	assert.Equal(t, "proj-b-1", recent[1].SessionID)
 }
+
+func TestGetStageHistory(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	require.NoError(t, CreateSession(db, &models.Session{
+		SessionID: "abc",
+		Project:   "app",
+		StartedAt: 1000,
+		Status:    "active",
+	}))
+	stage := int64(7)
+	require.NoError(t, RecordAgentSpawn(db, "abc", "Apollo", "opus", "Review tech spec", &stage, nil))
+	require.NoError(t, RecordAgentSpawn(db, "abc", "athena", "sonnet", "Review tech spec", nil, nil))
+
+	session := "abc"
+	verdict := "sound"
+	agent := "apollo"
+	review := newPipelineEvent(2, "completed", "7-spec-review-sa", 3000)
+	review.SessionID = &session
+	review.Verdict = &verdict
+	review.AgentName = &agent
+	require.NoError(t, InsertPipelineEvents(db, []*models.PipelineEvent{
+		newPipelineEvent(1, "completed", "1-prd", 2000),
+		review,
+		newPipelineEvent(3, "started", "8-test-plan", 4000),
+	}))
+
+	history, err := GetStageHistory(db, "app", "auth")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	// Unlinked completions still show up
+	assert.Equal(t, "1-prd", history[0].Stage)
+	assert.Nil(t, history[0].SessionID)
+	assert.Equal(t, "stage 1-prd was done", history[0].Summary)
+
+	assert.Equal(t, "7-spec-review-sa", history[1].Stage)
+	require.NotNil(t, history[1].AgentModel)
+	assert.Equal(t, "opus", *history[1].AgentModel)
+	assert.Equal(t, int64(3000), history[1].CompletedAt)
+	assert.Equal(t, "stage 7-spec-review-sa was done in session abc by Apollo/opus (sound)", history[1].Summary)
+
+	history, err = GetStageHistory(db, "app", "billing")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
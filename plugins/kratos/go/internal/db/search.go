@@ -0,0 +1,303 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// Search sources
+const (
+	SearchSourceStep     = "step"
+	SearchSourceDecision = "decision"
+	SearchSourceSession  = "session"
+)
+
+// Snippet highlight markers (markdown bold so results render in Claude Code)
+const (
+	snippetStart    = "**"
+	snippetEnd      = "**"
+	snippetEllipsis = "…"
+	snippetTokens   = 16
+)
+
+// SearchFilter narrows a full-text search
+// Empty fields are ignored; Since/Until are Unix epoch ms (0 = unbounded)
+type SearchFilter struct {
+	Project     string
+	FeatureName string
+	AgentName   string
+	StepType    string
+	Since       int64
+	Until       int64
+	Sources     []string // step, decision, session; empty means all
+	Limit       int
+}
+
+// includes reports whether the filter allows results from source
+func (f SearchFilter) includes(source string) bool {
+	if len(f.Sources) == 0 {
+		return true
+	}
+	for _, s := range f.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Search runs a ranked full-text search across steps, decisions and session summaries
+// bm25 scores from different FTS tables are not comparable, so each source's
+// scores are normalized to 0 (its best hit) through 1 (its worst) before the
+// results are merged, most relevant first
+func Search(db *sql.DB, searchTerm string, filter SearchFilter) ([]*models.SearchResult, error) {
+	match := ftsQuery(searchTerm)
+	if match == "" {
+		return []*models.SearchResult{}, nil
+	}
+
+	results := []*models.SearchResult{}
+
+	// Sessions carry no agent or step type, decisions are a single step type
+	searchers := []struct {
+		source string
+		skip   bool
+		fn     func(*sql.DB, string, SearchFilter) ([]*models.SearchResult, error)
+	}{
+		{SearchSourceStep, false, searchSteps},
+		{SearchSourceDecision, filter.StepType != "" && filter.StepType != "decision", searchDecisionHits},
+		{SearchSourceSession, filter.AgentName != "" || filter.StepType != "", searchSessionHits},
+	}
+
+	for _, s := range searchers {
+		if s.skip || !filter.includes(s.source) {
+			continue
+		}
+		hits, err := s.fn(db, match, filter)
+		if err != nil {
+			return nil, err
+		}
+		normalizeScores(hits)
+		results = append(results, hits...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score < results[j].Score
+		}
+		return results[i].Timestamp > results[j].Timestamp
+	})
+
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+
+	return results, nil
+}
+
+// normalizeScores rescales the bm25 scores of one source's hits, ordered best
+// first, to 0 for the best hit through 1 for the worst
+func normalizeScores(hits []*models.SearchResult) {
+	if len(hits) == 0 {
+		return
+	}
+	best, worst := hits[0].Score, hits[len(hits)-1].Score
+	for _, r := range hits {
+		if worst > best {
+			r.Score = (r.Score - best) / (worst - best)
+		} else {
+			r.Score = 0
+		}
+	}
+}
+
+// searchClauses builds the shared project/feature/date WHERE clauses
+// featureCol and timeCol name the columns to filter on for the source table
+func searchClauses(filter SearchFilter, featureCol, timeCol string) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+
+	if filter.Project != "" {
+		where = append(where, "s.project = ?")
+		args = append(args, filter.Project)
+	}
+	if filter.FeatureName != "" {
+		where = append(where, featureCol+" = ?")
+		args = append(args, filter.FeatureName)
+	}
+	if filter.Since > 0 {
+		where = append(where, timeCol+" >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Until > 0 {
+		where = append(where, timeCol+" <= ?")
+		args = append(args, filter.Until)
+	}
+
+	return where, args
+}
+
+// searchLimit returns the per-source LIMIT; each source may fill the whole result
+func searchLimit(filter SearchFilter) int {
+	if filter.Limit > 0 {
+		return filter.Limit
+	}
+	return -1 // SQLite: no limit
+}
+
+func searchSteps(db *sql.DB, match string, filter SearchFilter) ([]*models.SearchResult, error) {
+	where, args := searchClauses(filter, "s.feature_name", "st.timestamp")
+	if filter.AgentName != "" {
+		where = append(where, "st.agent_name = ?")
+		args = append(args, filter.AgentName)
+	}
+	if filter.StepType != "" {
+		where = append(where, "st.step_type = ?")
+		args = append(args, filter.StepType)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT st.id, st.session_id, s.project, s.feature_name, st.agent_name, st.step_type,
+		       st.timestamp, st.action,
+		       snippet(steps_fts, -1, '%s', '%s', '%s', %d),
+		       bm25(steps_fts)
+		FROM steps_fts
+		JOIN steps st ON st.id = steps_fts.rowid
+		JOIN sessions s ON s.session_id = st.session_id
+		WHERE steps_fts MATCH ?%s
+		ORDER BY bm25(steps_fts)
+		LIMIT ?
+	`, snippetStart, snippetEnd, snippetEllipsis, snippetTokens, andClauses(where))
+
+	args = append([]interface{}{match}, args...)
+	args = append(args, searchLimit(filter))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search steps: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*models.SearchResult{}
+	for rows.Next() {
+		r := &models.SearchResult{Source: SearchSourceStep}
+		var stepType string
+		if err := rows.Scan(
+			&r.ID, &r.SessionID, &r.Project, &r.FeatureName, &r.AgentName, &stepType,
+			&r.Timestamp, &r.Title, &r.Snippet, &r.Score,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan step result: %w", err)
+		}
+		r.StepType = &stepType
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func searchDecisionHits(db *sql.DB, match string, filter SearchFilter) ([]*models.SearchResult, error) {
+	where, args := searchClauses(filter, "d.feature_name", "d.timestamp")
+	if filter.AgentName != "" {
+		where = append(where, "d.agent_name = ?")
+		args = append(args, filter.AgentName)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT d.id, d.session_id, COALESCE(s.project, ''), d.feature_name, d.agent_name,
+		       d.timestamp, d.question,
+		       snippet(decisions_fts, -1, '%s', '%s', '%s', %d),
+		       bm25(decisions_fts)
+		FROM decisions_fts
+		JOIN decisions d ON d.id = decisions_fts.rowid
+		LEFT JOIN sessions s ON s.session_id = d.session_id
+		WHERE decisions_fts MATCH ?%s
+		ORDER BY bm25(decisions_fts)
+		LIMIT ?
+	`, snippetStart, snippetEnd, snippetEllipsis, snippetTokens, andClauses(where))
+
+	args = append([]interface{}{match}, args...)
+	args = append(args, searchLimit(filter))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search decisions: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*models.SearchResult{}
+	for rows.Next() {
+		r := &models.SearchResult{Source: SearchSourceDecision}
+		if err := rows.Scan(
+			&r.ID, &r.SessionID, &r.Project, &r.FeatureName, &r.AgentName,
+			&r.Timestamp, &r.Title, &r.Snippet, &r.Score,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan decision result: %w", err)
+		}
+		stepType := "decision"
+		r.StepType = &stepType
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func searchSessionHits(db *sql.DB, match string, filter SearchFilter) ([]*models.SearchResult, error) {
+	where, args := searchClauses(filter, "s.feature_name", "s.started_at")
+
+	query := fmt.Sprintf(`
+		SELECT s.id, s.session_id, s.project, s.feature_name, s.started_at,
+		       COALESCE(s.feature_name, s.project),
+		       snippet(sessions_fts, -1, '%s', '%s', '%s', %d),
+		       bm25(sessions_fts)
+		FROM sessions_fts
+		JOIN sessions s ON s.id = sessions_fts.rowid
+		WHERE sessions_fts MATCH ?%s
+		ORDER BY bm25(sessions_fts)
+		LIMIT ?
+	`, snippetStart, snippetEnd, snippetEllipsis, snippetTokens, andClauses(where))
+
+	args = append([]interface{}{match}, args...)
+	args = append(args, searchLimit(filter))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*models.SearchResult{}
+	for rows.Next() {
+		r := &models.SearchResult{Source: SearchSourceSession}
+		if err := rows.Scan(
+			&r.ID, &r.SessionID, &r.Project, &r.FeatureName,
+			&r.Timestamp, &r.Title, &r.Snippet, &r.Score,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session result: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// ftsQuery turns free text into an FTS5 MATCH expression
+// Each whitespace-separated token is quoted so punctuation in user input
+// cannot be parsed as FTS5 syntax, and prefix-matched so "retry" also finds
+// "retries"; tokens are implicitly ANDed
+func ftsQuery(text string) string {
+	var terms []string
+	for _, token := range strings.Fields(text) {
+		token = strings.ReplaceAll(token, `"`, `""`)
+		terms = append(terms, `"`+token+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// andClauses renders WHERE fragments to append after an existing condition
+func andClauses(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(where, " AND ")
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearch_AcrossSources tests that steps, decisions and sessions are all searched and ranked
+func TestSearch_AcrossSources(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	now := time.Now().UnixMilli()
+
+	require.NoError(t, CreateSession(db, &models.Session{
+		SessionID:   "sess-a",
+		Project:     "/project/api",
+		FeatureName: stringPtr("sync"),
+		StartedAt:   now - 10000,
+		Status:      "completed",
+		Summary:     stringPtr("Settled the retry policy for webhooks"),
+	}))
+	require.NoError(t, CreateSession(db, &models.Session{
+		SessionID: "sess-b",
+		Project:   "/project/web",
+		StartedAt: now - 5000,
+		Status:    "active",
+	}))
+
+	require.NoError(t, RecordAgentSpawn(db, "sess-a", "hephaestus", "opus", "Write retry section of tech spec", nil, nil))
+	require.NoError(t, RecordAgentSpawn(db, "sess-b", "ares", "sonnet", "Implement login form", nil, nil))
+
+	rationale := "Retries with jitter avoid thundering herds"
+	require.NoError(t, RecordDecision(db, &models.Decision{
+		SessionID:   "sess-a",
+		FeatureName: stringPtr("sync"),
+		AgentName:   stringPtr("apollo"),
+		Question:    "Retry policy",
+		Choice:      "Exponential backoff",
+		Rationale:   &rationale,
+	}))
+
+	results, err := Search(db, "retry", SearchFilter{})
+	require.NoError(t, err)
+
+	sources := map[string]int{}
+	for _, r := range results {
+		sources[r.Source]++
+		assert.Equal(t, "sess-a", r.SessionID)
+		assert.Contains(t, strings.ToLower(r.Snippet), "**retr", "snippet should highlight the match")
+	}
+	assert.Equal(t, 2, sources[SearchSourceStep], "agent spawn + decision step")
+	assert.Equal(t, 1, sources[SearchSourceDecision])
+	assert.Equal(t, 1, sources[SearchSourceSession])
+
+	// Ranked by bm25 ascending
+	for i := 1; i < len(results); i++ {
+		assert.LessOrEqual(t, results[i-1].Score, results[i].Score)
+	}
+}
+
+// TestSearch_NormalizesScoresPerSource tests that each source's best hit ranks alongside the others
+func TestSearch_NormalizesScoresPerSource(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s", Project: "/p", StartedAt: 1, Status: "active"}))
+
+	for i, action := range []string{"cache cache cache", "cache warmup", "cache invalidation for the billing service"} {
+		require.NoError(t, CreateStep(db, &models.Step{SessionID: "s", StepNumber: int64(i + 1), StepType: "command", Timestamp: int64(i + 1), Action: action}))
+	}
+	require.NoError(t, RecordDecision(db, &models.Decision{SessionID: "s", Timestamp: 10, Question: "Cache layer", Choice: "Redis"}))
+
+	results, err := Search(db, "cache", SearchFilter{Sources: []string{SearchSourceStep, SearchSourceDecision}})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	best := map[string]float64{}
+	for _, r := range results {
+		assert.GreaterOrEqual(t, r.Score, 0.0)
+		assert.LessOrEqual(t, r.Score, 1.0)
+		if _, ok := best[r.Source]; !ok {
+			best[r.Source] = r.Score
+		}
+	}
+	assert.Equal(t, 0.0, best[SearchSourceStep])
+	assert.Equal(t, 0.0, best[SearchSourceDecision])
+
+	// The decision's best hit is not pushed below every step by raw bm25
+	assert.Equal(t, SearchSourceDecision, results[0].Source, "ties go to the newest hit")
+}
+
+// TestSearch_Filters tests project, feature, agent, step type, source and date filters
+func TestSearch_Filters(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	now := time.Now().UnixMilli()
+
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "a", Project: "/p/a", FeatureName: stringPtr("auth"), StartedAt: now, Status: "active"}))
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "b", Project: "/p/b", FeatureName: stringPtr("billing"), StartedAt: now, Status: "active"}))
+
+	old := now - int64(30*24*time.Hour/time.Millisecond)
+	steps := []*models.Step{
+		{SessionID: "a", StepNumber: 1, StepType: "agent_spawn", Timestamp: old, AgentName: stringPtr("athena"), Action: "cache design"},
+		{SessionID: "a", StepNumber: 2, StepType: "command", Timestamp: now, AgentName: stringPtr("ares"), Action: "cache warmup"},
+		{SessionID: "b", StepNumber: 1, StepType: "agent_spawn", Timestamp: now, AgentName: stringPtr("ares"), Action: "cache invalidation"},
+	}
+	for _, s := range steps {
+		require.NoError(t, CreateStep(db, s))
+	}
+
+	count := func(f SearchFilter) int {
+		t.Helper()
+		r, err := Search(db, "cache", f)
+		require.NoError(t, err)
+		return len(r)
+	}
+
+	assert.Equal(t, 3, count(SearchFilter{}))
+	assert.Equal(t, 2, count(SearchFilter{Project: "/p/a"}))
+	assert.Equal(t, 1, count(SearchFilter{FeatureName: "billing"}))
+	assert.Equal(t, 2, count(SearchFilter{AgentName: "ares"}))
+	assert.Equal(t, 1, count(SearchFilter{StepType: "command"}))
+	assert.Equal(t, 2, count(SearchFilter{Since: now - 1000}))
+	assert.Equal(t, 1, count(SearchFilter{Until: now - 1000}))
+	assert.Equal(t, 0, count(SearchFilter{Sources: []string{SearchSourceDecision}}))
+	assert.Equal(t, 1, count(SearchFilter{Limit: 1}))
+}
+
+// TestSearch_EmptyAndPunctuation tests degenerate queries
+func TestSearch_EmptyAndPunctuation(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	results, err := Search(db, "   ", SearchFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = Search(db, `what's "NEAR(" -x*`, SearchFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+// TestSearchSessions_PicksUpSummaryOnEnd tests that ending a session re-indexes its summary
+func TestSearchSessions_PicksUpSummaryOnEnd(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s", Project: "/p", StartedAt: 1, Status: "active"}))
+	require.NoError(t, EndSession(db, "s", "Migrated billing to Stripe"))
+
+	results, err := SearchSessions(db, "stripe")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "s", results[0].SessionID)
+}
+
+// TestFTSQuery tests quoting of free text into an FTS5 prefix expression
+func TestFTSQuery(t *testing.T) {
+	assert.Equal(t, `"retry"* "policy"*`, ftsQuery("retry policy"))
+	assert.Equal(t, `"say"* """hi"""*`, ftsQuery(`say "hi"`))
+	assert.Equal(t, "", ftsQuery("  "))
+}
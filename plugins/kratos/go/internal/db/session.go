@@ -110,17 +110,74 @@ func GetActiveSession(db *sql.DB, project string) (*models.Session, error) {
 	return session, nil
 }
 
+// LinkClaudeSession maps a Claude Code session ID to a session
+func LinkClaudeSession(db *sql.DB, sessionID, claudeSessionID string) error {
+	_, err := db.Exec("UPDATE sessions SET claude_session_id = ? WHERE session_id = ?", claudeSessionID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to link Claude Code session: %w", err)
+	}
+	return nil
+}
+
+// GetSessionByClaudeID gets the latest session started for a Claude Code session ID
+// Returns nil if the Claude Code session has none
+func GetSessionByClaudeID(db *sql.DB, claudeSessionID string) (*models.Session, error) {
+	var sessionID string
+	err := db.QueryRow(`
+		SELECT session_id
+		FROM sessions
+		WHERE claude_session_id = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`, claudeSessionID).Scan(&sessionID)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session for Claude Code session: %w", err)
+	}
+
+	return GetSession(db, sessionID)
+}
+
+// SetSessionFeature links a session to a feature unless it already has one
+// Returns whether the session was updated
+func SetSessionFeature(db *sql.DB, sessionID, featureName string) (bool, error) {
+	query := `
+		UPDATE sessions
+		SET feature_name = ?
+		WHERE session_id = ? AND feature_name IS NULL
+	`
+
+	result, err := db.Exec(query, featureName, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set session feature: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set session feature: %w", err)
+	}
+
+	return n > 0, nil
+}
+
 // EndSession marks a session as completed with optional summary
 func EndSession(db *sql.DB, sessionID string, summary string) error {
+	return FinishSession(db, sessionID, "completed", summary)
+}
+
+// FinishSession ends a session with a final status (completed or abandoned) and summary
+func FinishSession(db *sql.DB, sessionID, status, summary string) error {
 	now := time.Now().UnixMilli()
 
 	query := `
 		UPDATE sessions
-		SET ended_at = ?, status = 'completed', summary = ?
+		SET ended_at = ?, status = ?, summary = ?
 		WHERE session_id = ?
 	`
 
-	_, err := db.Exec(query, now, summary, sessionID)
+	_, err := db.Exec(query, now, status, summary, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to end session: %w", err)
 	}
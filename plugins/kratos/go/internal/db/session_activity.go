@@ -0,0 +1,124 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// GetSessionActivity collects what a session did up to now (Unix epoch ms):
+// agent spawns, pipeline stages completed, files changed, decisions, and the
+// project's todos closed while it ran
+func GetSessionActivity(db *sql.DB, sessionID string, now int64) (*models.SessionActivity, error) {
+	session, err := GetSession(db, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &models.SessionActivity{
+		SessionID:       sessionID,
+		DurationMs:      now - session.StartedAt,
+		Steps:           session.TotalSteps,
+		Agents:          []*models.AgentActivity{},
+		StagesCompleted: []string{},
+		FilesChanged:    []string{},
+	}
+	if session.FeatureName != nil {
+		activity.Feature = *session.FeatureName
+	}
+
+	rows, err := db.Query(`
+		SELECT COALESCE(agent_name, ''), COALESCE(agent_model, ''), COUNT(*)
+		FROM steps
+		WHERE session_id = ? AND step_type = 'agent_spawn'
+		GROUP BY agent_name, agent_model
+		ORDER BY MIN(id) ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count agent spawns: %w", err)
+	}
+	for rows.Next() {
+		a := &models.AgentActivity{}
+		if err := rows.Scan(&a.Agent, &a.Model, &a.Spawns); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan agent spawns: %w", err)
+		}
+		activity.Agents = append(activity.Agents, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Stages completed, oldest first; the last one names the feature if the session has none
+	rows, err = db.Query(`
+		SELECT feature_name, COALESCE(stage, '')
+		FROM pipeline_events
+		WHERE session_id = ? AND event_type = 'completed'
+		ORDER BY timestamp ASC, id ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list completed stages: %w", err)
+	}
+	type completion struct{ feature, stage string }
+	var completions []completion
+	for rows.Next() {
+		var c completion
+		if err := rows.Scan(&c.feature, &c.stage); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan completed stage: %w", err)
+		}
+		completions = append(completions, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if activity.Feature == "" && len(completions) > 0 {
+		activity.Feature = completions[len(completions)-1].feature
+	}
+	for _, c := range completions {
+		if c.feature != activity.Feature {
+			c.stage = c.feature + "/" + c.stage
+		}
+		activity.StagesCompleted = append(activity.StagesCompleted, c.stage)
+	}
+
+	rows, err = db.Query(`
+		SELECT file_path
+		FROM file_changes
+		WHERE session_id = ?
+		GROUP BY file_path
+		ORDER BY COUNT(*) DESC, MAX(timestamp) DESC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan changed file: %w", err)
+		}
+		activity.FilesChanged = append(activity.FilesChanged, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := db.QueryRow("SELECT COUNT(*) FROM decisions WHERE session_id = ?", sessionID).Scan(&activity.Decisions); err != nil {
+		return nil, fmt.Errorf("failed to count decisions: %w", err)
+	}
+
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM todos
+		WHERE project = ? AND status = 'done' AND completed_at >= ? AND completed_at <= ?
+	`, session.Project, session.StartedAt, now).Scan(&activity.TodosClosed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count closed todos: %w", err)
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionActivity(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	start := time.Now().Add(-time.Hour).UnixMilli()
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s1", Project: "app", StartedAt: start, Status: "active"}))
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s2", Project: "app", StartedAt: start, Status: "active"}))
+
+	require.NoError(t, RecordAgentSpawn(db, "s1", "athena", "opus", "Write PRD", nil, nil))
+	require.NoError(t, RecordAgentSpawn(db, "s1", "athena", "opus", "Revise PRD", nil, nil))
+	require.NoError(t, RecordAgentSpawn(db, "s1", "ares", "sonnet", "Implement", nil, nil))
+	require.NoError(t, RecordFileChange(db, "s1", "Edit", "src/login.go"))
+	require.NoError(t, RecordFileChange(db, "s1", "Write", "README.md"))
+	require.NoError(t, RecordFileChange(db, "s1", "Edit", "src/login.go"))
+	require.NoError(t, RecordDecision(db, newDecision("s1", "auth", "athena", "Token storage?", "cookie")))
+
+	event := func(seq int64, feature, stage string) *models.PipelineEvent {
+		e := newPipelineEvent(seq, "completed", stage, start+seq)
+		e.FeatureName = feature
+		e.SessionID = stringPtr("s1")
+		return e
+	}
+	require.NoError(t, InsertPipelineEvents(db, []*models.PipelineEvent{
+		event(1, "billing", "1-prd"),
+		event(2, "auth", "1-prd"),
+		event(3, "auth", "2-prd-review"),
+	}))
+
+	todo, err := AddTodo(db, "Ship login", "app", "user", nil)
+	require.NoError(t, err)
+	_, err = DoneTodo(db, todo.ID)
+	require.NoError(t, err)
+
+	now := time.Now().UnixMilli()
+	activity, err := GetSessionActivity(db, "s1", now)
+	require.NoError(t, err)
+	assert.Equal(t, now-start, activity.DurationMs)
+	assert.Equal(t, int64(7), activity.Steps) // spawns, file changes and the decision
+	assert.Equal(t, []*models.AgentActivity{
+		{Agent: "athena", Model: "opus", Spawns: 2},
+		{Agent: "ares", Model: "sonnet", Spawns: 1},
+	}, activity.Agents)
+	assert.Equal(t, "auth", activity.Feature)
+	assert.Equal(t, []string{"billing/1-prd", "1-prd", "2-prd-review"}, activity.StagesCompleted)
+	assert.Equal(t, []string{"src/login.go", "README.md"}, activity.FilesChanged)
+	assert.Equal(t, 1, activity.Decisions)
+	assert.Equal(t, 1, activity.TodosClosed)
+	assert.False(t, activity.Idle())
+
+	// The todo closed during s2 as well, but s2 recorded nothing else
+	activity, err = GetSessionActivity(db, "s2", now)
+	require.NoError(t, err)
+	assert.Empty(t, activity.Feature)
+	assert.Empty(t, activity.Agents)
+	assert.Equal(t, 1, activity.TodosClosed)
+
+	require.NoError(t, FinishSession(db, "s2", "abandoned", "No recorded activity"))
+	session, err := GetSession(db, "s2")
+	require.NoError(t, err)
+	assert.Equal(t, "abandoned", session.Status)
+	assert.NotNil(t, session.EndedAt)
+
+	_, err = GetSessionActivity(db, "missing", now)
+	assert.Error(t, err)
+}
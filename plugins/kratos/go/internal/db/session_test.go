@@ -97,6 +97,62 @@ func TestGetActiveSession(t *testing.T) {
 	assert.Equal(t, "active-123", retrieved.SessionID)
 }
 
+// Test: Link a session to a feature only once
+func TestSetSessionFeature(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	require.NoError(t, CreateSession(db, &models.Session{
+		SessionID: "sess-1",
+		Project:   "/test/project",
+		StartedAt: time.Now().UnixMilli(),
+		Status:    "active",
+	}))
+
+	updated, err := SetSessionFeature(db, "sess-1", "auth")
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// An existing feature is kept
+	updated, err = SetSessionFeature(db, "sess-1", "billing")
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	session, err := GetSession(db, "sess-1")
+	require.NoError(t, err)
+	require.NotNil(t, session.FeatureName)
+	assert.Equal(t, "auth", *session.FeatureName)
+}
+
+func TestGetSessionByClaudeID(t *testing.T) {
+	db := NewTestDBWithSchema(t)
+	now := time.Now().UnixMilli()
+	for i, id := range []string{"sess-1", "sess-2", "sess-3"} {
+		require.NoError(t, CreateSession(db, &models.Session{
+			SessionID: id,
+			Project:   "/test/project",
+			StartedAt: now + int64(i),
+			Status:    "active",
+		}))
+	}
+	require.NoError(t, LinkClaudeSession(db, "sess-1", "claude-a"))
+	require.NoError(t, LinkClaudeSession(db, "sess-2", "claude-b"))
+	require.NoError(t, LinkClaudeSession(db, "sess-3", "claude-a"))
+
+	// The latest session of a Claude Code session wins
+	session, err := GetSessionByClaudeID(db, "claude-a")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, "sess-3", session.SessionID)
+
+	session, err = GetSessionByClaudeID(db, "claude-b")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, "sess-2", session.SessionID)
+
+	session, err = GetSessionByClaudeID(db, "claude-c")
+	require.NoError(t, err)
+	assert.Nil(t, session)
+}
+
 // Test 5: End session
 func TestEndSession(t *testing.T) {
 	db := NewTestDBWithSchema(t)
@@ -0,0 +1,489 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// Stats groupings
+const (
+	GroupByProject = "project"
+	GroupByFeature = "feature"
+)
+
+// StatsFilter narrows Stats
+// Since/Until are Unix epoch ms (0 = unbounded) and select the stage completions,
+// spawns and sessions counted; Now closes an open window (0 = current time)
+type StatsFilter struct {
+	Project     string
+	FeatureName string
+	Since       int64
+	Until       int64
+	GroupBy     string
+	Now         int64
+}
+
+// stageRun is one timed completion of a stage
+type stageRun struct {
+	project, feature, stage string
+	start, end              int64
+	agent, model            string
+}
+
+// spawn is an agent_spawn step with the session it belongs to
+type spawn struct {
+	sessionID, agent, model string
+	stage                   *int64
+	timestamp               int64
+	project, feature        string
+}
+
+// Stats aggregates stage cycle times, agent effort, sessions and throughput
+// Cycle times come from pipeline_events: a stage runs from its started event
+// (its created event for the first stage, or the revision that reopened it)
+// to its completed event. Completions without a known start count toward
+// throughput only
+func Stats(db *sql.DB, filter StatsFilter) (*models.Stats, error) {
+	if filter.GroupBy == "" {
+		filter.GroupBy = GroupByProject
+	}
+	if filter.GroupBy != GroupByProject && filter.GroupBy != GroupByFeature {
+		return nil, fmt.Errorf("invalid grouping: %s (use project or feature)", filter.GroupBy)
+	}
+	if filter.Now == 0 {
+		filter.Now = time.Now().UnixMilli()
+	}
+
+	events, err := ListPipelineEvents(db, PipelineEventFilter{Project: filter.Project, FeatureName: filter.FeatureName})
+	if err != nil {
+		return nil, err
+	}
+	spawns, err := listSpawns(db, filter)
+	if err != nil {
+		return nil, err
+	}
+	features, err := ListFeatures(db, FeatureFilter{Project: filter.Project})
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := featureSessions(db, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	inWindow := func(ts int64) bool {
+		return (filter.Since == 0 || ts >= filter.Since) && (filter.Until == 0 || ts <= filter.Until)
+	}
+	key := func(project, feature string) string {
+		if filter.GroupBy == GroupByFeature {
+			return project + "\x00" + feature
+		}
+		return project
+	}
+
+	groups := map[string]*statsBuilder{}
+	group := func(project, feature string) *statsBuilder {
+		k := key(project, feature)
+		if groups[k] == nil {
+			g := &models.StatsGroup{Project: project}
+			if filter.GroupBy == GroupByFeature {
+				g.Feature = feature
+			}
+			groups[k] = newStatsBuilder(g)
+		}
+		return groups[k]
+	}
+
+	// Stage runs, revisions and completions per feature log
+	runs, completions, revisions, earliest := stageRuns(events, spawns, inWindow)
+	for _, run := range runs {
+		group(run.project, run.feature).addRun(run)
+	}
+	for _, e := range completions {
+		b := group(e.Project, e.FeatureName)
+		b.throughput.StagesCompleted++
+		b.feature(e.FeatureName).StagesCompleted++
+	}
+	for _, e := range revisions {
+		b := group(e.Project, e.FeatureName)
+		b.stage(*e.Stage).RevisionsRequested++
+		b.feature(e.FeatureName).Revisions++
+	}
+
+	// Spawns in the window, attributed to the session's feature
+	for _, s := range spawns {
+		if !inWindow(s.timestamp) || s.project == "" {
+			continue
+		}
+		if filter.FeatureName != "" && s.feature != filter.FeatureName {
+			continue
+		}
+		group(s.project, s.feature).agent(s.agent, s.model).Spawns++
+	}
+
+	for _, s := range sessions {
+		if inWindow(s.startedAt) {
+			group(s.project, s.feature).feature(s.feature).Sessions++
+		}
+	}
+
+	for _, f := range features {
+		if filter.FeatureName != "" && f.FeatureName != filter.FeatureName {
+			continue
+		}
+		completed := f.Status == "completed"
+		b := groups[key(f.Project, f.FeatureName)]
+		if b == nil && !(completed && inWindow(f.UpdatedAt)) {
+			continue
+		}
+		if b == nil {
+			b = group(f.Project, f.FeatureName)
+		}
+		fs := b.feature(f.FeatureName)
+		fs.Status = f.Status
+		if completed {
+			fs.LeadTimeMs = f.UpdatedAt - f.CreatedAt
+			if inWindow(f.UpdatedAt) {
+				b.throughput.FeaturesCompleted++
+			}
+		}
+	}
+
+	// The window spans Since..Until, or the group's first event..now
+	until := filter.Until
+	if until == 0 || until > filter.Now {
+		until = filter.Now
+	}
+	result := &models.Stats{Since: filter.Since, Until: filter.Until, GroupBy: filter.GroupBy, Groups: []*models.StatsGroup{}}
+	for k, b := range groups {
+		since := filter.Since
+		if since == 0 {
+			first, ok := earliest[k]
+			if !ok {
+				first = until
+			}
+			since = first
+		}
+		result.Groups = append(result.Groups, b.finish(float64(until-since)/float64(24*time.Hour/time.Millisecond)))
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		gi, gj := result.Groups[i], result.Groups[j]
+		if gi.Project != gj.Project {
+			return gi.Project < gj.Project
+		}
+		return gi.Feature < gj.Feature
+	})
+	return result, nil
+}
+
+// stageRuns replays each feature's events into timed stage runs
+// Returns the runs, completed and revision-requested events that fall in the
+// window, and the first event time of every project and feature
+func stageRuns(events []*models.PipelineEvent, spawns []*spawn, inWindow func(int64) bool) ([]*stageRun, []*models.PipelineEvent, []*models.PipelineEvent, map[string]int64) {
+	logs := map[string][]*models.PipelineEvent{}
+	var order []string
+	for _, e := range events {
+		k := e.Project + "\x00" + e.FeatureName
+		if logs[k] == nil {
+			order = append(order, k)
+		}
+		logs[k] = append(logs[k], e)
+	}
+
+	// Spawn steps by session and stage number, latest last
+	bySession := map[string][]*spawn{}
+	for _, s := range spawns {
+		if s.stage != nil {
+			k := s.sessionID + "\x00" + strconv.FormatInt(*s.stage, 10)
+			bySession[k] = append(bySession[k], s)
+		}
+	}
+
+	var runs []*stageRun
+	var completions, revisions []*models.PipelineEvent
+	earliest := map[string]int64{}
+	for _, k := range order {
+		log := logs[k]
+		sort.SliceStable(log, func(i, j int) bool { return log[i].Seq < log[j].Seq })
+		for _, ek := range []string{log[0].Project, k} {
+			if first, ok := earliest[ek]; !ok || log[0].Timestamp < first {
+				earliest[ek] = log[0].Timestamp
+			}
+		}
+
+		starts := map[string]int64{}
+		done := map[string]bool{}
+		for _, e := range log {
+			stage := deref(e.Stage)
+			switch e.EventType {
+			case "created", "baseline", "started":
+				if stage != "" {
+					starts[stage] = e.Timestamp
+				}
+			case "revision-requested":
+				// The reopened stage and the reset ones restart now; reset stages
+				// that start later get a started event of their own
+				for id := range done {
+					if _, running := starts[id]; !running {
+						starts[id] = e.Timestamp
+					}
+				}
+				if inWindow(e.Timestamp) && stage != "" {
+					revisions = append(revisions, e)
+				}
+			case "completed":
+				if inWindow(e.Timestamp) {
+					completions = append(completions, e)
+				}
+				start, ok := starts[stage]
+				delete(starts, stage)
+				done[stage] = true
+				if !ok || !inWindow(e.Timestamp) {
+					continue
+				}
+				run := &stageRun{project: e.Project, feature: e.FeatureName, stage: stage, start: start, end: e.Timestamp, agent: deref(e.AgentName)}
+				if s := spawnFor(bySession, e); s != nil {
+					run.agent, run.model = s.agent, s.model
+				}
+				runs = append(runs, run)
+			}
+		}
+	}
+	return runs, completions, revisions, earliest
+}
+
+// spawnFor returns the agent_spawn step behind a completion: the latest spawn
+// for the stage in the event's session, preferring the event's own agent
+func spawnFor(bySession map[string][]*spawn, e *models.PipelineEvent) *spawn {
+	if e.SessionID == nil || e.Stage == nil {
+		return nil
+	}
+	n, _, _ := strings.Cut(*e.Stage, "-")
+	candidates := bySession[*e.SessionID+"\x00"+n]
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if strings.EqualFold(candidates[i].agent, deref(e.AgentName)) {
+			return candidates[i]
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[len(candidates)-1]
+	}
+	return nil
+}
+
+// listSpawns returns agent_spawn steps with their session's project and feature
+func listSpawns(db *sql.DB, filter StatsFilter) ([]*spawn, error) {
+	query := `
+		SELECT st.session_id, COALESCE(st.agent_name, ''), COALESCE(st.agent_model, ''),
+		       st.pipeline_stage, st.timestamp,
+		       COALESCE(se.project, ''), COALESCE(se.feature_name, '')
+		FROM steps st
+		LEFT JOIN sessions se ON se.session_id = st.session_id
+		WHERE st.step_type = 'agent_spawn'
+	`
+	var args []interface{}
+	if filter.Project != "" {
+		query += " AND se.project = ?"
+		args = append(args, filter.Project)
+	}
+	query += " ORDER BY st.timestamp ASC, st.id ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent spawns: %w", err)
+	}
+	defer rows.Close()
+
+	var spawns []*spawn
+	for rows.Next() {
+		s := &spawn{}
+		if err := rows.Scan(&s.sessionID, &s.agent, &s.model, &s.stage, &s.timestamp, &s.project, &s.feature); err != nil {
+			return nil, fmt.Errorf("failed to scan agent spawn: %w", err)
+		}
+		spawns = append(spawns, s)
+	}
+	return spawns, rows.Err()
+}
+
+// featureSession is a session that worked on a feature
+type featureSession struct {
+	project, feature string
+	startedAt        int64
+}
+
+// featureSessions returns the distinct sessions of every feature: those started
+// for it and those that wrote its pipeline events
+func featureSessions(db *sql.DB, filter StatsFilter) ([]*featureSession, error) {
+	query := `
+		SELECT project, feature_name, MIN(started_at) FROM (
+			SELECT session_id, project, feature_name, started_at
+			FROM sessions WHERE feature_name IS NOT NULL
+			UNION ALL
+			SELECT session_id, project, feature_name, MIN(timestamp)
+			FROM pipeline_events WHERE session_id IS NOT NULL
+			GROUP BY session_id, project, feature_name
+		)
+		WHERE (? = '' OR project = ?) AND (? = '' OR feature_name = ?)
+		GROUP BY session_id, project, feature_name
+	`
+
+	rows, err := db.Query(query, filter.Project, filter.Project, filter.FeatureName, filter.FeatureName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*featureSession
+	for rows.Next() {
+		s := &featureSession{}
+		if err := rows.Scan(&s.project, &s.feature, &s.startedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// statsBuilder accumulates one group
+type statsBuilder struct {
+	group      *models.StatsGroup
+	stages     map[string]*models.StageStats
+	durations  map[string][]int64
+	agents     map[string]*models.AgentStats
+	features   map[string]*models.FeatureStats
+	throughput *models.Throughput
+}
+
+func newStatsBuilder(g *models.StatsGroup) *statsBuilder {
+	return &statsBuilder{
+		group:      g,
+		stages:     map[string]*models.StageStats{},
+		durations:  map[string][]int64{},
+		agents:     map[string]*models.AgentStats{},
+		features:   map[string]*models.FeatureStats{},
+		throughput: &models.Throughput{},
+	}
+}
+
+func (b *statsBuilder) stage(id string) *models.StageStats {
+	if b.stages[id] == nil {
+		b.stages[id] = &models.StageStats{Stage: id}
+	}
+	return b.stages[id]
+}
+
+func (b *statsBuilder) agent(name, model string) *models.AgentStats {
+	k := strings.ToLower(name) + "\x00" + model
+	if b.agents[k] == nil {
+		b.agents[k] = &models.AgentStats{Agent: name, Model: model}
+	}
+	return b.agents[k]
+}
+
+func (b *statsBuilder) feature(name string) *models.FeatureStats {
+	if b.features[name] == nil {
+		b.features[name] = &models.FeatureStats{Feature: name}
+	}
+	return b.features[name]
+}
+
+// addRun credits a timed stage run to its stage, agent and feature
+func (b *statsBuilder) addRun(run *stageRun) {
+	d := run.end - run.start
+	st := b.stage(run.stage)
+	st.Completions++
+	st.TotalMs += d
+	if d > st.MaxMs {
+		st.MaxMs = d
+	}
+	b.durations[run.stage] = append(b.durations[run.stage], d)
+
+	if run.agent != "" {
+		a := b.agent(run.agent, run.model)
+		a.Stages++
+		a.TotalMs += d
+	}
+	b.feature(run.feature).ActiveMs += d
+}
+
+// finish computes averages and rates and sorts the group's tables
+func (b *statsBuilder) finish(days float64) *models.StatsGroup {
+	g := b.group
+	g.Stages = []*models.StageStats{}
+	for id, st := range b.stages {
+		if d := b.durations[id]; len(d) > 0 {
+			sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+			st.AvgMs = st.TotalMs / int64(len(d))
+			st.MedianMs = d[len(d)/2]
+			if len(d)%2 == 0 {
+				st.MedianMs = (d[len(d)/2-1] + d[len(d)/2]) / 2
+			}
+		}
+		g.Stages = append(g.Stages, st)
+	}
+	sort.Slice(g.Stages, func(i, j int) bool {
+		ni, nj := stageOrder(g.Stages[i].Stage), stageOrder(g.Stages[j].Stage)
+		if ni != nj {
+			return ni < nj
+		}
+		return g.Stages[i].Stage < g.Stages[j].Stage
+	})
+
+	g.Agents = []*models.AgentStats{}
+	for _, a := range b.agents {
+		g.Agents = append(g.Agents, a)
+	}
+	sort.Slice(g.Agents, func(i, j int) bool {
+		if g.Agents[i].TotalMs != g.Agents[j].TotalMs {
+			return g.Agents[i].TotalMs > g.Agents[j].TotalMs
+		}
+		if g.Agents[i].Agent != g.Agents[j].Agent {
+			return g.Agents[i].Agent < g.Agents[j].Agent
+		}
+		return g.Agents[i].Model < g.Agents[j].Model
+	})
+
+	g.Features = []*models.FeatureStats{}
+	for _, f := range b.features {
+		g.Features = append(g.Features, f)
+	}
+	sort.Slice(g.Features, func(i, j int) bool { return g.Features[i].Feature < g.Features[j].Feature })
+
+	t := b.throughput
+	t.Days = round1(days)
+	if weeks := days / 7; weeks > 0 {
+		t.StagesPerWeek = round1(float64(t.StagesCompleted) / weeks)
+		t.FeaturesPerWeek = round1(float64(t.FeaturesCompleted) / weeks)
+	}
+	g.Throughput = t
+	return g
+}
+
+// round1 rounds to one decimal place
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
+
+// stageOrder returns the numeric prefix of a stage ID, or a large number if it has none
+func stageOrder(stage string) int {
+	n, err := strconv.Atoi(strings.SplitN(stage, "-", 2)[0])
+	if err != nil {
+		return 1 << 30
+	}
+	return n
+}
+
+// deref returns the string a nullable column points to, or ""
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	hour = int64(60 * 60 * 1000)
+	day  = 24 * hour
+)
+
+// seedStats records feature auth of project app: the PRD is written, its review
+// asks for revisions, and both stages are redone in session s1
+func seedStats(t *testing.T) *sql.DB {
+	t.Helper()
+	db := NewTestDBWithSchema(t)
+
+	require.NoError(t, CreateSession(db, &models.Session{SessionID: "s1", Project: "app", FeatureName: stringPtr("auth"), StartedAt: 0, Status: "active"}))
+	prd, review := int64(1), int64(2)
+	recordSpawn(t, db, "s1", "athena", "opus", &prd, 0)
+	recordSpawn(t, db, "s1", "athena", "sonnet", &review, 3*hour)
+
+	event := func(seq int64, eventType, stage string, ts int64) *models.PipelineEvent {
+		e := newPipelineEvent(seq, eventType, stage, ts)
+		e.Project = "app"
+		e.SessionID = stringPtr("s1")
+		e.AgentName = stringPtr("athena")
+		return e
+	}
+	require.NoError(t, InsertPipelineEvents(db, []*models.PipelineEvent{
+		event(1, "created", "1-prd", 0),
+		event(2, "completed", "1-prd", 2*hour),
+		event(3, "started", "2-prd-review", 3*hour),
+		event(4, "completed", "2-prd-review", 4*hour),
+		event(5, "revision-requested", "2-prd-review", 4*hour),
+		event(6, "completed", "1-prd", 5*hour),
+		event(7, "started", "2-prd-review", 5*hour),
+		event(8, "completed", "2-prd-review", 5*hour+hour/2),
+	}))
+
+	feature := newFeature("app", "auth", 2)
+	feature.CreatedAt = 0
+	feature.UpdatedAt = 6 * hour
+	feature.Status = "completed"
+	require.NoError(t, UpsertFeature(db, feature))
+	return db
+}
+
+// recordSpawn inserts an agent_spawn step at ts
+func recordSpawn(t *testing.T, db *sql.DB, sessionID, agent, model string, stage *int64, ts int64) {
+	t.Helper()
+	require.NoError(t, CreateStep(db, &models.Step{
+		SessionID:     sessionID,
+		StepNumber:    ts + 1,
+		StepType:      "agent_spawn",
+		Timestamp:     ts,
+		AgentName:     &agent,
+		AgentModel:    &model,
+		PipelineStage: stage,
+		Action:        "spawn " + agent,
+	}))
+}
+
+func TestStats_CycleTimesAndEffort(t *testing.T) {
+	db := seedStats(t)
+
+	stats, err := Stats(db, StatsFilter{Now: 7 * day})
+	require.NoError(t, err)
+	assert.Equal(t, GroupByProject, stats.GroupBy)
+	require.Len(t, stats.Groups, 1)
+	g := stats.Groups[0]
+	assert.Equal(t, "app", g.Project)
+
+	require.Len(t, g.Stages, 2)
+	prd, review := g.Stages[0], g.Stages[1]
+	assert.Equal(t, "1-prd", prd.Stage)
+	assert.Equal(t, 2, prd.Completions)
+	assert.Equal(t, 3*hour, prd.TotalMs) // 2h, then 1h after the revision reopened it
+	assert.Equal(t, 2*hour, prd.MaxMs)
+	assert.Equal(t, 3*hour/2, prd.AvgMs)
+	assert.Equal(t, 3*hour/2, prd.MedianMs)
+	assert.Equal(t, 0, prd.RevisionsRequested)
+	assert.Equal(t, "2-prd-review", review.Stage)
+	assert.Equal(t, 3*hour/2, review.TotalMs)
+	assert.Equal(t, 1, review.RevisionsRequested)
+
+	require.Len(t, g.Agents, 2)
+	assert.Equal(t, &models.AgentStats{Agent: "athena", Model: "opus", Spawns: 1, Stages: 2, TotalMs: 3 * hour}, g.Agents[0])
+	assert.Equal(t, &models.AgentStats{Agent: "athena", Model: "sonnet", Spawns: 1, Stages: 2, TotalMs: 3 * hour / 2}, g.Agents[1])
+
+	require.Len(t, g.Features, 1)
+	assert.Equal(t, &models.FeatureStats{
+		Feature:         "auth",
+		Status:          "completed",
+		Sessions:        1,
+		StagesCompleted: 4,
+		Revisions:       1,
+		ActiveMs:        9 * hour / 2,
+		LeadTimeMs:      6 * hour,
+	}, g.Features[0])
+
+	assert.Equal(t, &models.Throughput{Days: 7, StagesCompleted: 4, FeaturesCompleted: 1, StagesPerWeek: 4, FeaturesPerWeek: 1}, g.Throughput)
+}
+
+func TestStats_Window(t *testing.T) {
+	db := seedStats(t)
+
+	// Only the reworked stages complete after 4.5h; the revision came before
+	stats, err := Stats(db, StatsFilter{Project: "app", Since: 4*hour + hour/2, Until: day, Now: 7 * day})
+	require.NoError(t, err)
+	require.Len(t, stats.Groups, 1)
+	g := stats.Groups[0]
+
+	require.Len(t, g.Stages, 2)
+	assert.Equal(t, 1, g.Stages[0].Completions)
+	assert.Equal(t, hour, g.Stages[0].TotalMs)
+	assert.Equal(t, 0, g.Stages[1].RevisionsRequested)
+	assert.Equal(t, 2, g.Throughput.StagesCompleted)
+	assert.Equal(t, 1, g.Throughput.FeaturesCompleted)
+	assert.Equal(t, 0, g.Agents[0].Spawns)
+
+	stats, err = Stats(db, StatsFilter{Project: "other"})
+	require.NoError(t, err)
+	assert.Empty(t, stats.Groups)
+}
+
+func TestStats_GroupByFeature(t *testing.T) {
+	db := seedStats(t)
+	require.NoError(t, InsertPipelineEvents(db, []*models.PipelineEvent{
+		{Project: "app", FeatureName: "billing", Seq: 1, Timestamp: day, EventType: "created", Stage: stringPtr("1-prd")},
+		{Project: "app", FeatureName: "billing", Seq: 2, Timestamp: day + hour, EventType: "completed", Stage: stringPtr("1-prd")},
+	}))
+
+	stats, err := Stats(db, StatsFilter{GroupBy: GroupByFeature, Now: 7 * day})
+	require.NoError(t, err)
+	require.Len(t, stats.Groups, 2)
+	assert.Equal(t, "auth", stats.Groups[0].Feature)
+	assert.Equal(t, "billing", stats.Groups[1].Feature)
+	assert.Equal(t, hour, stats.Groups[1].Stages[0].TotalMs)
+	assert.Equal(t, float64(6), stats.Groups[1].Throughput.Days)
+
+	stats, err = Stats(db, StatsFilter{FeatureName: "billing", Now: 7 * day})
+	require.NoError(t, err)
+	require.Len(t, stats.Groups, 1)
+	assert.Equal(t, 1, stats.Groups[0].Throughput.StagesCompleted)
+
+	_, err = Stats(db, StatsFilter{GroupBy: "agent"})
+	assert.Error(t, err)
+}
@@ -8,16 +8,33 @@ import (
 	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
 )
 
+// execer runs statements on either a connection or a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // CreateStep inserts a step into the database
 func CreateStep(db *sql.DB, step *models.Step) error {
+	return createStep(db, step)
+}
+
+// createStep inserts a step through db, which may be a transaction
+func createStep(db execer, step *models.Step) error {
 	query := `
 		INSERT INTO steps (
 			session_id, step_number, step_type, timestamp,
 			agent_name, agent_model, pipeline_stage,
-			action, target, result, context
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			action, target, result, context,
+			input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, duration_ms
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	var usage [5]interface{}
+	if u := step.Usage; u != nil {
+		usage = [5]interface{}{u.InputTokens, u.OutputTokens, u.CacheCreationTokens, u.CacheReadTokens, u.DurationMs}
+	}
+
 	result, err := db.Exec(query,
 		step.SessionID,
 		step.StepNumber,
@@ -30,6 +47,7 @@ func CreateStep(db *sql.DB, step *models.Step) error {
 		step.Target,
 		step.Result,
 		step.Context,
+		usage[0], usage[1], usage[2], usage[3], usage[4],
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create step: %w", err)
@@ -49,7 +67,8 @@ func GetStepsForSession(db *sql.DB, sessionID string) ([]*models.Step, error) {
 	query := `
 		SELECT id, session_id, step_number, step_type, timestamp,
 		       agent_name, agent_model, pipeline_stage,
-		       action, target, result, context
+		       action, target, result, context,
+		       input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, duration_ms
 		FROM steps
 		WHERE session_id = ?
 		ORDER BY step_number ASC
@@ -64,6 +83,7 @@ func GetStepsForSession(db *sql.DB, sessionID string) ([]*models.Step, error) {
 	steps := []*models.Step{}
 	for rows.Next() {
 		step := &models.Step{}
+		var usage [5]sql.NullInt64
 		err := rows.Scan(
 			&step.ID,
 			&step.SessionID,
@@ -77,10 +97,12 @@ func GetStepsForSession(db *sql.DB, sessionID string) ([]*models.Step, error) {
 			&step.Target,
 			&step.Result,
 			&step.Context,
+			&usage[0], &usage[1], &usage[2], &usage[3], &usage[4],
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan step: %w", err)
 		}
+		step.Usage = scanUsage(usage)
 		steps = append(steps, step)
 	}
 
@@ -89,6 +111,11 @@ func GetStepsForSession(db *sql.DB, sessionID string) ([]*models.Step, error) {
 
 // IncrementSessionSteps increments the total_steps counter for a session
 func IncrementSessionSteps(db *sql.DB, sessionID string) error {
+	return incrementSessionSteps(db, sessionID)
+}
+
+// incrementSessionSteps increments total_steps through db, which may be a transaction
+func incrementSessionSteps(db execer, sessionID string) error {
 	query := `
 		UPDATE sessions
 		SET total_steps = total_steps + 1
@@ -103,8 +130,28 @@ func IncrementSessionSteps(db *sql.DB, sessionID string) error {
 	return nil
 }
 
+// scanUsage builds a step's token usage from its nullable columns, nil when none is set
+func scanUsage(columns [5]sql.NullInt64) *models.TokenUsage {
+	valid := false
+	for _, c := range columns {
+		valid = valid || c.Valid
+	}
+	if !valid {
+		return nil
+	}
+	return &models.TokenUsage{
+		InputTokens:         columns[0].Int64,
+		OutputTokens:        columns[1].Int64,
+		CacheCreationTokens: columns[2].Int64,
+		CacheReadTokens:     columns[3].Int64,
+		DurationMs:          columns[4].Int64,
+	}
+}
+
 // RecordAgentSpawn records an agent spawn step
-func RecordAgentSpawn(db *sql.DB, sessionID, agentName, agentModel, action string) error {
+// stage is the numeric pipeline stage the agent works on, nil if unknown;
+// usage is the run's token usage, nil if not reported
+func RecordAgentSpawn(db *sql.DB, sessionID, agentName, agentModel, action string, stage *int64, usage *models.TokenUsage) error {
 	// Get next step number
 	var stepNum int64
 	err := db.QueryRow("SELECT COALESCE(MAX(step_number), 0) + 1 FROM steps WHERE session_id = ?", sessionID).Scan(&stepNum)
@@ -113,13 +160,15 @@ func RecordAgentSpawn(db *sql.DB, sessionID, agentName, agentModel, action strin
 	}
 
 	step := &models.Step{
-		SessionID:  sessionID,
-		StepNumber: stepNum,
-		StepType:   "agent_spawn",
-		Timestamp:  time.Now().UnixMilli(),
-		AgentName:  &agentName,
-		AgentModel: &agentModel,
-		Action:     action,
+		SessionID:     sessionID,
+		StepNumber:    stepNum,
+		StepType:      "agent_spawn",
+		Timestamp:     time.Now().UnixMilli(),
+		AgentName:     &agentName,
+		AgentModel:    &agentModel,
+		PipelineStage: stage,
+		Action:        action,
+		Usage:         usage,
 	}
 
 	if err := CreateStep(db, step); err != nil {
@@ -136,27 +185,10 @@ func RecordAgentSpawn(db *sql.DB, sessionID, agentName, agentModel, action strin
 	return err
 }
 
-// RecordFileChange records a file modification step
+// RecordFileChange records a file modification step and its file_changes row
 func RecordFileChange(db *sql.DB, sessionID, action, filePath string) error {
-	// Get next step number
-	var stepNum int64
-	err := db.QueryRow("SELECT COALESCE(MAX(step_number), 0) + 1 FROM steps WHERE session_id = ?", sessionID).Scan(&stepNum)
-	if err != nil {
-		return fmt.Errorf("failed to get step number: %w", err)
-	}
-
-	step := &models.Step{
-		SessionID:  sessionID,
-		StepNumber: stepNum,
-		StepType:   "file_modify",
-		Timestamp:  time.Now().UnixMilli(),
-		Action:     action,
-		Target:     &filePath,
-	}
-
-	if err := CreateStep(db, step); err != nil {
-		return err
-	}
-
-	return IncrementSessionSteps(db, sessionID)
+	return RecordFileChangeDetail(db, action, &models.FileChange{
+		SessionID: sessionID,
+		FilePath:  filePath,
+	})
 }
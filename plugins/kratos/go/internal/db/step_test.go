@@ -120,7 +120,8 @@ func TestRecordAgentSpawn(t *testing.T) {
 	require.NoError(t, err)
 
 	// Record agent spawn
-	err = RecordAgentSpawn(db, "test-session", "athena", "opus", "create_prd")
+	stage := int64(1)
+	err = RecordAgentSpawn(db, "test-session", "athena", "opus", "create_prd", &stage, nil)
 	require.NoError(t, err)
 
 	// Verify step created
@@ -130,12 +131,23 @@ func TestRecordAgentSpawn(t *testing.T) {
 	assert.Equal(t, "agent_spawn", steps[0].StepType)
 	assert.Equal(t, "athena", *steps[0].AgentName)
 	assert.Equal(t, "opus", *steps[0].AgentModel)
+	require.NotNil(t, steps[0].PipelineStage)
+	assert.Equal(t, int64(1), *steps[0].PipelineStage)
+	assert.Nil(t, steps[0].Usage)
 
 	// Verify count incremented
 	updated, err := GetSession(db, "test-session")
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), updated.TotalAgentsSpawned)
 	assert.Equal(t, int64(1), updated.TotalSteps)
+
+	// Token usage round-trips
+	usage := &models.TokenUsage{InputTokens: 1200, OutputTokens: 800, CacheCreationTokens: 300, CacheReadTokens: 5000, DurationMs: 42000}
+	require.NoError(t, RecordAgentSpawn(db, "test-session", "athena", "sonnet", "review_prd", nil, usage))
+	steps, err = GetStepsForSession(db, "test-session")
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	assert.Equal(t, usage, steps[1].Usage)
 }
 
 // Test 5: Record file change
@@ -178,13 +190,13 @@ func TestStepOrdering(t *testing.T) {
 	require.NoError(t, err)
 
 	// Record multiple steps
-	err = RecordAgentSpawn(db, "test-session", "athena", "opus", "create_prd")
+	err = RecordAgentSpawn(db, "test-session", "athena", "opus", "create_prd", nil, nil)
 	require.NoError(t, err)
 
 	err = RecordFileChange(db, "test-session", "Write", "prd.md")
 	require.NoError(t, err)
 
-	err = RecordAgentSpawn(db, "test-session", "hephaestus", "opus", "create_spec")
+	err = RecordAgentSpawn(db, "test-session", "hephaestus", "opus", "create_spec", nil, nil)
 	require.NoError(t, err)
 
 	// Verify ordering
@@ -0,0 +1,178 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// progressWidth is the number of cells in a board progress bar
+const progressWidth = 10
+
+// FormatBoard formats feature pipelines as a human-readable dashboard
+func FormatBoard(entries []*models.BoardEntry) string {
+	if len(entries) == 0 {
+		return "No features found\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d feature(s):\n\n", len(entries)))
+
+	for i, entry := range entries {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, entry.Feature))
+		if entry.Priority != "" {
+			sb.WriteString(fmt.Sprintf(" [%s]", entry.Priority))
+		}
+		sb.WriteString(fmt.Sprintf(" %s\n", FormatHealth(entry.Health)))
+
+		if entry.Description != "" {
+			sb.WriteString(fmt.Sprintf("   %s\n", entry.Description))
+		}
+		sb.WriteString(fmt.Sprintf("   Progress: %s\n", formatProgress(entry)))
+		sb.WriteString(fmt.Sprintf("   Pipeline: %s\n", FormatStages(entry.Stages)))
+		sb.WriteString(fmt.Sprintf("   Stage: %s\n", entry.CurrentStage))
+		sb.WriteString(fmt.Sprintf("   Next: %s\n", formatNext(entry)))
+		if entry.LastActivity > 0 {
+			sb.WriteString(fmt.Sprintf("   Last activity: %s\n", FormatTimestamp(entry.LastActivity)))
+		}
+
+		for _, issue := range entry.Issues {
+			sb.WriteString(fmt.Sprintf("   ⚠️  %s\n", issue))
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// FormatBoardMarkdown formats feature pipelines as a markdown table followed by their issues
+func FormatBoardMarkdown(entries []*models.BoardEntry) string {
+	if len(entries) == 0 {
+		return "No features found.\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| # | Feature | Priority | Stage | Progress | Health | Next | Last activity |\n")
+	sb.WriteString("|---|---------|----------|-------|----------|--------|------|---------------|\n")
+
+	for i, entry := range entries {
+		lastActivity := "-"
+		if entry.LastActivity > 0 {
+			lastActivity = FormatTimestamp(entry.LastActivity)
+		}
+		sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s | %s | %s | %s |\n",
+			i+1,
+			markdownCell(entry.Feature),
+			markdownCell(orDash(entry.Priority)),
+			markdownCell(orDash(entry.CurrentStage)),
+			formatProgress(entry),
+			FormatHealth(entry.Health),
+			markdownCell(formatNext(entry)),
+			lastActivity,
+		))
+	}
+
+	var issues strings.Builder
+	for _, entry := range entries {
+		for _, issue := range entry.Issues {
+			issues.WriteString(fmt.Sprintf("- **%s**: %s\n", markdownCell(entry.Feature), markdownCell(issue)))
+		}
+	}
+	if issues.Len() > 0 {
+		sb.WriteString("\n### Issues\n\n")
+		sb.WriteString(issues.String())
+	}
+
+	return sb.String()
+}
+
+// FormatHealth formats a board health value with an emoji prefix
+func FormatHealth(health string) string {
+	switch health {
+	case "healthy":
+		return "🟢 healthy"
+	case "warning":
+		return "🟡 warning"
+	case "critical":
+		return "🔴 critical"
+	case "complete":
+		return "✅ complete"
+	default:
+		return health
+	}
+}
+
+// FormatStages formats a pipeline as numbered stage symbols, e.g. [1]✅ → [2]🔄 → [3]⏭️
+func FormatStages(stages []models.BoardStage) string {
+	parts := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		number, _, _ := strings.Cut(stage.ID, "-")
+		parts = append(parts, fmt.Sprintf("[%s]%s", number, stageSymbol(stage.Status)))
+	}
+	return strings.Join(parts, " → ")
+}
+
+// FormatProgressBar renders a percentage as a bar of width cells
+func FormatProgressBar(percent, width int) string {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	filled := percent * width / 100
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// stageSymbol returns the dashboard symbol of a stage status
+func stageSymbol(status string) string {
+	switch status {
+	case "complete":
+		return "✅"
+	case "in-progress", "ready":
+		return "🔄"
+	case "pending":
+		return "⏳"
+	case "skipped":
+		return "⏭️"
+	case "blocked":
+		return "🔒"
+	case "waiting-user":
+		return "👤"
+	default:
+		return "?"
+	}
+}
+
+// formatProgress formats a board entry's progress bar and stage count
+func formatProgress(entry *models.BoardEntry) string {
+	return fmt.Sprintf("%s %d%% (%d/%d)", FormatProgressBar(entry.Percent, progressWidth), entry.Percent, entry.StagesDone, entry.StagesTotal)
+}
+
+// formatNext formats a board entry's next action, e.g. "start 5-tech-spec (hephaestus)"
+func formatNext(entry *models.BoardEntry) string {
+	next := entry.NextAction
+	if entry.NextStage != "" {
+		next += " " + entry.NextStage
+	}
+	if entry.NextAgent != "" {
+		next += fmt.Sprintf(" (%s)", entry.NextAgent)
+	}
+	return next
+}
+
+// markdownCell escapes pipes and newlines so text fits in a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// orDash returns "-" for empty values
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
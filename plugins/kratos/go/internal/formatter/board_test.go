@@ -0,0 +1,65 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// boardEntries returns a blocked and a healthy board entry
+func boardEntries() []*models.BoardEntry {
+	return []*models.BoardEntry{
+		{
+			Feature:      "auth",
+			Priority:     "P1",
+			CurrentStage: "5-tech-spec",
+			Percent:      50,
+			StagesDone:   4,
+			StagesTotal:  8,
+			Stages:       []models.BoardStage{{ID: "1-prd", Status: "complete"}, {ID: "3-decomposition", Status: "skipped"}, {ID: "5-tech-spec", Status: "in-progress"}},
+			Health:       "critical",
+			Blocked:      true,
+			Issues:       []string{"gate | blocked"},
+			NextAction:   "blocked",
+			NextStage:    "6-spec-review-pm",
+		},
+		{
+			Feature:     "billing",
+			Percent:     0,
+			StagesTotal: 8,
+			Health:      "healthy",
+			NextAction:  "continue",
+			NextStage:   "1-prd",
+			NextAgent:   "athena",
+		},
+	}
+}
+
+func TestFormatBoard(t *testing.T) {
+	text := FormatBoard(boardEntries())
+
+	assert.Contains(t, text, "Found 2 feature(s)")
+	assert.Contains(t, text, "1. auth [P1] 🔴 critical")
+	assert.Contains(t, text, "█████░░░░░ 50% (4/8)")
+	assert.Contains(t, text, "[1]✅ → [3]⏭️ → [5]🔄")
+	assert.Contains(t, text, "Next: continue 1-prd (athena)")
+	assert.Contains(t, text, "⚠️  gate | blocked")
+
+	assert.Equal(t, "No features found\n", FormatBoard(nil))
+}
+
+func TestFormatBoardMarkdown(t *testing.T) {
+	md := FormatBoardMarkdown(boardEntries())
+
+	assert.Contains(t, md, "| # | Feature |")
+	assert.Contains(t, md, "| 2 | billing | - | - |")
+	assert.Contains(t, md, "### Issues")
+	assert.Contains(t, md, "- **auth**: gate \\| blocked")
+}
+
+func TestFormatProgressBar(t *testing.T) {
+	assert.Equal(t, "░░░░", FormatProgressBar(0, 4))
+	assert.Equal(t, "██░░", FormatProgressBar(50, 4))
+	assert.Equal(t, "████", FormatProgressBar(150, 4))
+}
@@ -0,0 +1,111 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// costCSVHeader is the column layout of FormatCostCSV
+var costCSVHeader = []string{
+	"breakdown", "name", "spawns", "input_tokens", "output_tokens",
+	"cache_creation_tokens", "cache_read_tokens", "duration_minutes", "cost_usd", "unpriced",
+}
+
+// FormatCost formats a cost report as one table per breakdown
+func FormatCost(report *models.CostReport) string {
+	if report.Total.Spawns == 0 {
+		msg := "No agent token usage recorded\n"
+		if report.UnmeteredSpawns > 0 {
+			msg += fmt.Sprintf("%d agent spawn(s) were recorded without token usage\n", report.UnmeteredSpawns)
+		}
+		return msg
+	}
+
+	var sb strings.Builder
+	t := report.Total
+	sb.WriteString(fmt.Sprintf("Total: $%.2f over %d spawn(s), %s tokens in, %s out, %s agent time\n",
+		t.CostUSD, t.Spawns, formatTokens(t.InputTokens+t.CacheCreationTokens+t.CacheReadTokens),
+		formatTokens(t.OutputTokens), formatMs(t.DurationMs)))
+	if report.UnmeteredSpawns > 0 {
+		sb.WriteString(fmt.Sprintf("Not metered: %d spawn(s) without token usage\n", report.UnmeteredSpawns))
+	}
+	if len(report.UnpricedModels) > 0 {
+		sb.WriteString(fmt.Sprintf("Not priced: %s (add them to the pricing table)\n", strings.Join(report.UnpricedModels, ", ")))
+	}
+
+	for _, section := range []struct {
+		title string
+		lines []*models.CostLine
+	}{
+		{"FEATURE", report.ByFeature},
+		{"AGENT", report.ByAgent},
+		{"MODEL", report.ByModel},
+		{"DAY", report.ByDay},
+	} {
+		sb.WriteString("\n")
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\tSPAWNS\tINPUT\tOUTPUT\tCACHE WRITE\tCACHE READ\tTIME\tCOST\n", section.title)
+		for _, l := range section.lines {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t$%.2f\n", orDash(l.Name), l.Spawns,
+				formatTokens(l.InputTokens), formatTokens(l.OutputTokens),
+				formatTokens(l.CacheCreationTokens), formatTokens(l.CacheReadTokens),
+				formatMs(l.DurationMs), l.CostUSD)
+		}
+		tw.Flush()
+	}
+
+	return sb.String()
+}
+
+// FormatCostCSV formats a cost report as one CSV table
+// The breakdown column is total, feature, agent, model or day
+func FormatCostCSV(report *models.CostReport) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{costCSVHeader}
+
+	row := func(breakdown string, l *models.CostLine) []string {
+		return []string{
+			breakdown, l.Name, strconv.Itoa(l.Spawns),
+			strconv.FormatInt(l.InputTokens, 10), strconv.FormatInt(l.OutputTokens, 10),
+			strconv.FormatInt(l.CacheCreationTokens, 10), strconv.FormatInt(l.CacheReadTokens, 10),
+			minutes(l.DurationMs), strconv.FormatFloat(l.CostUSD, 'f', 4, 64), strconv.Itoa(l.Unpriced),
+		}
+	}
+	rows = append(rows, row("total", report.Total))
+	for _, l := range report.ByFeature {
+		rows = append(rows, row("feature", l))
+	}
+	for _, l := range report.ByAgent {
+		rows = append(rows, row("agent", l))
+	}
+	for _, l := range report.ByModel {
+		rows = append(rows, row("model", l))
+	}
+	for _, l := range report.ByDay {
+		rows = append(rows, row("day", l))
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// formatTokens abbreviates a token count: 950, 12.3k, 1.5M
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
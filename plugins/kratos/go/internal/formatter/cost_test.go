@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// costReport returns a report of two spawns on feature auth
+func costReport() *models.CostReport {
+	opus := &models.CostLine{Name: "opus", Spawns: 1, TokenUsage: models.TokenUsage{InputTokens: 100000, OutputTokens: 20000, DurationMs: 60000}, CostUSD: 3}
+	local := &models.CostLine{Name: "local-llm", Spawns: 1, TokenUsage: models.TokenUsage{OutputTokens: 500}, Unpriced: 1}
+	return &models.CostReport{
+		Total:           &models.CostLine{Name: "total", Spawns: 2, TokenUsage: models.TokenUsage{InputTokens: 100000, OutputTokens: 20500, DurationMs: 60000}, CostUSD: 3, Unpriced: 1},
+		ByFeature:       []*models.CostLine{{Name: "auth", Spawns: 2, CostUSD: 3, Unpriced: 1}},
+		ByAgent:         []*models.CostLine{{Name: "athena", Spawns: 2, CostUSD: 3, Unpriced: 1}},
+		ByModel:         []*models.CostLine{opus, local},
+		ByDay:           []*models.CostLine{{Name: "2026-03-02", Spawns: 2, CostUSD: 3, Unpriced: 1}},
+		UnmeteredSpawns: 1,
+		UnpricedModels:  []string{"local-llm"},
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	text := FormatCost(costReport())
+
+	assert.Contains(t, text, "Total: $3.00 over 2 spawn(s), 100.0k tokens in, 20.5k out")
+	assert.Contains(t, text, "Not metered: 1 spawn(s)")
+	assert.Contains(t, text, "Not priced: local-llm")
+	for _, title := range []string{"FEATURE", "AGENT", "MODEL", "DAY"} {
+		assert.Contains(t, text, title+" ")
+	}
+	assert.Contains(t, text, "2026-03-02")
+
+	empty := FormatCost(&models.CostReport{Total: &models.CostLine{}, UnmeteredSpawns: 2})
+	assert.Equal(t, "No agent token usage recorded\n2 agent spawn(s) were recorded without token usage\n", empty)
+}
+
+func TestFormatCostCSV(t *testing.T) {
+	out, err := FormatCostCSV(costReport())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, strings.Join(costCSVHeader, ","), lines[0])
+	assert.Equal(t, "total,total,2,100000,20500,0,0,1.0,3.0000,1", lines[1])
+	assert.Equal(t, "model,opus,1,100000,20000,0,0,1.0,3.0000,0", lines[4])
+	assert.Equal(t, "day,2026-03-02,2,0,0,0,0,0.0,3.0000,1", lines[6])
+}
+
+func TestFormatTokens(t *testing.T) {
+	assert.Equal(t, "950", formatTokens(950))
+	assert.Equal(t, "12.3k", formatTokens(12345))
+	assert.Equal(t, "1.5M", formatTokens(1500000))
+}
@@ -0,0 +1,108 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// maxMemoryLine is the longest decision or todo shown in the memory context
+const maxMemoryLine = 100
+
+// EstimateTokens approximates the tokens of text at four characters per token
+func EstimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// FormatMemoryContext formats the project state injected with a Kratos prompt:
+// the active feature (nil if none) with its stage and next agent, the latest
+// decisions and the open todos, in that order of priority. Lines that would
+// take the text over budget tokens are dropped; empty if nothing fits
+func FormatMemoryContext(entry *models.BoardEntry, decisions []*models.Decision, todos []string, budget int) string {
+	w := &budgetWriter{budget: budget}
+	w.sb.WriteString("KRATOS MEMORY: current project state\n")
+	w.section("", featureLines(entry)...)
+
+	var lines []string
+	for _, d := range decisions {
+		line := d.Question + ": " + d.Choice
+		if d.AgentName != nil {
+			line += " (" + *d.AgentName + ")"
+		}
+		lines = append(lines, "    - "+clipLine(line, maxMemoryLine))
+	}
+	w.section("  Recent decisions:", lines...)
+
+	lines = nil
+	for _, todo := range todos {
+		lines = append(lines, "    - "+clipLine(todo, maxMemoryLine))
+	}
+	w.section(fmt.Sprintf("  Open todos (%d):", len(todos)), lines...)
+
+	if w.written == 0 {
+		return ""
+	}
+	return w.sb.String()
+}
+
+// featureLines describes the active feature, nil if there is none
+func featureLines(entry *models.BoardEntry) []string {
+	if entry == nil {
+		return nil
+	}
+	feature := entry.Feature
+	if entry.Description != "" {
+		feature += " — " + entry.Description
+	}
+	lines := []string{
+		"  Feature: " + clipLine(feature, maxMemoryLine),
+		fmt.Sprintf("  Stage: %s (%d/%d stages done)", orDash(entry.CurrentStage), entry.StagesDone, entry.StagesTotal),
+	}
+	if entry.NextAction != "" {
+		lines = append(lines, "  Next: "+formatNext(entry))
+	}
+	if entry.Blocked && len(entry.Issues) > 0 {
+		lines = append(lines, "  Blocked: "+clipLine(entry.Issues[0], maxMemoryLine))
+	}
+	return lines
+}
+
+// budgetWriter writes lines until the token budget is spent
+type budgetWriter struct {
+	sb      strings.Builder
+	budget  int
+	written int  // lines written after the heading
+	full    bool // a line was dropped, so later ones are too
+}
+
+// section writes a heading (if any) and as many of its lines as fit
+// The heading is only written along with the first line
+func (w *budgetWriter) section(heading string, lines ...string) {
+	for i, line := range lines {
+		text := line + "\n"
+		if i == 0 && heading != "" {
+			text = heading + "\n" + text
+		}
+		if !w.fits(text) {
+			w.full = true
+			return
+		}
+		w.sb.WriteString(text)
+		w.written++
+	}
+}
+
+// fits reports whether text can be added, once no earlier line was dropped
+func (w *budgetWriter) fits(text string) bool {
+	return !w.full && EstimateTokens(w.sb.String()+text) <= w.budget
+}
+
+// clipLine collapses whitespace and cuts s to max characters
+func clipLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-3]) + "..."
+	}
+	return s
+}
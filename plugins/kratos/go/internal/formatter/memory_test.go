@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMemoryContext(t *testing.T) {
+	entry := &models.BoardEntry{
+		Feature:      "auth",
+		Description:  "Login flow",
+		CurrentStage: "2-prd-review",
+		StagesDone:   1,
+		StagesTotal:  11,
+		NextAction:   "start",
+		NextStage:    "2-prd-review",
+		NextAgent:    "hermes",
+	}
+	agent := "apollo"
+	decisions := []*models.Decision{
+		{Question: "Session storage", Choice: "Postgres", AgentName: &agent},
+		{Question: "Token format", Choice: "JWT"},
+	}
+	todos := []string{"#2 Rotate keys", "#1 Update  the\nREADME"}
+
+	assert.Equal(t, "KRATOS MEMORY: current project state\n"+
+		"  Feature: auth — Login flow\n"+
+		"  Stage: 2-prd-review (1/11 stages done)\n"+
+		"  Next: start 2-prd-review (hermes)\n"+
+		"  Recent decisions:\n"+
+		"    - Session storage: Postgres (apollo)\n"+
+		"    - Token format: JWT\n"+
+		"  Open todos (2):\n"+
+		"    - #2 Rotate keys\n"+
+		"    - #1 Update the README\n", FormatMemoryContext(entry, decisions, todos, 1000))
+
+	// Lines past the budget are dropped, lowest priority first
+	assert.Equal(t, "KRATOS MEMORY: current project state\n"+
+		"  Feature: auth — Login flow\n"+
+		"  Stage: 2-prd-review (1/11 stages done)\n"+
+		"  Next: start 2-prd-review (hermes)\n"+
+		"  Recent decisions:\n"+
+		"    - Session storage: Postgres (apollo)\n", FormatMemoryContext(entry, decisions, todos, 55))
+
+	// Without a feature the other sections still show
+	assert.Equal(t, "KRATOS MEMORY: current project state\n"+
+		"  Open todos (1):\n"+
+		"    - #2 Rotate keys\n", FormatMemoryContext(nil, nil, todos[:1], 1000))
+
+	assert.Empty(t, FormatMemoryContext(nil, nil, nil, 1000))
+	assert.Empty(t, FormatMemoryContext(entry, decisions, todos, 5))
+}
@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// maxBannerAction is the longest action shown in the resume banner
+const maxBannerAction = 60
+
+// FormatResumeBanner formats the SessionStart banner offering to resume a feature
+// lastActive is Unix epoch ms; actions are the last session's latest actions, oldest first
+func FormatResumeBanner(entry *models.BoardEntry, lastActive int64, actions []string) string {
+	var sb strings.Builder
+	sb.WriteString("KRATOS MEMORY: Last session detected\n")
+
+	feature := entry.Feature
+	if entry.Description != "" {
+		feature += " — " + entry.Description
+	}
+	sb.WriteString(fmt.Sprintf("  Feature: %s\n", feature))
+	sb.WriteString(fmt.Sprintf("  Stage: %s (%d/%d stages done)\n", orDash(entry.CurrentStage), entry.StagesDone, entry.StagesTotal))
+	if lastActive > 0 {
+		sb.WriteString(fmt.Sprintf("  Last active: %s\n", FormatTimestamp(lastActive)))
+	}
+	if entry.Blocked && len(entry.Issues) > 0 {
+		sb.WriteString(fmt.Sprintf("  Blocked: %s\n", entry.Issues[0]))
+	}
+
+	if len(actions) > 0 {
+		sb.WriteString("  Last actions:\n")
+		for _, action := range actions {
+			action = strings.Join(strings.Fields(action), " ")
+			if len(action) > maxBannerAction {
+				action = action[:maxBannerAction-3] + "..."
+			}
+			sb.WriteString(fmt.Sprintf("    - %s\n", action))
+		}
+	}
+
+	if entry.NextStage != "" {
+		sb.WriteString(fmt.Sprintf("  Recommendation: %s\n", formatNext(entry)))
+		sb.WriteString("  Say \"continue\" or \"/kratos\" to resume\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatResumeBanner(t *testing.T) {
+	entries := boardEntries()
+	lastActive := time.Now().Add(-3 * time.Hour).UnixMilli()
+	long := strings.Repeat("x", 80)
+
+	banner := FormatResumeBanner(entries[0], lastActive, []string{"athena: Write PRD", long})
+	assert.Contains(t, banner, "KRATOS MEMORY: Last session detected\n")
+	assert.Contains(t, banner, "  Feature: auth\n")
+	assert.Contains(t, banner, "  Stage: 5-tech-spec (4/8 stages done)\n")
+	assert.Contains(t, banner, "  Last active: 3 hours ago\n")
+	assert.Contains(t, banner, "  Blocked: gate | blocked\n")
+	assert.Contains(t, banner, "    - athena: Write PRD\n")
+	assert.Contains(t, banner, "    - "+strings.Repeat("x", 57)+"...\n")
+	assert.Contains(t, banner, "  Recommendation: blocked 6-spec-review-pm\n")
+
+	entries[1].Description = "Invoices"
+	banner = FormatResumeBanner(entries[1], 0, nil)
+	assert.Contains(t, banner, "  Feature: billing — Invoices\n")
+	assert.Contains(t, banner, "  Stage: - (0/8 stages done)\n")
+	assert.Contains(t, banner, "  Recommendation: continue 1-prd (athena)\n")
+	assert.NotContains(t, banner, "Last active")
+	assert.NotContains(t, banner, "Last actions")
+}
@@ -0,0 +1,129 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// statsCSVHeader is the column layout shared by every row of FormatStatsCSV
+var statsCSVHeader = []string{
+	"project", "feature", "metric", "name", "model", "count",
+	"total_minutes", "avg_minutes", "median_minutes", "max_minutes", "revisions", "sessions", "spawns",
+}
+
+// FormatStats formats a stats report as tables, one block per group
+func FormatStats(stats *models.Stats) string {
+	if len(stats.Groups) == 0 {
+		return "No pipeline activity found\n"
+	}
+
+	var sb strings.Builder
+	for i, g := range stats.Groups {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(statsTitle(g) + "\n")
+		t := g.Throughput
+		sb.WriteString(fmt.Sprintf("Throughput: %d stage(s), %d feature(s) completed in %.1f days (%.1f stages/week, %.1f features/week)\n\n",
+			t.StagesCompleted, t.FeaturesCompleted, t.Days, t.StagesPerWeek, t.FeaturesPerWeek))
+
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "STAGE\tRUNS\tAVG\tMEDIAN\tMAX\tTOTAL\tREVISIONS")
+		for _, st := range g.Stages {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%d\n", st.Stage, st.Completions,
+				formatMs(st.AvgMs), formatMs(st.MedianMs), formatMs(st.MaxMs), formatMs(st.TotalMs), st.RevisionsRequested)
+		}
+		tw.Flush()
+		sb.WriteString("\n")
+
+		tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "AGENT\tMODEL\tSPAWNS\tSTAGES\tTIME")
+		for _, a := range g.Agents {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", a.Agent, orDash(a.Model), a.Spawns, a.Stages, formatMs(a.TotalMs))
+		}
+		tw.Flush()
+		sb.WriteString("\n")
+
+		tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "FEATURE\tSTATUS\tSESSIONS\tSTAGES\tREVISIONS\tACTIVE\tLEAD TIME")
+		for _, f := range g.Features {
+			lead := "-"
+			if f.LeadTimeMs > 0 {
+				lead = formatMs(f.LeadTimeMs)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n", orDash(f.Feature), orDash(f.Status),
+				f.Sessions, f.StagesCompleted, f.Revisions, formatMs(f.ActiveMs), lead)
+		}
+		tw.Flush()
+	}
+
+	return sb.String()
+}
+
+// FormatStatsCSV formats a stats report as one CSV table
+// The metric column tells stage, agent, feature and throughput rows apart
+func FormatStatsCSV(stats *models.Stats) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{statsCSVHeader}
+
+	for _, g := range stats.Groups {
+		// row fills the group columns; cells follows the header from "count" on
+		row := func(metric, name, model string, cells ...string) []string {
+			r := append([]string{g.Project, g.Feature, metric, name, model}, cells...)
+			for len(r) < len(statsCSVHeader) {
+				r = append(r, "")
+			}
+			return r
+		}
+		for _, st := range g.Stages {
+			rows = append(rows, row("stage", st.Stage, "", strconv.Itoa(st.Completions),
+				minutes(st.TotalMs), minutes(st.AvgMs), minutes(st.MedianMs), minutes(st.MaxMs), strconv.Itoa(st.RevisionsRequested)))
+		}
+		for _, a := range g.Agents {
+			rows = append(rows, row("agent", a.Agent, a.Model, strconv.Itoa(a.Stages), minutes(a.TotalMs),
+				"", "", "", "", "", strconv.Itoa(a.Spawns)))
+		}
+		for _, f := range g.Features {
+			rows = append(rows, row("feature", f.Feature, "", strconv.Itoa(f.StagesCompleted), minutes(f.ActiveMs),
+				"", "", "", strconv.Itoa(f.Revisions), strconv.Itoa(f.Sessions)))
+		}
+		t := g.Throughput
+		rows = append(rows,
+			row("throughput", "stages", "", strconv.Itoa(t.StagesCompleted)),
+			row("throughput", "features", "", strconv.Itoa(t.FeaturesCompleted)),
+		)
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// statsTitle names a stats group
+func statsTitle(g *models.StatsGroup) string {
+	if g.Feature != "" {
+		return fmt.Sprintf("== %s / %s ==", g.Project, g.Feature)
+	}
+	return fmt.Sprintf("== %s ==", g.Project)
+}
+
+// formatMs formats a duration in milliseconds, "-" for none
+func formatMs(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return FormatDuration(0, ms)
+}
+
+// minutes renders milliseconds as minutes with one decimal
+func minutes(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/60000, 'f', 1, 64)
+}
@@ -0,0 +1,64 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsReport returns a one-project report with a reworked PRD
+func statsReport() *models.Stats {
+	return &models.Stats{
+		GroupBy: "project",
+		Groups: []*models.StatsGroup{{
+			Project: "app",
+			Stages: []*models.StageStats{
+				{Stage: "1-prd", Completions: 2, TotalMs: 10800000, AvgMs: 5400000, MedianMs: 5400000, MaxMs: 7200000},
+				{Stage: "2-prd-review", Completions: 2, TotalMs: 5400000, AvgMs: 2700000, MedianMs: 2700000, MaxMs: 3600000, RevisionsRequested: 1},
+			},
+			Agents: []*models.AgentStats{
+				{Agent: "athena", Model: "opus", Spawns: 1, Stages: 2, TotalMs: 10800000},
+				{Agent: "hermes", Stages: 1},
+			},
+			Features: []*models.FeatureStats{
+				{Feature: "auth", Status: "completed", Sessions: 1, StagesCompleted: 4, Revisions: 1, ActiveMs: 16200000, LeadTimeMs: 21600000},
+			},
+			Throughput: &models.Throughput{Days: 7, StagesCompleted: 4, FeaturesCompleted: 1, StagesPerWeek: 4, FeaturesPerWeek: 1},
+		}},
+	}
+}
+
+func TestFormatStats(t *testing.T) {
+	text := FormatStats(statsReport())
+
+	assert.Contains(t, text, "== app ==")
+	assert.Contains(t, text, "Throughput: 4 stage(s), 1 feature(s) completed in 7.0 days")
+	assert.Contains(t, text, "STAGE")
+	assert.Contains(t, text, "2-prd-review")
+	assert.Contains(t, text, "completed")
+
+	// An agent without a recorded model or time shows dashes
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "hermes") {
+			assert.Equal(t, []string{"hermes", "-", "0", "1", "-"}, strings.Fields(line))
+		}
+	}
+
+	assert.Equal(t, "No pipeline activity found\n", FormatStats(&models.Stats{}))
+}
+
+func TestFormatStatsCSV(t *testing.T) {
+	out, err := FormatStatsCSV(statsReport())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, strings.Join(statsCSVHeader, ","), lines[0])
+	assert.Equal(t, "app,,stage,1-prd,,2,180.0,90.0,90.0,120.0,0,,", lines[1])
+	assert.Equal(t, "app,,agent,athena,opus,2,180.0,,,,,,1", lines[3])
+	assert.Equal(t, "app,,feature,auth,,4,270.0,,,,1,1,", lines[5])
+	assert.Equal(t, "app,,throughput,features,,1,,,,,,,", lines[7])
+}
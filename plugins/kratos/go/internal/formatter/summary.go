@@ -0,0 +1,71 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// maxSummaryFiles is the number of changed files listed in a session summary
+const maxSummaryFiles = 5
+
+// FormatSessionSummary formats the end-of-session summary stored with a session
+// currentStage is the stage the feature is at now, empty if unknown
+func FormatSessionSummary(a *models.SessionActivity, currentStage string) string {
+	duration := FormatDuration(0, a.DurationMs)
+	if a.Idle() {
+		return fmt.Sprintf("No recorded activity (%s)\n", duration)
+	}
+
+	var sb strings.Builder
+	subject := "Session"
+	if a.Feature != "" {
+		subject = "Worked on " + a.Feature
+	}
+	sb.WriteString(fmt.Sprintf("%s for %s (%d steps)\n", subject, duration, a.Steps))
+
+	if len(a.StagesCompleted) > 0 || currentStage != "" {
+		line := "Stages completed: " + orDash(strings.Join(a.StagesCompleted, ", "))
+		if currentStage != "" {
+			line += "; now at " + currentStage
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	if len(a.Agents) > 0 {
+		agents := make([]string, 0, len(a.Agents))
+		for _, agent := range a.Agents {
+			name := agent.Agent
+			if agent.Model != "" {
+				name += "/" + agent.Model
+			}
+			if agent.Spawns > 1 {
+				name += fmt.Sprintf(" ×%d", agent.Spawns)
+			}
+			agents = append(agents, name)
+		}
+		sb.WriteString("Agents: " + strings.Join(agents, ", ") + "\n")
+	}
+
+	if n := len(a.FilesChanged); n > 0 {
+		files := a.FilesChanged
+		if n > maxSummaryFiles {
+			files = files[:maxSummaryFiles]
+		}
+		line := fmt.Sprintf("Files changed (%d): %s", n, strings.Join(files, ", "))
+		if n > maxSummaryFiles {
+			line += fmt.Sprintf(" and %d more", n-maxSummaryFiles)
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	if a.Decisions > 0 {
+		sb.WriteString(fmt.Sprintf("Decisions: %d\n", a.Decisions))
+	}
+	if a.TodosClosed > 0 {
+		sb.WriteString(fmt.Sprintf("Todos closed: %d\n", a.TodosClosed))
+	}
+
+	return sb.String()
+}
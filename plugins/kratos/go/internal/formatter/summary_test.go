@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSessionSummary(t *testing.T) {
+	activity := &models.SessionActivity{
+		Feature:    "auth",
+		DurationMs: 65 * 60 * 1000,
+		Steps:      12,
+		Agents: []*models.AgentActivity{
+			{Agent: "athena", Model: "opus", Spawns: 2},
+			{Agent: "ares", Model: "sonnet", Spawns: 1},
+			{Agent: "hermes", Spawns: 1},
+		},
+		StagesCompleted: []string{"1-prd", "billing/3-decomposition"},
+		FilesChanged:    []string{"a.go", "b.go", "c.go", "d.go", "e.go", "f.go", "g.go"},
+		Decisions:       2,
+		TodosClosed:     3,
+	}
+
+	assert.Equal(t, "Worked on auth for 1h 5m (12 steps)\n"+
+		"Stages completed: 1-prd, billing/3-decomposition; now at 2-prd-review\n"+
+		"Agents: athena/opus ×2, ares/sonnet, hermes\n"+
+		"Files changed (7): a.go, b.go, c.go, d.go, e.go and 2 more\n"+
+		"Decisions: 2\n"+
+		"Todos closed: 3\n", FormatSessionSummary(activity, "2-prd-review"))
+
+	// Only the sections with activity are shown
+	activity = &models.SessionActivity{DurationMs: 90 * 1000, Steps: 1, FilesChanged: []string{"main.go"}}
+	assert.Equal(t, "Session for 1m (1 steps)\nFiles changed (1): main.go\n", FormatSessionSummary(activity, ""))
+
+	activity = &models.SessionActivity{DurationMs: 5 * 60 * 1000}
+	assert.Equal(t, "No recorded activity (5m)\n", FormatSessionSummary(activity, "1-prd"))
+}
@@ -0,0 +1,311 @@
+// Package gates checks a subagent's final message and deliverables before it may stop
+package gates
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/layered"
+)
+
+// defaultConfig is the built-in gate set
+//
+//go:embed default.json
+var defaultConfig []byte
+
+// Config is a layer of gates
+type Config struct {
+	// MaxBlocks is how often a gate may block the same subagent, for gates without their own
+	MaxBlocks int     `json:"max_blocks,omitempty" yaml:"max_blocks,omitempty"`
+	Gates     []*Gate `json:"gates" yaml:"gates"`
+}
+
+// Gate lists the checks an agent's work must pass
+type Gate struct {
+	Agent     string          `json:"agent" yaml:"agent"`
+	MinLength int             `json:"min_length,omitempty" yaml:"min_length,omitempty"` // characters of the final message
+	Sections  *SectionCheck   `json:"sections,omitempty" yaml:"sections,omitempty"`
+	Patterns  []*PatternCheck `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+	Files     []*FileCheck    `json:"files,omitempty" yaml:"files,omitempty"`
+	Hint      string          `json:"hint,omitempty" yaml:"hint,omitempty"` // how to pass, appended to the reason
+	MaxBlocks int             `json:"max_blocks,omitempty" yaml:"max_blocks,omitempty"`
+	Disabled  bool            `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+
+	// Source is "built-in" or the file the gate was read from
+	Source string `json:"source" yaml:"-"`
+}
+
+// SectionCheck requires the final message to cover named sections
+type SectionCheck struct {
+	Names    []string `json:"names" yaml:"names"`
+	Min      int      `json:"min,omitempty" yaml:"min,omitempty"`           // sections required, all if 0
+	Headings bool     `json:"headings,omitempty" yaml:"headings,omitempty"` // only markdown headings count
+	Message  string   `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// PatternCheck requires a regex to match the final message, or not to with Absent
+type PatternCheck struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Absent  bool   `json:"absent,omitempty" yaml:"absent,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// FileCheck requires a deliverable to exist
+// Path is relative to the repository and may be a glob; {feature} is the
+// folder of the feature the agent works on and {document} the document of the
+// agent's stage there. A check whose placeholders cannot be resolved is skipped
+type FileCheck struct {
+	Path      string `json:"path" yaml:"path"`
+	MinLength int    `json:"min_length,omitempty" yaml:"min_length,omitempty"` // bytes
+	Message   string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Work is what a stopping subagent produced
+type Work struct {
+	Message    string // the final assistant message
+	Root       string // repository root
+	FeatureDir string // folder of the feature the agent works on, empty if none
+	Document   string // path of the document of the agent's stage there, empty if none
+}
+
+// Result is the outcome of a gate
+type Result struct {
+	Agent     string   `json:"agent"`
+	Failures  []string `json:"failures"`
+	Hint      string   `json:"hint,omitempty"`
+	MaxBlocks int      `json:"max_blocks"`
+	Source    string   `json:"source"`
+}
+
+// Passed reports whether every check passed
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Reason explains a failed gate to the agent
+func (r *Result) Reason() string {
+	name := []rune(r.Agent)
+	name[0] = unicode.ToUpper(name[0])
+	reason := fmt.Sprintf("%s quality gate failed: %s.", string(name), strings.Join(r.Failures, "; "))
+	if r.Hint != "" {
+		reason += " " + r.Hint
+	}
+	return reason
+}
+
+// Engine checks work against compiled gates
+type Engine struct {
+	gates map[string]*compiledGate
+}
+
+type compiledGate struct {
+	*Gate
+	maxBlocks int
+	sections  []*regexp.Regexp
+	patterns  []*regexp.Regexp
+}
+
+// ProjectPaths returns the gate files a repository may hold, in load order
+func ProjectPaths(root string) []string {
+	return layered.ProjectPaths(root, "gates")
+}
+
+// Default returns the built-in gate set
+func Default() *Config {
+	cfg, err := Parse(defaultConfig, "default.json")
+	if err != nil {
+		panic(err) // the embedded file is covered by tests
+	}
+	for _, gate := range cfg.Gates {
+		gate.Source = "built-in"
+	}
+	return cfg
+}
+
+// Parse reads a gate layer, as YAML for .yaml/.yml paths and JSON otherwise
+func Parse(data []byte, path string) (*Config, error) {
+	cfg := &Config{}
+	if err := layered.Decode(data, path, "gates", cfg); err != nil {
+		return nil, err
+	}
+	for _, gate := range cfg.Gates {
+		gate.Agent = strings.ToLower(strings.TrimSpace(gate.Agent))
+		if gate.Agent == "" {
+			return nil, fmt.Errorf("invalid gates %s: gate without an agent", path)
+		}
+		gate.Source = path
+	}
+	return cfg, nil
+}
+
+// Load compiles the built-in gates overridden by the layers at paths
+func Load(paths ...string) (*Engine, error) {
+	cfg, err := LoadConfig(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(cfg)
+}
+
+// LoadConfig returns the built-in gates overridden by the layers at paths, in order
+// Missing files are skipped. A gate replaces the earlier gate of the same agent
+// (disabled: true drops it) and a non-zero max_blocks wins
+func LoadConfig(paths ...string) (*Config, error) {
+	return layered.Load(Default(), paths, "gates", Parse, merge)
+}
+
+// merge applies layer on top of base
+func merge(base, layer *Config) *Config {
+	out := &Config{MaxBlocks: base.MaxBlocks}
+	if layer.MaxBlocks != 0 {
+		out.MaxBlocks = layer.MaxBlocks
+	}
+	out.Gates = layered.MergeByName(base.Gates, layer.Gates, func(gate *Gate) string { return gate.Agent })
+	return out
+}
+
+// Compile builds an engine, compiling every pattern once
+func Compile(cfg *Config) (*Engine, error) {
+	e := &Engine{gates: map[string]*compiledGate{}}
+	for _, gate := range cfg.Gates {
+		if gate.Disabled {
+			continue
+		}
+		c := &compiledGate{Gate: gate, maxBlocks: gate.MaxBlocks}
+		if c.maxBlocks == 0 {
+			c.maxBlocks = max(cfg.MaxBlocks, 1)
+		}
+		if gate.Sections != nil {
+			for _, name := range gate.Sections.Names {
+				c.sections = append(c.sections, sectionPattern(name, gate.Sections.Headings))
+			}
+		}
+		for _, p := range gate.Patterns {
+			re, err := regexp.Compile("(?i)" + p.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("gate %s (%s): invalid pattern %q: %w", gate.Agent, gate.Source, p.Pattern, err)
+			}
+			c.patterns = append(c.patterns, re)
+		}
+		e.gates[gate.Agent] = c
+	}
+	return e, nil
+}
+
+// sectionPattern matches a section name as whole words, or as a markdown heading
+func sectionPattern(name string, heading bool) *regexp.Regexp {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	pattern := `\b` + strings.Join(words, `\s+`) + `\b`
+	if heading {
+		pattern = `(?m)^\s*#{1,6}\s*(?:\d+[.)]?\s*)?` + pattern
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+// Check runs the gate of agent (a subagent type such as kratos:ares) against
+// work, nil if the agent has no gate
+func (e *Engine) Check(agent string, work Work) *Result {
+	if i := strings.LastIndex(agent, ":"); i >= 0 {
+		agent = agent[i+1:]
+	}
+	gate, ok := e.gates[strings.ToLower(agent)]
+	if !ok {
+		return nil
+	}
+
+	result := &Result{Agent: gate.Agent, Failures: []string{}, Hint: gate.Hint, MaxBlocks: gate.maxBlocks, Source: gate.Source}
+	fail := func(msg string) { result.Failures = append(result.Failures, msg) }
+
+	if n := len([]rune(strings.TrimSpace(work.Message))); n < gate.MinLength {
+		fail(fmt.Sprintf("the final report is too short (%d of at least %d characters)", n, gate.MinLength))
+	}
+
+	if gate.Sections != nil && len(gate.sections) > 0 {
+		var found []string
+		for i, re := range gate.sections {
+			if re.MatchString(work.Message) {
+				found = append(found, gate.Sections.Names[i])
+			}
+		}
+		need := gate.Sections.Min
+		if need <= 0 || need > len(gate.sections) {
+			need = len(gate.sections)
+		}
+		if len(found) < need {
+			msg := gate.Sections.Message
+			if msg == "" {
+				msg = fmt.Sprintf("only %d of %d required sections were covered", len(found), need)
+			}
+			if len(found) == 0 {
+				found = []string{"none"}
+			}
+			fail(fmt.Sprintf("%s (found sections: %s)", msg, strings.Join(found, ", ")))
+		}
+	}
+
+	for i, re := range gate.patterns {
+		if re.MatchString(work.Message) == gate.Patterns[i].Absent {
+			fail(gate.Patterns[i].Message)
+		}
+	}
+
+	for _, file := range gate.Files {
+		if msg := checkFile(file, work); msg != "" {
+			fail(msg)
+		}
+	}
+
+	return result
+}
+
+// checkFile returns why a file check failed, empty if it passed or was skipped
+func checkFile(check *FileCheck, work Work) string {
+	path := check.Path
+	for placeholder, value := range map[string]string{"{feature}": work.FeatureDir, "{document}": work.Document} {
+		if !strings.Contains(path, placeholder) {
+			continue
+		}
+		if value == "" {
+			return ""
+		}
+		path = strings.ReplaceAll(path, placeholder, value)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(work.Root, path)
+	}
+
+	describe := func(fallback string) string {
+		if check.Message != "" {
+			return check.Message
+		}
+		return fallback
+	}
+
+	matches, _ := filepath.Glob(path)
+	if len(matches) == 0 {
+		return describe(fmt.Sprintf("%s was not written", display(path, work.Root)))
+	}
+	minLength := max(check.MinLength, 1)
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() && info.Size() >= int64(minLength) {
+			return ""
+		}
+	}
+	return describe(fmt.Sprintf("%s is shorter than %d bytes", display(matches[0], work.Root), minLength))
+}
+
+// display shows path relative to root when it is under it
+func display(path, root string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
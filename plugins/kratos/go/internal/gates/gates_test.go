@@ -0,0 +1,139 @@
+package gates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultGates(t *testing.T) {
+	engine, err := Compile(Default())
+	require.NoError(t, err)
+
+	// Only Ares and Hephaestus are gated out of the box; other agents opt in per project
+	assert.Len(t, engine.gates, 2)
+	assert.Nil(t, engine.Check("kratos:athena", Work{}))
+	assert.Nil(t, engine.Check("general-purpose", Work{}))
+
+	tests := []struct {
+		name     string
+		agent    string
+		message  string
+		failures []string
+	}{
+		{"ares passes", "kratos:ares", "TODO:\n1. [x] Implement auth\ncreated auth.ts\nImplementation complete.", nil},
+		{"ares without todo list", "kratos:ares", "created auth.ts. Implementation complete.", []string{"no TODO list was written before starting work"}},
+		{"ares without files", "Ares", "TODO:\n1. [x] Done\nImplementation complete.", []string{"no specific files were mentioned as created or modified"}},
+		{"ares incomplete is not complete", "kratos:ares", "## Plan\nupdated auth.go, still incomplete", []string{"implementation completion was not confirmed"}},
+		{"hephaestus passes", "kratos:hephaestus", "## Architecture\n...\n## API\n...", nil},
+		{"hephaestus too few sections", "kratos:hephaestus", "This is a brief spec about the api.",
+			[]string{"technical spec appears incomplete (found sections: api)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := engine.Check(tt.agent, Work{Message: tt.message, Root: t.TempDir()})
+			require.NotNil(t, result)
+			if tt.failures == nil {
+				assert.True(t, result.Passed(), result.Failures)
+			} else {
+				assert.Equal(t, tt.failures, result.Failures)
+			}
+		})
+	}
+
+	result := engine.Check("kratos:ares", Work{Message: "nothing"})
+	assert.True(t, strings.HasPrefix(result.Reason(), "Ares quality gate failed: no TODO list was written before starting work; "))
+	assert.True(t, strings.HasSuffix(result.Reason(), ". Write a TODO list, implement all items, and confirm which files were created."))
+	assert.Equal(t, 1, result.MaxBlocks)
+}
+
+func TestCheckFiles(t *testing.T) {
+	root := t.TempDir()
+	featureDir := filepath.Join(root, ".claude", "feature", "auth")
+	require.NoError(t, os.MkdirAll(featureDir, 0755))
+
+	engine, err := Compile(&Config{Gates: []*Gate{{
+		Agent: "artemis",
+		Files: []*FileCheck{
+			{Path: "{document}", MinLength: 10},
+			{Path: "{feature}/notes-*.md", Message: "no notes were written"},
+		},
+		Sections: &SectionCheck{Names: []string{"test cases", "edge cases"}, Headings: true},
+	}}})
+	require.NoError(t, err)
+
+	work := Work{
+		Message:    "Covers test cases and edge cases",
+		Root:       root,
+		FeatureDir: featureDir,
+		Document:   filepath.Join(featureDir, "test-plan.md"),
+	}
+	assert.Equal(t, []string{
+		"only 0 of 2 required sections were covered (found sections: none)",
+		".claude/feature/auth/test-plan.md was not written",
+		"no notes were written",
+	}, engine.Check("artemis", work).Failures)
+
+	require.NoError(t, os.WriteFile(work.Document, []byte("short"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "notes-1.md"), []byte("n"), 0644))
+	work.Message = "## Test Cases\n...\n### 2. Edge cases\n..."
+	assert.Equal(t, []string{".claude/feature/auth/test-plan.md is shorter than 10 bytes"}, engine.Check("artemis", work).Failures)
+
+	// Checks naming a feature or document are skipped without one
+	assert.True(t, engine.Check("artemis", Work{Message: work.Message, Root: root}).Passed())
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "gates.yaml")
+	require.NoError(t, os.WriteFile(project, []byte(`
+max_blocks: 3
+gates:
+  - agent: Hera
+    patterns:
+      - pattern: "\\bverdict:\\s*aligned\\b"
+        message: no aligned verdict
+      - pattern: "\\bTBD\\b"
+        absent: true
+        message: open questions remain
+    max_blocks: 2
+  - agent: clio
+    disabled: true
+  - agent: scribe
+    min_length: 20
+`), 0644))
+
+	engine, err := Load(filepath.Join(dir, "missing.json"), project)
+	require.NoError(t, err)
+
+	result := engine.Check("kratos:hera", Work{Message: "Verdict: aligned, TBD on docs"})
+	assert.Equal(t, []string{"open questions remain"}, result.Failures)
+	assert.Equal(t, 2, result.MaxBlocks)
+	assert.Equal(t, project, result.Source)
+
+	assert.Nil(t, engine.Check("clio", Work{}))
+	result = engine.Check("scribe", Work{Message: "hi"})
+	assert.Equal(t, []string{"the final report is too short (2 of at least 20 characters)"}, result.Failures)
+	assert.Equal(t, 3, result.MaxBlocks)
+	assert.Equal(t, 3, engine.Check("ares", Work{}).MaxBlocks)
+
+	cfg, err := LoadConfig(project)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Gates, len(Default().Gates)+3)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gates.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"gates": [{"agent": "ares", "patterns": [{"pattern": "("}]}]}`), 0644))
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "gate ares")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"gates": [{"min_length": 5}]}`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "gate without an agent")
+}
@@ -0,0 +1,80 @@
+// Package layered loads configuration layers from JSON or YAML files, later
+// layers overriding earlier ones
+package layered
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectPaths returns the files named name a repository may hold, in load order
+func ProjectPaths(root, name string) []string {
+	dir := filepath.Join(root, ".claude", "kratos")
+	return []string{filepath.Join(dir, name+".json"), filepath.Join(dir, name+".yaml")}
+}
+
+// Decode reads a kind layer into v, as YAML for .yaml/.yml paths and JSON otherwise
+func Decode(data []byte, path, kind string, v interface{}) error {
+	var err error
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, v)
+	default:
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse %s %s: %w", kind, path, err)
+	}
+	return nil
+}
+
+// Load applies the kind layers at paths on top of base, in order
+// Missing files are skipped; parse reads a layer and merge applies it
+func Load[C any](base C, paths []string, kind string, parse func([]byte, string) (C, error), merge func(base, layer C) C) (C, error) {
+	merged := base
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			var zero C
+			return zero, fmt.Errorf("failed to read %s %s: %w", kind, path, err)
+		}
+		layer, err := parse(data, path)
+		if err != nil {
+			var zero C
+			return zero, err
+		}
+		merged = merge(merged, layer)
+	}
+	return merged, nil
+}
+
+// MergeByName overlays layer on base: an entry replaces the base entry of the
+// same name in place, and new entries follow in their order in the layer
+func MergeByName[T any](base, layer []T, name func(T) string) []T {
+	overrides := map[string]T{}
+	for _, entry := range layer {
+		overrides[name(entry)] = entry
+	}
+	var out []T
+	for _, entry := range base {
+		if override, ok := overrides[name(entry)]; ok {
+			delete(overrides, name(entry))
+			entry = override
+		}
+		out = append(out, entry)
+	}
+	for _, entry := range layer {
+		if _, ok := overrides[name(entry)]; ok {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package layered
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	Name  string `json:"name" yaml:"name"`
+	Value int    `json:"value" yaml:"value"`
+}
+
+type layer struct {
+	Entries []entry `json:"entries" yaml:"entries"`
+}
+
+// parse reads a test layer
+func parse(data []byte, path string) (layer, error) {
+	var l layer
+	err := Decode(data, path, "test", &l)
+	return l, err
+}
+
+// mergeLayers applies a test layer on top of base
+func mergeLayers(base, l layer) layer {
+	return layer{Entries: MergeByName(base.Entries, l.Entries, func(e entry) string { return e.Name })}
+}
+
+// TestMergeByName tests that overrides keep their place and new entries follow
+func TestMergeByName(t *testing.T) {
+	base := []entry{{"a", 1}, {"b", 1}, {"c", 1}}
+	got := MergeByName(base, []entry{{"d", 2}, {"b", 2}, {"e", 2}}, func(e entry) string { return e.Name })
+	assert.Equal(t, []entry{{"a", 1}, {"b", 2}, {"c", 1}, {"d", 2}, {"e", 2}}, got)
+	assert.Equal(t, entry{"b", 1}, base[1], "base is left untouched")
+}
+
+// TestLoad tests that JSON and YAML layers apply in order and missing files are skipped
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	paths := ProjectPaths(dir, "test")
+	assert.Equal(t, filepath.Join(dir, ".claude", "kratos", "test.json"), paths[0])
+	require.NoError(t, os.MkdirAll(filepath.Dir(paths[0]), 0o755))
+	require.NoError(t, os.WriteFile(paths[0], []byte(`{"entries": [{"name": "a", "value": 2}]}`), 0o644))
+	require.NoError(t, os.WriteFile(paths[1], []byte("entries:\n  - name: b\n    value: 3\n"), 0o644))
+
+	missing := filepath.Join(dir, "missing.json")
+	got, err := Load(layer{Entries: []entry{{"a", 1}}}, append([]string{missing}, paths...), "test", parse, mergeLayers)
+	require.NoError(t, err)
+	assert.Equal(t, []entry{{"a", 2}, {"b", 3}}, got.Entries)
+}
+
+// TestLoad_InvalidLayer tests that a malformed layer names its kind and path
+func TestLoad_InvalidLayer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("entries: ["), 0o644))
+
+	_, err := Load(layer{}, []string{path}, "test", parse, mergeLayers)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "failed to parse test "+path))
+}
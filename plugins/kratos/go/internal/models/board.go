@@ -0,0 +1,29 @@
+package models
+
+// BoardEntry summarizes one feature pipeline for the 'kratos board' dashboard
+type BoardEntry struct {
+	Feature        string       `json:"feature"`
+	Description    string       `json:"description,omitempty"`
+	Priority       string       `json:"priority,omitempty"`
+	CurrentStage   string       `json:"current_stage"`
+	PipelineStatus string       `json:"pipeline_status"`
+	Percent        int          `json:"percent"`      // completed share of non-skipped stages
+	StagesDone     int          `json:"stages_done"`  // completed stages
+	StagesTotal    int          `json:"stages_total"` // stages that were not skipped
+	Stages         []BoardStage `json:"stages"`
+	Health         string       `json:"health"`   // healthy, warning, critical, complete
+	Blocked        bool         `json:"blocked"`  // a gate or verdict stops the pipeline
+	Conflict       bool         `json:"conflict"` // documents are stale, missing or orphaned
+	Stale          bool         `json:"stale"`    // no activity within the stale window
+	Issues         []string     `json:"issues"`
+	NextAction     string       `json:"next_action"`
+	NextStage      string       `json:"next_stage,omitempty"`
+	NextAgent      string       `json:"next_agent,omitempty"`
+	LastActivity   int64        `json:"last_activity"` // Unix epoch ms
+}
+
+// BoardStage is one stage of a board entry's pipeline
+type BoardStage struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
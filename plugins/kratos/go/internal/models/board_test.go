@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBoardEntry_JSONMarshaling tests marshaling BoardEntry to JSON
+func TestBoardEntry_JSONMarshaling(t *testing.T) {
+	entry := BoardEntry{
+		Feature:      "auth",
+		CurrentStage: "5-tech-spec",
+		Percent:      40,
+		Stages:       []BoardStage{{ID: "1-prd", Status: "complete"}},
+		Health:       "warning",
+		Conflict:     true,
+		Issues:       []string{"prd.md changed since 5-tech-spec completed"},
+		NextAction:   "continue",
+		LastActivity: 1707738000000,
+	}
+
+	jsonBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(jsonBytes, &result))
+
+	assert.Equal(t, "auth", result["feature"])
+	assert.Equal(t, float64(40), result["percent"])
+	assert.Equal(t, true, result["conflict"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "1-prd", "status": "complete"}}, result["stages"])
+	assert.NotContains(t, result, "description")
+	assert.NotContains(t, result, "next_stage")
+}
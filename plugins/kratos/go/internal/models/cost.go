@@ -0,0 +1,25 @@
+package models
+
+// CostReport is the token spend report of 'kratos stats cost'
+// Since/Until are Unix epoch ms (0 = unbounded); costs are in USD
+type CostReport struct {
+	Since           int64       `json:"since,omitempty"`
+	Until           int64       `json:"until,omitempty"`
+	Project         string      `json:"project,omitempty"`
+	Total           *CostLine   `json:"total"`
+	ByFeature       []*CostLine `json:"by_feature"`
+	ByAgent         []*CostLine `json:"by_agent"`
+	ByModel         []*CostLine `json:"by_model"`
+	ByDay           []*CostLine `json:"by_day"`
+	UnmeteredSpawns int         `json:"unmetered_spawns"`          // agent spawns recorded without token usage
+	UnpricedModels  []string    `json:"unpriced_models,omitempty"` // models missing from the pricing table
+}
+
+// CostLine is the usage and spend of one feature, agent, model or day
+type CostLine struct {
+	Name   string `json:"name"`
+	Spawns int    `json:"spawns"`
+	TokenUsage
+	CostUSD  float64 `json:"cost_usd"`
+	Unpriced int     `json:"unpriced,omitempty"` // spawns not included in CostUSD
+}
@@ -0,0 +1,17 @@
+package models
+
+// Decision represents an important choice made during a Kratos session
+type Decision struct {
+	ID           int64    `json:"id"`
+	SessionID    string   `json:"session_id"`
+	StepID       *int64   `json:"step_id,omitempty"`
+	FeatureName  *string  `json:"feature_name,omitempty"`
+	AgentName    *string  `json:"agent_name,omitempty"`
+	Timestamp    int64    `json:"timestamp"`     // Unix epoch ms
+	DecisionType string   `json:"decision_type"` // architecture, implementation, trade_off, direction
+	Question     string   `json:"question"`
+	Choice       string   `json:"choice"`
+	Alternatives []string `json:"alternatives,omitempty"` // stored as a JSON array
+	Rationale    *string  `json:"rationale,omitempty"`
+	Impact       *string  `json:"impact,omitempty"`
+}
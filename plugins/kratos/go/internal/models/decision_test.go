@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecision_JSONMarshaling tests marshaling Decision to JSON
+func TestDecision_JSONMarshaling(t *testing.T) {
+	feature := "auth-system"
+	rationale := "Fewer moving parts"
+
+	decision := Decision{
+		ID:           1,
+		SessionID:    "sess-123",
+		FeatureName:  &feature,
+		Timestamp:    1707738000000,
+		DecisionType: "architecture",
+		Question:     "Session storage",
+		Choice:       "Postgres",
+		Alternatives: []string{"Redis", "JWT only"},
+		Rationale:    &rationale,
+	}
+
+	jsonBytes, err := json.Marshal(decision)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(jsonBytes, &result))
+
+	assert.Equal(t, "architecture", result["decision_type"])
+	assert.Equal(t, "Postgres", result["choice"])
+	assert.Equal(t, []interface{}{"Redis", "JWT only"}, result["alternatives"])
+	assert.Equal(t, "Fewer moving parts", result["rationale"])
+
+	// Omitted optional fields
+	_, hasImpact := result["impact"]
+	assert.False(t, hasImpact)
+	_, hasStep := result["step_id"]
+	assert.False(t, hasStep)
+}
+
+// TestDecision_JSONRoundTrip tests unmarshaling a marshaled Decision
+func TestDecision_JSONRoundTrip(t *testing.T) {
+	agent := "themis"
+	original := Decision{
+		SessionID:    "sess-1",
+		AgentName:    &agent,
+		DecisionType: "implementation",
+		Question:     "Retry policy",
+		Choice:       "Exponential backoff",
+	}
+
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var decoded Decision
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, original, decoded)
+}
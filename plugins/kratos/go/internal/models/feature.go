@@ -0,0 +1,23 @@
+package models
+
+// Feature mirrors a feature pipeline's status.json so features can be queried across projects
+type Feature struct {
+	ID                      int64         `json:"id"`
+	FeatureName             string        `json:"feature_name"`
+	Project                 string        `json:"project"`
+	CreatedAt               int64         `json:"created_at"` // Unix epoch ms
+	UpdatedAt               int64         `json:"updated_at"` // Unix epoch ms
+	CurrentStage            int           `json:"current_stage"`
+	CurrentStageName        *string       `json:"current_stage_name,omitempty"` // e.g. 5-tech-spec
+	Status                  string        `json:"status"`                       // in_progress, completed, abandoned
+	Priority                *string       `json:"priority,omitempty"`
+	StatusPath              *string       `json:"status_path,omitempty"`
+	StagesCompleted         map[int]int64 `json:"stages_completed"` // stage number -> Unix epoch ms
+	PRDPath                 *string       `json:"prd_path,omitempty"`
+	TechSpecPath            *string       `json:"tech_spec_path,omitempty"`
+	TestPlanPath            *string       `json:"test_plan_path,omitempty"`
+	ImplementationNotesPath *string       `json:"implementation_notes_path,omitempty"`
+	Description             *string       `json:"description,omitempty"`
+	TotalSessions           int64         `json:"total_sessions"`
+	TotalAgentsUsed         int64         `json:"total_agents_used"`
+}
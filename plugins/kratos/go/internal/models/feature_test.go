@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeature_JSONMarshaling tests marshaling Feature to JSON
+func TestFeature_JSONMarshaling(t *testing.T) {
+	stage := "5-tech-spec"
+	prd := "/repo/.claude/feature/auth/prd.md"
+
+	feature := Feature{
+		ID:               1,
+		FeatureName:      "auth",
+		Project:          "repo",
+		CurrentStage:     5,
+		CurrentStageName: &stage,
+		Status:           "in_progress",
+		StagesCompleted:  map[int]int64{1: 1707738000000, 2: 1707739000000},
+		PRDPath:          &prd,
+	}
+
+	jsonBytes, err := json.Marshal(feature)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(jsonBytes, &result))
+
+	assert.Equal(t, "auth", result["feature_name"])
+	assert.Equal(t, float64(5), result["current_stage"])
+	assert.Equal(t, "5-tech-spec", result["current_stage_name"])
+	assert.Equal(t, prd, result["prd_path"])
+	assert.Equal(t, map[string]interface{}{
+		"1": float64(1707738000000),
+		"2": float64(1707739000000),
+	}, result["stages_completed"])
+
+	// Omitted optional fields
+	_, hasSpec := result["tech_spec_path"]
+	assert.False(t, hasSpec)
+}
+
+// TestFeature_JSONRoundTrip tests unmarshaling a marshaled Feature
+func TestFeature_JSONRoundTrip(t *testing.T) {
+	priority := "P1"
+	original := Feature{
+		FeatureName:     "search",
+		Project:         "kratos",
+		Status:          "completed",
+		Priority:        &priority,
+		StagesCompleted: map[int]int64{11: 1707738000000},
+	}
+
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var decoded Feature
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, original, decoded)
+}
@@ -0,0 +1,27 @@
+package models
+
+// FileChange is a structured journal entry for a single file modification
+type FileChange struct {
+	ID           int64   `json:"id"`
+	SessionID    string  `json:"session_id"`
+	StepID       *int64  `json:"step_id,omitempty"`
+	Timestamp    int64   `json:"timestamp"` // Unix epoch ms
+	FilePath     string  `json:"file_path"`
+	ChangeType   string  `json:"change_type"` // created, modified, deleted, renamed
+	OldPath      *string `json:"old_path,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	LinesAdded   *int64  `json:"lines_added,omitempty"`
+	LinesRemoved *int64  `json:"lines_removed,omitempty"`
+	AgentName    *string `json:"agent_name,omitempty"`
+	FeatureName  *string `json:"feature_name,omitempty"`
+}
+
+// HotFile aggregates the changes made to one file
+type HotFile struct {
+	FilePath     string `json:"file_path"`
+	Changes      int64  `json:"changes"`
+	Sessions     int64  `json:"sessions"`
+	LinesAdded   int64  `json:"lines_added"`
+	LinesRemoved int64  `json:"lines_removed"`
+	LastChanged  int64  `json:"last_changed"` // Unix epoch ms
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileChange_JSONMarshaling tests marshaling FileChange to JSON
+func TestFileChange_JSONMarshaling(t *testing.T) {
+	agent := "ares"
+	added := int64(7)
+
+	change := FileChange{
+		ID:         1,
+		SessionID:  "sess-123",
+		Timestamp:  1707738000000,
+		FilePath:   "src/main.go",
+		ChangeType: "modified",
+		AgentName:  &agent,
+		LinesAdded: &added,
+	}
+
+	jsonBytes, err := json.Marshal(change)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(jsonBytes, &result))
+
+	assert.Equal(t, "src/main.go", result["file_path"])
+	assert.Equal(t, "modified", result["change_type"])
+	assert.Equal(t, "ares", result["agent_name"])
+	assert.Equal(t, float64(7), result["lines_added"])
+
+	// Unknown counts are omitted rather than reported as zero
+	_, hasRemoved := result["lines_removed"]
+	assert.False(t, hasRemoved)
+}
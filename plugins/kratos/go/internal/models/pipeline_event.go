@@ -0,0 +1,30 @@
+package models
+
+// PipelineEvent mirrors one entry of a feature's events.jsonl
+type PipelineEvent struct {
+	ID          int64   `json:"id"`
+	Project     string  `json:"project"`
+	FeatureName string  `json:"feature_name"`
+	Seq         int64   `json:"seq"`
+	Revision    int64   `json:"revision"`
+	Timestamp   int64   `json:"timestamp"`  // Unix epoch ms
+	EventType   string  `json:"event_type"` // created, started, completed, gate-forced, ...
+	Stage       *string `json:"stage,omitempty"`
+	FromStatus  *string `json:"from_status,omitempty"`
+	ToStatus    *string `json:"to_status,omitempty"`
+	Verdict     *string `json:"verdict,omitempty"`
+	AgentName   *string `json:"agent_name,omitempty"`
+	SessionID   *string `json:"session_id,omitempty"`
+	Reason      *string `json:"reason,omitempty"`
+}
+
+// StageWork records who completed a pipeline stage: the session, the agent and its model
+type StageWork struct {
+	Stage       string  `json:"stage"`
+	SessionID   *string `json:"session_id,omitempty"`
+	AgentName   *string `json:"agent_name,omitempty"`
+	AgentModel  *string `json:"agent_model,omitempty"`
+	Verdict     *string `json:"verdict,omitempty"`
+	CompletedAt int64   `json:"completed_at"` // Unix epoch ms
+	Summary     string  `json:"summary"`      // e.g. "stage 7-spec-review-sa was done in session abc by apollo/opus"
+}
@@ -0,0 +1,16 @@
+package models
+
+// SearchResult is a single ranked hit from a full-text search
+type SearchResult struct {
+	Source      string  `json:"source"` // step, decision, session
+	ID          int64   `json:"id"`     // row ID within the source table
+	SessionID   string  `json:"session_id"`
+	Project     string  `json:"project"`
+	FeatureName *string `json:"feature_name,omitempty"`
+	AgentName   *string `json:"agent_name,omitempty"`
+	StepType    *string `json:"step_type,omitempty"`
+	Timestamp   int64   `json:"timestamp"` // Unix epoch ms
+	Title       string  `json:"title"`     // step action, decision question, or session feature/project
+	Snippet     string  `json:"snippet"`   // best-matching fragment with highlighted terms
+	Score       float64 `json:"score"`     // bm25 score normalized within its source, 0 (best) to 1; lower is more relevant
+}
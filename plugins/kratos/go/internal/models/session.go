@@ -21,3 +21,28 @@ type SessionContext struct {
 	TotalSteps  int64    `json:"total_steps"`
 	TotalAgents int64    `json:"total_agents"`
 }
+
+// SessionActivity is what a session did, the basis of its end-of-session summary
+type SessionActivity struct {
+	SessionID       string           `json:"session_id"`
+	Feature         string           `json:"feature,omitempty"` // session feature, else the feature of its last completed stage
+	DurationMs      int64            `json:"duration_ms"`
+	Steps           int64            `json:"steps"`
+	Agents          []*AgentActivity `json:"agents"`
+	StagesCompleted []string         `json:"stages_completed"` // "feature/stage" for stages of another feature
+	FilesChanged    []string         `json:"files_changed"`    // most changed first
+	Decisions       int              `json:"decisions"`
+	TodosClosed     int              `json:"todos_closed"` // project todos closed while the session ran
+}
+
+// AgentActivity counts the spawns of one agent and model in a session
+type AgentActivity struct {
+	Agent  string `json:"agent"`
+	Model  string `json:"model,omitempty"`
+	Spawns int    `json:"spawns"`
+}
+
+// Idle reports whether the session did no recorded work
+func (a *SessionActivity) Idle() bool {
+	return a.Steps == 0 && len(a.StagesCompleted) == 0 && a.Decisions == 0 && a.TodosClosed == 0
+}
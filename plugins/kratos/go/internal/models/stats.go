@@ -0,0 +1,61 @@
+package models
+
+// Stats is the time and effort report of 'kratos stats'
+// Durations are in milliseconds; Since/Until are Unix epoch ms (0 = unbounded)
+type Stats struct {
+	Since   int64         `json:"since,omitempty"`
+	Until   int64         `json:"until,omitempty"`
+	GroupBy string        `json:"group_by"` // project or feature
+	Groups  []*StatsGroup `json:"groups"`
+}
+
+// StatsGroup aggregates the pipelines of one project, or of one feature
+type StatsGroup struct {
+	Project    string          `json:"project"`
+	Feature    string          `json:"feature,omitempty"`
+	Stages     []*StageStats   `json:"stages"`
+	Agents     []*AgentStats   `json:"agents"`
+	Features   []*FeatureStats `json:"features"`
+	Throughput *Throughput     `json:"throughput"`
+}
+
+// StageStats is the cycle time of one stage: from its start to its completion
+// Each completion counts once, so a stage reworked after a revision counts twice
+type StageStats struct {
+	Stage              string `json:"stage"`
+	Completions        int    `json:"completions"`
+	TotalMs            int64  `json:"total_ms"`
+	AvgMs              int64  `json:"avg_ms"`
+	MedianMs           int64  `json:"median_ms"`
+	MaxMs              int64  `json:"max_ms"`
+	RevisionsRequested int    `json:"revisions_requested"` // times this review sent work back
+}
+
+// AgentStats is the stage time of one agent and model
+type AgentStats struct {
+	Agent   string `json:"agent"`
+	Model   string `json:"model,omitempty"` // empty when no spawn step recorded it
+	Spawns  int    `json:"spawns"`
+	Stages  int    `json:"stages"` // stage completions credited to the agent
+	TotalMs int64  `json:"total_ms"`
+}
+
+// FeatureStats is the effort spent on one feature
+type FeatureStats struct {
+	Feature         string `json:"feature"`
+	Status          string `json:"status,omitempty"`
+	Sessions        int    `json:"sessions"`
+	StagesCompleted int    `json:"stages_completed"`
+	Revisions       int    `json:"revisions"`
+	ActiveMs        int64  `json:"active_ms"`              // sum of stage cycle times
+	LeadTimeMs      int64  `json:"lead_time_ms,omitempty"` // creation to completion, completed features only
+}
+
+// Throughput counts the work finished within the window
+type Throughput struct {
+	Days              float64 `json:"days"`
+	StagesCompleted   int     `json:"stages_completed"`
+	FeaturesCompleted int     `json:"features_completed"`
+	StagesPerWeek     float64 `json:"stages_per_week"`
+	FeaturesPerWeek   float64 `json:"features_per_week"`
+}
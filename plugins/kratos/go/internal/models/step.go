@@ -2,16 +2,35 @@ package models
 
 // Step represents a single action within a Kratos session
 type Step struct {
-	ID            int64   `json:"id"`
-	SessionID     string  `json:"session_id"`
-	StepNumber    int64   `json:"step_number"`
-	StepType      string  `json:"step_type"` // agent_spawn, file_modify, decision, command
-	Timestamp     int64   `json:"timestamp"` // Unix epoch ms
-	AgentName     *string `json:"agent_name,omitempty"`
-	AgentModel    *string `json:"agent_model,omitempty"`
-	PipelineStage *int64  `json:"pipeline_stage,omitempty"`
-	Action        string  `json:"action"`
-	Target        *string `json:"target,omitempty"`
-	Result        *string `json:"result,omitempty"`
-	Context       *string `json:"context,omitempty"`
+	ID            int64       `json:"id"`
+	SessionID     string      `json:"session_id"`
+	StepNumber    int64       `json:"step_number"`
+	StepType      string      `json:"step_type"` // agent_spawn, file_modify, decision, command
+	Timestamp     int64       `json:"timestamp"` // Unix epoch ms
+	AgentName     *string     `json:"agent_name,omitempty"`
+	AgentModel    *string     `json:"agent_model,omitempty"`
+	PipelineStage *int64      `json:"pipeline_stage,omitempty"`
+	Action        string      `json:"action"`
+	Target        *string     `json:"target,omitempty"`
+	Result        *string     `json:"result,omitempty"`
+	Context       *string     `json:"context,omitempty"`
+	Usage         *TokenUsage `json:"usage,omitempty"` // agent spawns only, when the Task result reported it
+}
+
+// TokenUsage is the token consumption and wall time of one agent run
+type TokenUsage struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_tokens"`
+	DurationMs          int64 `json:"duration_ms"`
+}
+
+// Add accumulates another run's usage
+func (u *TokenUsage) Add(other *TokenUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.DurationMs += other.DurationMs
 }
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// Board health values, worst first
+const (
+	HealthCritical = "critical" // blocked by a gate, verdict or the user
+	HealthWarning  = "warning"  // document conflicts or no recent activity
+	HealthHealthy  = "healthy"
+	HealthComplete = "complete"
+)
+
+// Summarize builds the dashboard entry of a feature
+// dir is the feature folder; a feature with no activity for staleAfter
+// before now is flagged stale
+func Summarize(s *Status, dir string, staleAfter time.Duration, now time.Time) *models.BoardEntry {
+	entry := &models.BoardEntry{
+		Feature:        s.Feature,
+		Description:    s.Description,
+		Priority:       s.Priority,
+		CurrentStage:   s.CurrentStage,
+		PipelineStatus: s.PipelineStatus,
+		Stages:         []models.BoardStage{},
+		Issues:         []string{},
+	}
+	if entry.Feature == "" {
+		entry.Feature = filepath.Base(dir)
+	}
+
+	for _, id := range s.StageIDs() {
+		stage := s.Stages[id]
+		entry.Stages = append(entry.Stages, models.BoardStage{ID: id, Status: stage.Status})
+		if stage.Status == StatusSkipped {
+			continue
+		}
+		entry.StagesTotal++
+		if stage.Status == StatusComplete {
+			entry.StagesDone++
+		}
+	}
+	if entry.StagesTotal > 0 {
+		entry.Percent = entry.StagesDone * 100 / entry.StagesTotal
+	}
+
+	next := Next(s)
+	entry.NextAction = next.Action
+	entry.NextStage = next.Stage
+	entry.NextAgent = next.Agent
+	if next.Action == ActionBlocked || s.PipelineStatus == StatusBlocked {
+		entry.Blocked = true
+		entry.Issues = append(entry.Issues, next.Reason)
+		entry.Issues = append(entry.Issues, next.Unmet...)
+	}
+
+	for _, issue := range Check(s, dir).Issues {
+		entry.Conflict = true
+		entry.Issues = append(entry.Issues, issue.Reason)
+	}
+
+	entry.LastActivity = lastActivity(s, dir)
+	finished := next.Action == ActionDone
+	if !finished && entry.LastActivity > 0 && now.Sub(time.UnixMilli(entry.LastActivity)) > staleAfter {
+		entry.Stale = true
+	}
+
+	switch {
+	case entry.Blocked:
+		entry.Health = HealthCritical
+	case entry.Conflict || entry.Stale:
+		entry.Health = HealthWarning
+	case finished:
+		entry.Health = HealthComplete
+	default:
+		entry.Health = HealthHealthy
+	}
+
+	return entry
+}
+
+// lastActivity returns the latest of the updated time and history timestamps as
+// Unix epoch ms, falling back to the status.json modification time
+func lastActivity(s *Status, dir string) int64 {
+	var latest time.Time
+	consider := func(ts string) {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+
+	consider(s.Updated)
+	for _, entry := range s.History {
+		consider(entry.Timestamp)
+	}
+	if latest.IsZero() {
+		info, err := os.Stat(filepath.Join(dir, "status.json"))
+		if err != nil {
+			return 0
+		}
+		latest = info.ModTime()
+	}
+	return latest.UnixMilli()
+}
@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize_Healthy(t *testing.T) {
+	s, dir := specPipeline(t)
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	entry := Summarize(s, dir, 7*24*time.Hour, now)
+	assert.Equal(t, "auth", entry.Feature)
+	assert.Equal(t, HealthHealthy, entry.Health)
+	// Skipped optional stages do not count towards progress
+	assert.Equal(t, 3, entry.StagesDone)
+	assert.Equal(t, 9, entry.StagesTotal)
+	assert.Equal(t, 33, entry.Percent)
+	assert.Len(t, entry.Stages, len(s.StageIDs()))
+	assert.Equal(t, ActionStart, entry.NextAction)
+	assert.Equal(t, "6-spec-review-pm", entry.NextStage)
+	assert.False(t, entry.Blocked || entry.Conflict || entry.Stale)
+	assert.Empty(t, entry.Issues)
+	assert.Equal(t, time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC).UnixMilli(), entry.LastActivity)
+}
+
+func TestSummarize_StaleAndConflict(t *testing.T) {
+	s, dir := specPipeline(t)
+	now := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	entry := Summarize(s, dir, 7*24*time.Hour, now)
+	assert.True(t, entry.Stale)
+	assert.False(t, entry.Conflict)
+	assert.Equal(t, HealthWarning, entry.Health)
+
+	writeDoc(t, dir, "prd.md", "# PRD v2", time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC))
+	entry = Summarize(s, dir, 7*24*time.Hour, now)
+	assert.True(t, entry.Conflict)
+	require.NotEmpty(t, entry.Issues)
+	assert.Contains(t, entry.Issues[0], "prd.md")
+}
+
+func TestSummarize_BlockedAndComplete(t *testing.T) {
+	s, dir := specPipeline(t)
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	s.PipelineStatus = StatusBlocked
+	entry := Summarize(s, dir, 7*24*time.Hour, now)
+	assert.True(t, entry.Blocked)
+	assert.Equal(t, HealthCritical, entry.Health)
+
+	s = New("done", "Finished", "P3", "2026-01-01T10:00:00Z")
+	for _, id := range s.StageIDs() {
+		if s.Stages[id].Status != StatusSkipped {
+			finish(s, "2026-01-01T11:00:00Z", map[string]string{id: ""})
+		}
+	}
+	s.Stages["2-prd-review"].Verdict = "approved"
+	s.Stages["6-spec-review-pm"].Verdict = "approved"
+	s.Stages["7-spec-review-sa"].Verdict = "sound"
+	s.Stages["10-prd-alignment"].Verdict = "aligned"
+	s.Stages["11-review"].Verdict = "approved"
+
+	// A finished feature is never stale
+	entry = Summarize(s, t.TempDir(), time.Hour, now)
+	assert.Equal(t, 100, entry.Percent)
+	assert.Equal(t, ActionDone, entry.NextAction)
+	assert.False(t, entry.Stale)
+}
@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Document issue kinds reported by Check
+const (
+	IssueStale    = "stale"    // a document changed after a stage built on it completed
+	IssueMissing  = "missing"  // a finished stage's document or task file does not exist
+	IssueOrphaned = "orphaned" // a document in the feature folder no stage references
+)
+
+// CheckIssue is one document problem found by Check
+type CheckIssue struct {
+	Kind     string `json:"kind"`
+	Stage    string `json:"stage,omitempty"`
+	Document string `json:"document"`
+	Upstream string `json:"upstream,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// CheckReport lists the document problems of one feature
+type CheckReport struct {
+	Feature string        `json:"feature"`
+	OK      bool          `json:"ok"`
+	Issues  []*CheckIssue `json:"issues"`
+}
+
+// Check compares a feature's documents with its stage completions
+// dir is the feature folder that relative document paths resolve against.
+// A finished stage is stale when an input document's hash differs from the one
+// recorded at completion or, without recorded hashes, when the input was
+// modified or its stage completed after the dependent stage completed
+func Check(s *Status, dir string) *CheckReport {
+	report := &CheckReport{Feature: s.Feature, Issues: []*CheckIssue{}}
+
+	for _, id := range s.StageIDs() {
+		stage := s.Stages[id]
+		if stage.Status != StatusComplete {
+			continue
+		}
+		report.Issues = append(report.Issues, checkMissing(id, stage, dir)...)
+		report.Issues = append(report.Issues, s.checkStale(id, stage, dir)...)
+	}
+	report.Issues = append(report.Issues, s.checkOrphaned(dir)...)
+
+	report.OK = len(report.Issues) == 0
+	return report
+}
+
+// RecordInputs stores the hashes of the input documents stage id was built on
+// Inputs that do not exist on disk are left out
+func (s *Status) RecordInputs(id, dir string) {
+	stage := s.Stages[id]
+	if stage == nil {
+		return
+	}
+	stage.InputHashes = nil
+	for _, doc := range s.Inputs(id) {
+		if hash, err := hashFile(resolve(dir, doc)); err == nil {
+			if stage.InputHashes == nil {
+				stage.InputHashes = map[string]string{}
+			}
+			stage.InputHashes[doc] = hash
+		}
+	}
+}
+
+// checkMissing reports a finished stage with none of its documents on disk,
+// and task files that do not exist
+func checkMissing(id string, stage *Stage, dir string) []*CheckIssue {
+	var issues []*CheckIssue
+
+	if len(stage.Documents) > 0 && len(stage.Tasks) == 0 {
+		found := false
+		for _, doc := range stage.Documents {
+			if exists(resolve(dir, doc)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			issues = append(issues, &CheckIssue{
+				Kind:     IssueMissing,
+				Stage:    id,
+				Document: stage.Document(),
+				Reason:   fmt.Sprintf("%s is complete but %s does not exist", id, stage.Document()),
+			})
+		}
+	}
+
+	for _, task := range stage.Tasks {
+		if task.File != "" && !exists(resolve(dir, task.File)) {
+			issues = append(issues, &CheckIssue{
+				Kind:     IssueMissing,
+				Stage:    id,
+				Document: task.File,
+				Reason:   fmt.Sprintf("task %s file %s does not exist", task.ID, task.File),
+			})
+		}
+	}
+
+	return issues
+}
+
+// checkStale reports inputs of a finished stage that changed after it completed
+func (s *Status) checkStale(id string, stage *Stage, dir string) []*CheckIssue {
+	var issues []*CheckIssue
+	for _, in := range s.definition(id).inputs {
+		upstream := s.Stages[in]
+		if upstream == nil || upstream.Status == StatusSkipped || upstream.Status == StatusPending {
+			continue
+		}
+		if upstream.Status != StatusComplete {
+			issues = append(issues, &CheckIssue{
+				Kind:     IssueStale,
+				Stage:    id,
+				Document: upstream.Document(),
+				Upstream: in,
+				Reason:   fmt.Sprintf("%s is %s but %s built on it is complete", in, upstream.Status, id),
+			})
+			continue
+		}
+
+		for _, doc := range upstream.Documents {
+			path := resolve(dir, doc)
+			if !exists(path) {
+				continue
+			}
+			if reason := staleReason(id, stage, in, upstream, doc, path); reason != "" {
+				issues = append(issues, &CheckIssue{
+					Kind:     IssueStale,
+					Stage:    id,
+					Document: doc,
+					Upstream: in,
+					Reason:   reason,
+				})
+			}
+		}
+	}
+
+	// Hephaestus records the PRD version the spec was written against
+	if id == "5-tech-spec" {
+		if prd := s.Stages["1-prd"]; prd != nil && prd.Status == StatusComplete {
+			var basedOn string
+			if raw, ok := stage.Extra["based_on_prd_version"]; ok && json.Unmarshal(raw, &basedOn) == nil && basedOn != "" && after(prd.Completed, basedOn) {
+				issues = append(issues, &CheckIssue{
+					Kind:     IssueStale,
+					Stage:    id,
+					Document: prd.Document(),
+					Upstream: "1-prd",
+					Reason:   fmt.Sprintf("tech spec is based on the PRD of %s but 1-prd completed %s", basedOn, prd.Completed),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// staleReason explains why input doc of stage id is stale, or returns ""
+func staleReason(id string, stage *Stage, in string, upstream *Stage, doc, path string) string {
+	if recorded, ok := stage.InputHashes[doc]; ok {
+		hash, err := hashFile(path)
+		if err == nil && hash != recorded {
+			return fmt.Sprintf("%s changed since %s completed", doc, id)
+		}
+		return ""
+	}
+
+	if after(upstream.Completed, stage.Completed) {
+		return fmt.Sprintf("%s completed %s, after %s completed %s", in, upstream.Completed, id, stage.Completed)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	completed, err := time.Parse(time.RFC3339, stage.Completed)
+	// status.json timestamps have second precision
+	if err == nil && info.ModTime().Truncate(time.Second).After(completed) {
+		return fmt.Sprintf("%s modified %s, after %s completed %s", doc, info.ModTime().Format(time.RFC3339), id, stage.Completed)
+	}
+	return ""
+}
+
+// checkOrphaned reports markdown files in the feature folder and its tasks
+// folder that no stage or task references
+func (s *Status) checkOrphaned(dir string) []*CheckIssue {
+	referenced := map[string]bool{}
+	for _, stage := range s.Stages {
+		for _, doc := range stage.Documents {
+			referenced[resolve(dir, doc)] = true
+		}
+		for _, task := range stage.Tasks {
+			if task.File != "" {
+				referenced[resolve(dir, task.File)] = true
+			}
+		}
+	}
+
+	var files []string
+	for _, pattern := range []string{"*.md", filepath.Join("tasks", "*.md")} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var issues []*CheckIssue
+	for _, file := range files {
+		if referenced[file] {
+			continue
+		}
+		// tasks/00-overview.md indexes the task files rather than being one
+		if strings.HasPrefix(filepath.Base(file), "00-") && filepath.Base(filepath.Dir(file)) == "tasks" {
+			continue
+		}
+		rel, _ := filepath.Rel(dir, file)
+		issues = append(issues, &CheckIssue{
+			Kind:     IssueOrphaned,
+			Document: filepath.ToSlash(rel),
+			Reason:   fmt.Sprintf("%s is not referenced by any stage", filepath.ToSlash(rel)),
+		})
+	}
+	return issues
+}
+
+// resolve returns doc as an absolute path, relative paths resolving against dir
+func resolve(dir, doc string) string {
+	if filepath.IsAbs(doc) {
+		return filepath.Clean(doc)
+	}
+	return filepath.Join(dir, doc)
+}
+
+// exists reports whether path exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// hashFile returns the hex SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeDoc writes a feature document and sets its modification time
+func writeDoc(t *testing.T, dir, name, content string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+// specPipeline returns a pipeline with the PRD, review and tech spec complete
+// and their documents written before the stages completed
+func specPipeline(t *testing.T) (*Status, string) {
+	t.Helper()
+	dir := t.TempDir()
+	day := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	s := New("auth", "Login flow", "P1", day.Format(time.RFC3339))
+	finish(s, day.Add(1*time.Hour).Format(time.RFC3339), map[string]string{"1-prd": ""})
+	finish(s, day.Add(2*time.Hour).Format(time.RFC3339), map[string]string{"2-prd-review": "approved"})
+	finish(s, day.Add(3*time.Hour).Format(time.RFC3339), map[string]string{"5-tech-spec": ""})
+
+	writeDoc(t, dir, "prd.md", "# PRD", day.Add(30*time.Minute))
+	writeDoc(t, dir, "prd-review.md", "# Review", day.Add(90*time.Minute))
+	writeDoc(t, dir, "tech-spec.md", "# Spec", day.Add(150*time.Minute))
+	return s, dir
+}
+
+func TestCheck_Clean(t *testing.T) {
+	s, dir := specPipeline(t)
+
+	report := Check(s, dir)
+	assert.True(t, report.OK)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, "auth", report.Feature)
+}
+
+func TestCheck_StaleByModTime(t *testing.T) {
+	s, dir := specPipeline(t)
+	writeDoc(t, dir, "prd.md", "# PRD v2", time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC))
+
+	report := Check(s, dir)
+	assert.False(t, report.OK)
+	require.Len(t, report.Issues, 2)
+	for i, stage := range []string{"2-prd-review", "5-tech-spec"} {
+		issue := report.Issues[i]
+		assert.Equal(t, IssueStale, issue.Kind)
+		assert.Equal(t, stage, issue.Stage)
+		assert.Equal(t, "prd.md", issue.Document)
+		assert.Equal(t, "1-prd", issue.Upstream)
+	}
+}
+
+func TestCheck_StaleByHash(t *testing.T) {
+	s, dir := specPipeline(t)
+	s.RecordInputs("5-tech-spec", dir)
+	assert.Contains(t, s.Stages["5-tech-spec"].InputHashes, "prd.md")
+
+	// Touching the file without changing it is not a conflict once hashes exist
+	later := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "prd.md"), later, later))
+	s.RecordInputs("2-prd-review", dir)
+	assert.True(t, Check(s, dir).OK)
+
+	writeDoc(t, dir, "prd.md", "# PRD v2", time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	report := Check(s, dir)
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, "prd.md changed since 5-tech-spec completed", report.Issues[1].Reason)
+}
+
+func TestCheck_StaleByCompletion(t *testing.T) {
+	s, dir := specPipeline(t)
+	s.Stages["1-prd"].Completed = "2026-01-01T14:00:00Z"
+
+	report := Check(s, dir)
+	require.Len(t, report.Issues, 2)
+	assert.Contains(t, report.Issues[1].Reason, "1-prd completed 2026-01-01T14:00:00Z, after 5-tech-spec completed")
+}
+
+func TestCheck_BasedOnPRDVersion(t *testing.T) {
+	s, dir := specPipeline(t)
+	s.Stages["5-tech-spec"].Extra = map[string]json.RawMessage{"based_on_prd_version": json.RawMessage(`"2026-01-01T10:30:00Z"`)}
+
+	report := Check(s, dir)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, IssueStale, report.Issues[0].Kind)
+	assert.Contains(t, report.Issues[0].Reason, "based on the PRD of 2026-01-01T10:30:00Z")
+}
+
+func TestCheck_ReopenedUpstream(t *testing.T) {
+	s, dir := specPipeline(t)
+	s.Stages["1-prd"].Status = StatusInProgress
+
+	report := Check(s, dir)
+	require.NotEmpty(t, report.Issues)
+	assert.Equal(t, "1-prd is in-progress but 2-prd-review built on it is complete", report.Issues[0].Reason)
+}
+
+func TestCheck_MissingAndOrphaned(t *testing.T) {
+	s, dir := specPipeline(t)
+	require.NoError(t, os.Remove(filepath.Join(dir, "tech-spec.md")))
+	writeDoc(t, dir, "notes-old.md", "scratch", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	writeDoc(t, dir, "tasks/00-overview.md", "index", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	writeDoc(t, dir, "tasks/02-extra.md", "task", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	impl := s.Stages["9-implementation"]
+	impl.Status = StatusComplete
+	impl.Tasks = []*Task{{ID: "01", File: "tasks/01-schema.md", Title: "Schema", Status: StatusComplete}}
+
+	report := Check(s, dir)
+	kinds := map[string][]string{}
+	for _, issue := range report.Issues {
+		kinds[issue.Kind] = append(kinds[issue.Kind], issue.Document)
+	}
+	assert.Equal(t, []string{"tech-spec.md", "tasks/01-schema.md"}, kinds[IssueMissing])
+	assert.Equal(t, []string{"notes-old.md", "tasks/02-extra.md"}, kinds[IssueOrphaned])
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+// stageDef describes a stage of the default feature pipeline
+type stageDef struct {
+	id        string
+	agent     string
+	documents []string
+	optional  bool
+	requires  []string
+	condition string
+	inputs    []string // stages whose documents the agent reads, when finished
+}
+
+// defaultStages is the standard 11-stage Kratos pipeline
+var defaultStages = []stageDef{
+	{"1-prd", "athena", []string{"prd.md"}, false, nil, "", nil},
+	{"2-prd-review", "athena", []string{"prd-review.md"}, false, []string{"1-prd"}, "prd.status === 'complete'", []string{"1-prd"}},
+	{"3-decomposition", "daedalus", []string{"decomposition.md"}, true, []string{"2-prd-review"}, "prd-review.verdict === 'approved' AND user opts in", []string{"1-prd"}},
+	{"4-discuss", "themis", []string{"context.md"}, true, []string{"2-prd-review"}, "prd-review.verdict === 'approved' AND user opts in", []string{"1-prd"}},
+	{"5-tech-spec", "hephaestus", []string{"tech-spec.md"}, false, []string{"2-prd-review"}, "prd-review.verdict === 'approved'", []string{"1-prd", "3-decomposition", "4-discuss"}},
+	{"6-spec-review-pm", "athena", []string{"spec-review-pm.md"}, false, []string{"5-tech-spec"}, "tech-spec.status === 'complete'", []string{"5-tech-spec", "1-prd"}},
+	{"7-spec-review-sa", "apollo", []string{"spec-review-sa.md"}, false, []string{"5-tech-spec"}, "tech-spec.status === 'complete'", []string{"5-tech-spec"}},
+	{"8-test-plan", "artemis", []string{"test-plan.md"}, false, []string{"6-spec-review-pm", "7-spec-review-sa"}, "both reviews passed", []string{"1-prd", "5-tech-spec", "3-decomposition"}},
+	{"9-implementation", "ares", []string{"implementation-notes.md"}, false, []string{"8-test-plan"}, "test-plan exists", []string{"5-tech-spec", "8-test-plan", "3-decomposition"}},
+	{"10-prd-alignment", "hera", []string{"prd-alignment.md"}, false, []string{"9-implementation"}, "implementation complete", []string{"1-prd", "8-test-plan", "9-implementation"}},
+	{"11-review", "hermes", []string{"code-review.md", "risk-analysis.md"}, false, []string{"10-prd-alignment"}, "prd-alignment verdict === 'aligned'", []string{"1-prd", "5-tech-spec", "8-test-plan", "9-implementation", "10-prd-alignment"}},
+}
+
+// defaultStage returns the default definition of a stage, if it has one
+func defaultStage(id string) (stageDef, bool) {
+	for _, def := range defaultStages {
+		if def.id == id {
+			return def, true
+		}
+	}
+	return stageDef{}, false
+}
+
+// definition returns the definition of a stage in this pipeline
+// Features created from a template only know the inputs stored in their
+// stages; the default pipeline falls back to defaultStages
+func (s *Status) definition(id string) stageDef {
+	def := stageDef{id: id}
+	if s.Template == "" {
+		def, _ = defaultStage(id)
+	}
+	if stage := s.Stages[id]; stage != nil && stage.Inputs != nil {
+		def.inputs = stage.Inputs
+	}
+	return def
+}
+
+// New returns the status of a freshly initialized feature on the default pipeline
+// The first stage starts in progress at ts; optional stages start skipped
+func New(feature, description, priority, ts string) *Status {
+	return defaultTemplate().Instantiate(feature, description, priority, ts)
+}
@@ -0,0 +1,261 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Event types that are not history actions
+const (
+	EventCreated  = "created"  // feature initialized; carries the initial snapshot
+	EventBaseline = "baseline" // first logged write of a feature created before the log existed
+	EventUpdated  = "updated"  // a write that recorded no history, such as adding a task
+)
+
+// Event is one entry of a feature's append-only event log (events.jsonl)
+// Every history entry is logged as an event of the same type; the last event
+// of each write carries the stages and top-level fields it changed, so the
+// log alone can rebuild status.json
+type Event struct {
+	Seq       int    `json:"seq"`
+	Revision  int    `json:"revision"` // status.json revision after the write
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"` // created, started, completed, gate-forced, revision-requested, ...
+	Stage     string `json:"stage,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Verdict   string `json:"verdict,omitempty"`
+	Agent     string `json:"agent,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	Snapshot *Status  `json:"snapshot,omitempty"` // created and baseline events only
+	Changes  *Changes `json:"changes,omitempty"`
+}
+
+// Changes is the state a write left behind: the stages it touched and the top-level fields
+type Changes struct {
+	Stages             map[string]*Stage `json:"stages,omitempty"`
+	CurrentStage       string            `json:"current_stage"`
+	PipelineStatus     string            `json:"pipeline_status,omitempty"`
+	Mode               string            `json:"mode,omitempty"`
+	ImplementationMode string            `json:"implementation_mode,omitempty"`
+	Updated            string            `json:"updated"`
+}
+
+// EventsPath returns the event log path of the status.json at path
+func EventsPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "events.jsonl")
+}
+
+// Create writes a new status.json at path and starts its event log
+// Fails if the feature already exists
+func Create(path string, status *Status) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create directory: %w", err)
+	}
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("status.json already exists at %s", path)
+	}
+
+	created := &Event{
+		Revision:  status.Revision,
+		Timestamp: status.Created,
+		Type:      EventCreated,
+		Stage:     status.CurrentStage,
+		Reason:    fmt.Sprintf("initialized %s", status.Feature),
+		Snapshot:  status,
+	}
+	if status.Template != "" {
+		created.Reason += " from template " + status.Template
+	}
+	if err := os.Remove(EventsPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot reset event log: %w", err)
+	}
+	if err := AppendEvents(EventsPath(path), []*Event{created}); err != nil {
+		return err
+	}
+	return Save(path, status)
+}
+
+// ReadEvents reads a feature's event log in order
+// A missing log yields no events
+func ReadEvents(path string) ([]*Event, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Event{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	events := []*Event{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		event := &Event{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			return nil, fmt.Errorf("cannot parse %s line %d: %w", path, line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// AppendEvents numbers events after the last logged one and appends them to the log
+// Callers hold the feature lock
+func AppendEvents(path string, events []*Event) error {
+	existing, err := ReadEvents(path)
+	if err != nil {
+		return err
+	}
+	seq := 0
+	if len(existing) > 0 {
+		seq = existing[len(existing)-1].Seq
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		seq++
+		event.Seq = seq
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("cannot marshal event: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot append to %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// Diff returns the events of a write that turned before into after
+// history is the number of history entries before the write
+func Diff(before, after *Status, history int) []*Event {
+	var events []*Event
+	for _, entry := range after.History[history:] {
+		events = append(events, &Event{
+			Revision:  after.Revision,
+			Timestamp: entry.Timestamp,
+			Type:      entry.Action,
+			Stage:     entry.Stage,
+			From:      entry.From,
+			To:        entry.To,
+			Verdict:   entry.Verdict,
+			Agent:     entry.Agent,
+			SessionID: entry.SessionID,
+			Reason:    entry.Notes,
+		})
+	}
+	if len(events) == 0 {
+		events = append(events, &Event{Revision: after.Revision, Timestamp: after.Updated, Type: EventUpdated})
+	}
+
+	changes := &Changes{
+		Stages:             map[string]*Stage{},
+		CurrentStage:       after.CurrentStage,
+		PipelineStatus:     after.PipelineStatus,
+		Mode:               after.Mode,
+		ImplementationMode: after.ImplementationMode,
+		Updated:            after.Updated,
+	}
+	for id, stage := range after.Stages {
+		if !sameStage(before.Stages[id], stage) {
+			changes.Stages[id] = stage
+		}
+	}
+	events[len(events)-1].Changes = changes
+	return events
+}
+
+// Replay rebuilds a status from its event log
+// The log must start with a created or baseline snapshot; every later event
+// adds its history entry and applies the changes it carries
+func Replay(events []*Event) (*Status, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events to replay")
+	}
+	if events[0].Snapshot == nil {
+		return nil, fmt.Errorf("event log does not start with a snapshot (first event is %d %s)", events[0].Seq, events[0].Type)
+	}
+
+	status, err := clone(events[0].Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events[1:] {
+		if event.Snapshot != nil {
+			return nil, fmt.Errorf("event %d: unexpected %s snapshot", event.Seq, event.Type)
+		}
+		if event.Type != EventUpdated {
+			status.History = append(status.History, &HistoryEntry{
+				Timestamp: event.Timestamp,
+				Stage:     event.Stage,
+				Action:    event.Type,
+				From:      event.From,
+				To:        event.To,
+				Agent:     event.Agent,
+				Verdict:   event.Verdict,
+				SessionID: event.SessionID,
+				Notes:     event.Reason,
+			})
+		}
+		status.Revision = event.Revision
+		if c := event.Changes; c != nil {
+			for id, stage := range c.Stages {
+				status.Stages[id] = stage
+			}
+			status.CurrentStage = c.CurrentStage
+			status.PipelineStatus = c.PipelineStatus
+			status.Mode = c.Mode
+			status.ImplementationMode = c.ImplementationMode
+			status.Updated = c.Updated
+		}
+	}
+	return status, nil
+}
+
+// clone deep-copies a status through its JSON form
+func clone(s *Status) (*Status, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal status: %w", err)
+	}
+	return Parse(data)
+}
+
+// sameStage reports whether two stages serialize identically
+func sameStage(a, b *Stage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertSameStatus compares two statuses by their JSON form
+func assertSameStatus(t *testing.T, want, got *Status) {
+	t.Helper()
+	w, err := json.Marshal(want)
+	require.NoError(t, err)
+	g, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(w), string(g))
+}
+
+func TestCreate_StartsLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth", "status.json")
+	require.NoError(t, Create(path, New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")))
+
+	events, err := ReadEvents(EventsPath(path))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].Seq)
+	assert.Equal(t, EventCreated, events[0].Type)
+	require.NotNil(t, events[0].Snapshot)
+	assert.Equal(t, "auth", events[0].Snapshot.Feature)
+
+	err = Create(path, New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z"))
+	assert.Error(t, err)
+}
+
+func TestReplay_MatchesStatusAfterUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	require.NoError(t, Create(path, New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")))
+
+	_, events, err := Update(path, NoRevision, func(s *Status) error {
+		s.Stages["1-prd"].Status = StatusComplete
+		s.History = append(s.History, &HistoryEntry{
+			Timestamp: "2026-01-01T11:00:00Z", Stage: "1-prd", Action: "completed",
+			From: StatusInProgress, To: StatusComplete, Agent: "athena", SessionID: "abc",
+		})
+		s.Updated = "2026-01-01T11:00:00Z"
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "completed", events[0].Type)
+	assert.Equal(t, "abc", events[0].SessionID)
+	require.NotNil(t, events[0].Changes)
+	assert.Contains(t, events[0].Changes.Stages, "1-prd")
+	assert.Len(t, events[0].Changes.Stages, 1)
+
+	_, _, err = Update(path, NoRevision, func(s *Status) error {
+		s.Stages["2-prd-review"].Status = StatusInProgress
+		s.CurrentStage = "2-prd-review"
+		s.History = append(s.History, &HistoryEntry{
+			Timestamp: "2026-01-01T12:00:00Z", Stage: "2-prd-review", Action: "started",
+			From: StatusPending, To: StatusInProgress, Agent: "hermes",
+		})
+		s.Updated = "2026-01-01T12:00:00Z"
+		return nil
+	})
+	require.NoError(t, err)
+
+	// A write without history is logged as an update
+	_, events, err = Update(path, NoRevision, func(s *Status) error {
+		s.Mode = "eco"
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventUpdated, events[0].Type)
+
+	logged, err := ReadEvents(EventsPath(path))
+	require.NoError(t, err)
+	for i, e := range logged {
+		assert.Equal(t, i+1, e.Seq)
+	}
+
+	replayed, err := Replay(logged)
+	require.NoError(t, err)
+	current, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, replayed.Revision)
+	assertSameStatus(t, current, replayed)
+}
+
+func TestUpdate_BaselinesUnloggedFeature(t *testing.T) {
+	path := newStatusFile(t)
+
+	_, _, err := Update(path, NoRevision, func(s *Status) error {
+		s.Stages["1-prd"].Status = StatusComplete
+		return nil
+	})
+	require.NoError(t, err)
+
+	events, err := ReadEvents(EventsPath(path))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, EventBaseline, events[0].Type)
+	assert.Equal(t, StatusInProgress, events[0].Snapshot.Stages["1-prd"].Status)
+
+	replayed, err := Replay(events)
+	require.NoError(t, err)
+	current, err := Load(path)
+	require.NoError(t, err)
+	assertSameStatus(t, current, replayed)
+}
+
+func TestReplay_RequiresSnapshot(t *testing.T) {
+	_, err := Replay(nil)
+	assert.Error(t, err)
+
+	_, err = Replay([]*Event{{Seq: 1, Type: "started"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not start with a snapshot")
+}
+
+func TestReadEvents(t *testing.T) {
+	dir := t.TempDir()
+
+	events, err := ReadEvents(filepath.Join(dir, "events.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	path := filepath.Join(dir, "bad.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"seq\":1}\n\nnot json\n"), 0o644))
+	_, err = ReadEvents(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+}
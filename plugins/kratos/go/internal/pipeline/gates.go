@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// verdictRule is a verdict a review stage must carry before later stages may run
+type verdictRule struct {
+	fields []string // stage fields checked in order; the first non-empty one wins
+	pass   []string
+}
+
+// verdictRules lists the review stages whose verdicts gate downstream stages
+// An SA "concerns" verdict blocks the test plan just like "unsound" does
+var verdictRules = map[string][]verdictRule{
+	"2-prd-review":     {{[]string{"verdict"}, []string{"approved", "approved-with-comments"}}},
+	"6-spec-review-pm": {{[]string{"verdict"}, []string{"approved", "aligned"}}},
+	"7-spec-review-sa": {{[]string{"verdict"}, []string{"sound"}}},
+	"10-prd-alignment": {{[]string{"alignment_verdict", "verdict"}, []string{"aligned"}}},
+	"11-review": {
+		{[]string{"code_review_verdict", "verdict"}, []string{"approved"}},
+		{[]string{"risk_verdict"}, []string{"clear", "caution"}},
+	},
+}
+
+// revisionTargets maps each review stage to the stage its negative verdict sends work back to
+var revisionTargets = map[string]string{
+	"2-prd-review":     "1-prd",
+	"6-spec-review-pm": "5-tech-spec",
+	"7-spec-review-sa": "5-tech-spec",
+	"10-prd-alignment": "9-implementation",
+	"11-review":        "9-implementation",
+}
+
+// escalateVerdicts cannot be fixed by revising upstream work and need the user
+var escalateVerdicts = []string{"misaligned"}
+
+// GatedStatus reports whether moving a stage to status must pass its gate
+func GatedStatus(status string) bool {
+	switch status {
+	case StatusInProgress, StatusReady, StatusComplete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Requires returns the stages that must be finished before id may start
+// Stages written without a gate fall back to the default pipeline definition
+func (s *Status) Requires(id string) []string {
+	if stage := s.Stages[id]; stage != nil && stage.Gate != nil {
+		return stage.Gate.Requires
+	}
+	return s.definition(id).requires
+}
+
+// CheckGate returns the unmet requirements of a stage's gate, or nil if it is open
+// A required stage must be complete (or skipped, if optional) and, for review
+// stages, carry a passing verdict
+func (s *Status) CheckGate(id string) []string {
+	var unmet []string
+	for _, req := range s.Requires(id) {
+		stage := s.Stages[req]
+		if stage == nil {
+			unmet = append(unmet, fmt.Sprintf("%s is missing", req))
+			continue
+		}
+		if stage.Status == StatusSkipped && stage.Optional {
+			continue
+		}
+		if stage.Status != StatusComplete {
+			unmet = append(unmet, fmt.Sprintf("%s is %s, not complete", req, stage.Status))
+			continue
+		}
+		for _, rule := range s.rules(req) {
+			verdict := stage.verdict(rule.fields)
+			if verdict == "" {
+				unmet = append(unmet, fmt.Sprintf("%s has no verdict, needs %s", req, strings.Join(rule.pass, " or ")))
+			} else if !contains(rule.pass, verdict) {
+				unmet = append(unmet, fmt.Sprintf("%s verdict is %s, needs %s", req, verdict, strings.Join(rule.pass, " or ")))
+			}
+		}
+	}
+	return unmet
+}
+
+// FailedVerdict returns the negative verdict recorded on a completed review stage
+// Returns "" if the stage is not a finished review or its verdicts pass or are missing
+func (s *Status) FailedVerdict(id string) string {
+	stage := s.Stages[id]
+	if stage == nil || stage.Status != StatusComplete {
+		return ""
+	}
+	for _, rule := range s.rules(id) {
+		if verdict := stage.verdict(rule.fields); verdict != "" && !contains(rule.pass, verdict) {
+			return verdict
+		}
+	}
+	return ""
+}
+
+// RevisionTarget returns the stage a negative verdict on review stage id sends work back to
+// Returns "" for stages that are not reviews and for verdicts that need the user
+func (s *Status) RevisionTarget(id, verdict string) string {
+	if contains(escalateVerdicts, NormalizeVerdict(verdict)) {
+		return ""
+	}
+	if stage := s.Stages[id]; stage != nil && stage.Revises != "" {
+		return stage.Revises
+	}
+	if s.Template != "" {
+		return ""
+	}
+	return revisionTargets[id]
+}
+
+// rules returns the verdict rules of a review stage
+// Template stages list their passing verdicts; built-in stages use verdictRules
+func (s *Status) rules(id string) []verdictRule {
+	if stage := s.Stages[id]; stage != nil && len(stage.Verdicts) > 0 {
+		return []verdictRule{{[]string{"verdict"}, stage.Verdicts}}
+	}
+	if s.Template != "" {
+		return nil
+	}
+	return verdictRules[id]
+}
+
+// NormalizeVerdict lowercases a verdict and joins words with hyphens
+// so "Approved with Comments" and "approved-with-comments" compare equal
+func NormalizeVerdict(verdict string) string {
+	return strings.Join(strings.Fields(strings.ToLower(verdict)), "-")
+}
+
+// verdict returns the first non-empty verdict among fields, normalized
+// Fields other than "verdict" are agent-written extras such as alignment_verdict
+func (st *Stage) verdict(fields []string) string {
+	for _, field := range fields {
+		value := st.Verdict
+		if field != "verdict" {
+			value = ""
+			if raw, ok := st.Extra[field]; ok {
+				json.Unmarshal(raw, &value)
+			}
+		}
+		if value != "" {
+			return NormalizeVerdict(value)
+		}
+	}
+	return ""
+}
+
+// contains reports whether v is one of values
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// complete marks stages complete with the given verdicts ("" for none)
+func complete(s *Status, verdicts map[string]string) {
+	for id, verdict := range verdicts {
+		s.Stages[id].Status = StatusComplete
+		s.Stages[id].Verdict = verdict
+	}
+}
+
+// TestCheckGate_RequiredStages tests that required stages must be complete
+func TestCheckGate_RequiredStages(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+
+	assert.Empty(t, s.CheckGate("1-prd"))
+	assert.Equal(t, []string{"1-prd is in-progress, not complete"}, s.CheckGate("2-prd-review"))
+	assert.Equal(t, []string{"6-spec-review-pm is pending, not complete", "7-spec-review-sa is pending, not complete"}, s.CheckGate("8-test-plan"))
+
+	complete(s, map[string]string{"1-prd": ""})
+	assert.Empty(t, s.CheckGate("2-prd-review"))
+}
+
+// TestCheckGate_Verdicts tests that review stages need a passing verdict
+func TestCheckGate_Verdicts(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+
+	complete(s, map[string]string{"1-prd": "", "2-prd-review": ""})
+	assert.Equal(t, []string{"2-prd-review has no verdict, needs approved or approved-with-comments"}, s.CheckGate("5-tech-spec"))
+
+	complete(s, map[string]string{"2-prd-review": "revisions"})
+	assert.Equal(t, []string{"2-prd-review verdict is revisions, needs approved or approved-with-comments"}, s.CheckGate("5-tech-spec"))
+
+	complete(s, map[string]string{"2-prd-review": "Approved with Comments"})
+	assert.Empty(t, s.CheckGate("5-tech-spec"))
+
+	complete(s, map[string]string{"6-spec-review-pm": "approved", "7-spec-review-sa": "concerns"})
+	assert.Equal(t, []string{"7-spec-review-sa verdict is concerns, needs sound"}, s.CheckGate("8-test-plan"))
+
+	complete(s, map[string]string{"7-spec-review-sa": "Sound"})
+	assert.Empty(t, s.CheckGate("8-test-plan"))
+}
+
+// TestCheckGate_AlignmentVerdict tests reading Hera's alignment_verdict field
+func TestCheckGate_AlignmentVerdict(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	complete(s, map[string]string{"10-prd-alignment": ""})
+
+	s.Stages["10-prd-alignment"].Extra = map[string]json.RawMessage{"alignment_verdict": json.RawMessage(`"gaps"`)}
+	assert.Equal(t, []string{"10-prd-alignment verdict is gaps, needs aligned"}, s.CheckGate("11-review"))
+
+	s.Stages["10-prd-alignment"].Extra = map[string]json.RawMessage{"alignment_verdict": json.RawMessage(`"aligned"`)}
+	assert.Empty(t, s.CheckGate("11-review"))
+}
+
+// TestCheckGate_OptionalSkipped tests that skipped optional stages do not block
+func TestCheckGate_OptionalSkipped(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	complete(s, map[string]string{"1-prd": "", "2-prd-review": "approved"})
+	s.Stages["9-implementation"].Gate = &Gate{Requires: []string{"3-decomposition", "2-prd-review"}}
+
+	assert.Empty(t, s.CheckGate("9-implementation"))
+
+	// A skipped stage that is not optional still blocks
+	s.Stages["3-decomposition"].Optional = false
+	assert.Equal(t, []string{"3-decomposition is skipped, not complete"}, s.CheckGate("9-implementation"))
+}
+
+// TestRequires_DefaultsWithoutGate tests the fallback for stages written without a gate
+func TestRequires_DefaultsWithoutGate(t *testing.T) {
+	s, err := Parse([]byte(legacyStatus))
+	require.NoError(t, err)
+
+	assert.Nil(t, s.Stages["5-tech-spec"].Gate)
+	assert.Equal(t, []string{"2-prd-review"}, s.Requires("5-tech-spec"))
+	assert.Equal(t, []string{"2-prd-review is in-progress, not complete"}, s.CheckGate("5-tech-spec"))
+}
+
+func TestGatedStatus(t *testing.T) {
+	assert.True(t, GatedStatus(StatusInProgress))
+	assert.True(t, GatedStatus(StatusComplete))
+	assert.True(t, GatedStatus(StatusReady))
+	assert.False(t, GatedStatus(StatusSkipped))
+	assert.False(t, GatedStatus(StatusBlocked))
+}
+
+func TestFailedVerdict(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+
+	// Verdicts only count once the review is complete
+	s.Stages["7-spec-review-sa"].Verdict = "unsound"
+	assert.Empty(t, s.FailedVerdict("7-spec-review-sa"))
+
+	complete(s, map[string]string{"7-spec-review-sa": "Unsound"})
+	assert.Equal(t, "unsound", s.FailedVerdict("7-spec-review-sa"))
+
+	complete(s, map[string]string{"7-spec-review-sa": "sound", "1-prd": "whatever"})
+	assert.Empty(t, s.FailedVerdict("7-spec-review-sa"))
+	assert.Empty(t, s.FailedVerdict("1-prd"))
+
+	complete(s, map[string]string{"11-review": "approved"})
+	s.Stages["11-review"].Extra = map[string]json.RawMessage{"risk_verdict": json.RawMessage(`"blocked"`)}
+	assert.Equal(t, "blocked", s.FailedVerdict("11-review"))
+}
+
+func TestRevisionTarget(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	assert.Equal(t, "1-prd", s.RevisionTarget("2-prd-review", "revisions"))
+	assert.Equal(t, "5-tech-spec", s.RevisionTarget("7-spec-review-sa", "unsound"))
+	assert.Equal(t, "9-implementation", s.RevisionTarget("10-prd-alignment", "gaps"))
+	assert.Empty(t, s.RevisionTarget("10-prd-alignment", "Misaligned"))
+	assert.Empty(t, s.RevisionTarget("5-tech-spec", "revisions"))
+}
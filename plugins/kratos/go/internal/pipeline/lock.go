@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// LockTimeout is how long Lock waits for another writer before giving up
+var LockTimeout = 10 * time.Second
+
+// lockPoll is the interval between lock attempts
+const lockPoll = 25 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("locked")
+
+// Lock takes an exclusive advisory lock on path by locking path + ".lock"
+// The lock is released by the returned function or when the process exits,
+// so a crashed writer never leaves the feature locked
+func Lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open lock file %s: %w", lockPath, err)
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close()
+			return nil, fmt.Errorf("cannot lock %s: %w", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for lock on %s", LockTimeout, path)
+		}
+		time.Sleep(lockPoll)
+	}
+
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
+
+// ErrRevisionConflict is returned when status.json changed since the caller read it
+var ErrRevisionConflict = errors.New("revision conflict")
+
+// NoRevision disables the compare-and-swap check of Update
+const NoRevision = -1
+
+// Update applies fn to the status at path under an exclusive lock and saves it
+// with its revision incremented. When expect is not NoRevision the update is
+// refused unless the file is still at that revision, so a caller that read the
+// status earlier never overwrites a change it has not seen.
+// status.json is replaced first and the write then logged to events.jsonl, so
+// the log never records a change that did not happen; if logging fails the
+// saved status is returned with the error and the log lags status.json, which
+// replay detects by revision. The returned events are the ones appended
+func Update(path string, expect int, fn func(s *Status) error) (*Status, []*Event, error) {
+	unlock, err := Lock(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	status, err := Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expect != NoRevision && status.Revision != expect {
+		return nil, nil, fmt.Errorf("%w: %s is at revision %d, expected %d (re-read it and retry)", ErrRevisionConflict, path, status.Revision, expect)
+	}
+
+	before, err := clone(status)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fn(status); err != nil {
+		return nil, nil, err
+	}
+	status.Revision++
+
+	events := Diff(before, status, len(before.History))
+	logPath := EventsPath(path)
+	if _, err := os.Stat(logPath); errors.Is(err, os.ErrNotExist) {
+		// Features created before the event log start it from their current state
+		baseline := &Event{
+			Revision:  before.Revision,
+			Timestamp: before.Updated,
+			Type:      EventBaseline,
+			Stage:     before.CurrentStage,
+			Reason:    "event log started from existing status.json",
+			Snapshot:  before,
+		}
+		events = append([]*Event{baseline}, events...)
+	}
+	if err := Save(path, status); err != nil {
+		return nil, nil, err
+	}
+	if err := AppendEvents(logPath, events); err != nil {
+		return status, nil, fmt.Errorf("status.json saved at revision %d but its events were not logged: %w", status.Revision, err)
+	}
+	return status, events, nil
+}
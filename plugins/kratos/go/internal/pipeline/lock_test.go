@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatusFile writes a fresh pipeline to a temp status.json
+func newStatusFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "status.json")
+	require.NoError(t, Save(path, New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")))
+	return path
+}
+
+func TestUpdate_ConcurrentWritersKeepEveryChange(t *testing.T) {
+	path := newStatusFile(t)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := Update(path, NoRevision, func(s *Status) error {
+				s.History = append(s.History, &HistoryEntry{Stage: "11-review", Action: "note", Notes: fmt.Sprint(i)})
+				return nil
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, s.History, writers)
+	assert.Equal(t, writers, s.Revision)
+
+	// Writers leave no temp files behind
+	leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestUpdate_ExpectRevision(t *testing.T) {
+	path := newStatusFile(t)
+
+	s, _, err := Update(path, 0, func(s *Status) error {
+		s.Stages["1-prd"].Status = StatusComplete
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Revision)
+
+	// A writer that read revision 0 must not overwrite revision 1
+	_, _, err = Update(path, 0, func(s *Status) error {
+		s.Stages["1-prd"].Status = StatusPending
+		return nil
+	})
+	require.ErrorIs(t, err, ErrRevisionConflict)
+	assert.Contains(t, err.Error(), "is at revision 1, expected 0")
+
+	s, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, StatusComplete, s.Stages["1-prd"].Status)
+
+	// A failing update leaves the file untouched
+	_, _, err = Update(path, NoRevision, func(s *Status) error { return fmt.Errorf("gate not met") })
+	require.Error(t, err)
+	s, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Revision)
+}
+
+func TestLock_Timeout(t *testing.T) {
+	path := newStatusFile(t)
+	defer func(timeout time.Duration) { LockTimeout = timeout }(LockTimeout)
+	LockTimeout = 50 * time.Millisecond
+
+	unlock, err := Lock(path)
+	require.NoError(t, err)
+
+	_, err = Lock(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+
+	unlock()
+	unlock, err = Lock(path)
+	require.NoError(t, err)
+	unlock()
+
+	_, err = os.Stat(path + ".lock")
+	assert.NoError(t, err)
+}
+
+func TestUpdate_SavesBeforeLogging(t *testing.T) {
+	path := newStatusFile(t)
+	// An unwritable log fails the append after status.json is saved
+	require.NoError(t, os.Mkdir(EventsPath(path), 0755))
+
+	s, events, err := Update(path, NoRevision, func(s *Status) error {
+		s.PipelineStatus = StatusBlocked
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "saved at revision 1")
+	assert.Nil(t, events)
+	require.NotNil(t, s)
+
+	saved, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, saved.Revision)
+	assert.Equal(t, StatusBlocked, saved.PipelineStatus)
+}
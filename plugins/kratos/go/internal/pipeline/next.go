@@ -0,0 +1,258 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Next actions
+const (
+	ActionStart    = "start"     // spawn the agent for a stage that has not started
+	ActionContinue = "continue"  // the stage is already running; resume or re-spawn its agent
+	ActionRevise   = "revise"    // a review verdict sent work back to an earlier stage
+	ActionComplete = "complete"  // the stage's work is done; mark it complete
+	ActionWaitUser = "wait-user" // the user has to act before the pipeline can move
+	ActionBlocked  = "blocked"   // a gate or verdict stops the pipeline
+	ActionDone     = "done"      // every stage is finished
+)
+
+// NextAction is the routing answer for a feature pipeline
+type NextAction struct {
+	Feature  string        `json:"feature"`
+	Action   string        `json:"action"`
+	Stage    string        `json:"stage,omitempty"`
+	Agent    string        `json:"agent,omitempty"`
+	Inputs   []string      `json:"inputs"`
+	Parallel []*StageRef   `json:"parallel,omitempty"`
+	Unmet    []string      `json:"unmet,omitempty"`
+	Tasks    *TaskProgress `json:"tasks,omitempty"`
+	Reason   string        `json:"reason"`
+}
+
+// StageRef names a stage and the agent that works it
+type StageRef struct {
+	Stage string `json:"stage"`
+	Agent string `json:"agent"`
+}
+
+// TaskProgress counts the stage-9 tasks
+type TaskProgress struct {
+	Total    int `json:"total"`
+	Complete int `json:"complete"`
+}
+
+// Next computes the next actionable stage of a pipeline
+// Negative review verdicts are resolved first, earliest stage first; otherwise
+// the first unfinished stage in pipeline order decides the action
+func Next(s *Status) *NextAction {
+	ids := s.StageIDs()
+
+	for _, id := range ids {
+		verdict := s.FailedVerdict(id)
+		if verdict == "" || s.overridden(id) {
+			continue
+		}
+		return s.nextRevision(id, verdict)
+	}
+
+	for _, id := range ids {
+		stage := s.Stages[id]
+		switch stage.Status {
+		case StatusComplete, StatusSkipped:
+			continue
+
+		case StatusInProgress, StatusReady:
+			next := s.action(ActionContinue, id, fmt.Sprintf("%s is %s", id, stage.Status))
+			if revision := s.LastRevision(id); revision != nil && stage.Revisions > 0 {
+				next.Reason = fmt.Sprintf("%s is being revised after %s returned %s", id, revision.Stage, revision.Verdict)
+				if review := s.Stages[revision.Stage]; review != nil {
+					next.Inputs = appendDocuments(next.Inputs, review)
+				}
+			}
+			if len(stage.Tasks) > 0 {
+				progress := stage.TaskProgress()
+				next.Tasks = progress
+				switch {
+				case progress.Complete == progress.Total:
+					next.Action = ActionComplete
+					next.Reason = fmt.Sprintf("all %d tasks are complete", progress.Total)
+				case stage.Mode == "user" || s.ImplementationMode == "user":
+					next.Action = ActionWaitUser
+					next.Reason = fmt.Sprintf("user mode: %d of %d tasks complete", progress.Complete, progress.Total)
+				default:
+					next.Reason = fmt.Sprintf("%d of %d tasks complete", progress.Complete, progress.Total)
+				}
+			}
+			next.Parallel = s.startable(id)
+			return next
+
+		case StatusWaitingUser:
+			return s.action(ActionWaitUser, id, fmt.Sprintf("%s is waiting for the user", id))
+
+		case StatusBlocked:
+			return s.action(ActionBlocked, id, fmt.Sprintf("%s is marked blocked", id))
+
+		default:
+			if unmet := s.CheckGate(id); len(unmet) > 0 {
+				next := s.action(ActionBlocked, id, fmt.Sprintf("gate for %s not met", id))
+				next.Unmet = unmet
+				return next
+			}
+			next := s.action(ActionStart, id, fmt.Sprintf("gate for %s is met", id))
+			next.Parallel = s.startable(id)
+			return next
+		}
+	}
+
+	return &NextAction{Feature: s.Feature, Action: ActionDone, Inputs: []string{}, Reason: "every stage is complete or skipped"}
+}
+
+// nextRevision routes a negative verdict on review stage id
+func (s *Status) nextRevision(id, verdict string) *NextAction {
+	target := s.RevisionTarget(id, verdict)
+	if target == "" || s.Stages[target] == nil {
+		next := s.action(ActionBlocked, id, fmt.Sprintf("%s verdict is %s; escalate to the user", id, verdict))
+		next.Unmet = []string{fmt.Sprintf("%s verdict is %s", id, verdict)}
+		return next
+	}
+
+	review, revised := s.Stages[id], s.Stages[target]
+	switch {
+	case revised.Status == StatusComplete && after(revised.Completed, review.Completed):
+		return s.action(ActionStart, id, fmt.Sprintf("%s was revised after the %s verdict; re-run %s", target, verdict, id))
+	case revised.Status == StatusInProgress:
+		next := s.action(ActionContinue, target, fmt.Sprintf("%s is being revised after %s returned %s", target, id, verdict))
+		next.Inputs = appendDocuments(next.Inputs, review)
+		return next
+	default:
+		next := s.action(ActionRevise, target, fmt.Sprintf("%s returned %s", id, verdict))
+		next.Inputs = appendDocuments(next.Inputs, review)
+		return next
+	}
+}
+
+// overridden reports whether a stage gated on review id has already started
+// That only happens when the gate was forced, so the verdict no longer routes
+func (s *Status) overridden(id string) bool {
+	for other, stage := range s.Stages {
+		if stage.Status == StatusPending || stage.Status == StatusSkipped {
+			continue
+		}
+		if contains(s.Requires(other), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// action builds a next action for stage id with the stage's agent and input documents
+func (s *Status) action(action, id, reason string) *NextAction {
+	return &NextAction{
+		Feature: s.Feature,
+		Action:  action,
+		Stage:   id,
+		Agent:   s.agent(id),
+		Inputs:  s.Inputs(id),
+		Reason:  reason,
+	}
+}
+
+// startable returns the pending stages other than id whose gates are open
+// These can run alongside id, like the PM and SA spec reviews
+func (s *Status) startable(id string) []*StageRef {
+	var refs []*StageRef
+	for _, other := range s.StageIDs() {
+		if other == id || s.Stages[other].Status != StatusPending {
+			continue
+		}
+		if len(s.CheckGate(other)) == 0 {
+			refs = append(refs, &StageRef{Stage: other, Agent: s.agent(other)})
+		}
+	}
+	return refs
+}
+
+// agent returns the agent assigned to a stage, falling back to the default pipeline
+func (s *Status) agent(id string) string {
+	if stage := s.Stages[id]; stage != nil && stage.Agent != "" {
+		return stage.Agent
+	}
+	return s.definition(id).agent
+}
+
+// Inputs returns the documents of finished stages the agent for id reads
+// Skipped and unfinished stages contribute nothing
+func (s *Status) Inputs(id string) []string {
+	inputs := []string{}
+	for _, in := range s.definition(id).inputs {
+		if stage := s.Stages[in]; stage != nil && stage.Status == StatusComplete {
+			inputs = appendDocuments(inputs, stage)
+		}
+	}
+	return inputs
+}
+
+// TaskProgress counts the stage's tasks and how many are complete
+func (st *Stage) TaskProgress() *TaskProgress {
+	progress := &TaskProgress{Total: len(st.Tasks)}
+	for _, task := range st.Tasks {
+		if task.Status == StatusComplete {
+			progress.Complete++
+		}
+	}
+	return progress
+}
+
+// appendDocuments appends the stage's documents that are not already listed
+func appendDocuments(docs []string, stage *Stage) []string {
+	for _, doc := range stage.Documents {
+		if !contains(docs, doc) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// after reports whether RFC3339 timestamp a is later than b
+// A missing a is never later; a missing b counts as earlier than any a
+func after(a, b string) bool {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return false
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	if err != nil {
+		return true
+	}
+	return ta.After(tb)
+}
+
+// AgentStage returns the stage a newly spawned agent is working on
+// The agent's running stage wins (the current stage first), then its first
+// startable stage; an agent that owns no such stage is credited to the current stage
+func (s *Status) AgentStage(agent string) string {
+	var running, startable string
+	for _, id := range s.StageIDs() {
+		if !strings.EqualFold(s.agent(id), agent) {
+			continue
+		}
+		switch s.Stages[id].Status {
+		case StatusInProgress, StatusReady:
+			if running == "" || id == s.CurrentStage {
+				running = id
+			}
+		case StatusPending:
+			if startable == "" && len(s.CheckGate(id)) == 0 {
+				startable = id
+			}
+		}
+	}
+	switch {
+	case running != "":
+		return running
+	case startable != "":
+		return startable
+	}
+	return s.CurrentStage
+}
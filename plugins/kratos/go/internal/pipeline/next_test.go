@@ -0,0 +1,186 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// finish marks stages complete at ts with the given verdicts ("" for none)
+func finish(s *Status, ts string, verdicts map[string]string) {
+	for id, verdict := range verdicts {
+		s.Stages[id].Status = StatusComplete
+		s.Stages[id].Completed = ts
+		s.Stages[id].Verdict = verdict
+	}
+}
+
+func TestNext_Fresh(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+
+	next := Next(s)
+	assert.Equal(t, ActionContinue, next.Action)
+	assert.Equal(t, "1-prd", next.Stage)
+	assert.Equal(t, "athena", next.Agent)
+	assert.Empty(t, next.Inputs)
+}
+
+func TestNext_StartWithInputs(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": "", "2-prd-review": "approved"})
+
+	// Optional stages 3 and 4 are skipped, so the tech spec is next
+	next := Next(s)
+	assert.Equal(t, ActionStart, next.Action)
+	assert.Equal(t, "5-tech-spec", next.Stage)
+	assert.Equal(t, "hephaestus", next.Agent)
+	assert.Equal(t, []string{"prd.md"}, next.Inputs)
+
+	// An opted-in decomposition runs first and feeds the spec
+	s.Stages["3-decomposition"].Status = StatusPending
+	next = Next(s)
+	assert.Equal(t, "3-decomposition", next.Stage)
+	assert.Equal(t, []*StageRef{{Stage: "5-tech-spec", Agent: "hephaestus"}}, next.Parallel)
+
+	finish(s, "2026-01-03T10:00:00Z", map[string]string{"3-decomposition": ""})
+	assert.Equal(t, []string{"prd.md", "decomposition.md"}, Next(s).Inputs)
+}
+
+func TestNext_ParallelReviews(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": "", "2-prd-review": "approved", "5-tech-spec": ""})
+
+	next := Next(s)
+	assert.Equal(t, ActionStart, next.Action)
+	assert.Equal(t, "6-spec-review-pm", next.Stage)
+	assert.Equal(t, []*StageRef{{Stage: "7-spec-review-sa", Agent: "apollo"}}, next.Parallel)
+	assert.Equal(t, []string{"tech-spec.md", "prd.md"}, next.Inputs)
+}
+
+func TestNext_Blocked(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": "", "2-prd-review": ""})
+
+	next := Next(s)
+	assert.Equal(t, ActionBlocked, next.Action)
+	assert.Equal(t, "5-tech-spec", next.Stage)
+	assert.Equal(t, []string{"2-prd-review has no verdict, needs approved or approved-with-comments"}, next.Unmet)
+}
+
+func TestNext_RevisionLoop(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": ""})
+	finish(s, "2026-01-03T10:00:00Z", map[string]string{"2-prd-review": "revisions"})
+
+	next := Next(s)
+	assert.Equal(t, ActionRevise, next.Action)
+	assert.Equal(t, "1-prd", next.Stage)
+	assert.Equal(t, "athena", next.Agent)
+	assert.Equal(t, []string{"prd-review.md"}, next.Inputs)
+
+	s.Stages["1-prd"].Status = StatusInProgress
+	assert.Equal(t, ActionContinue, Next(s).Action)
+
+	// Once the PRD is revised the review runs again
+	finish(s, "2026-01-04T10:00:00Z", map[string]string{"1-prd": ""})
+	next = Next(s)
+	assert.Equal(t, ActionStart, next.Action)
+	assert.Equal(t, "2-prd-review", next.Stage)
+}
+
+func TestNext_RevisionVerdictFields(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	for _, id := range s.StageIDs() {
+		if !s.Stages[id].Optional {
+			finish(s, "2026-01-02T10:00:00Z", map[string]string{id: ""})
+		}
+	}
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"2-prd-review": "approved", "6-spec-review-pm": "approved", "7-spec-review-sa": "sound"})
+	s.Stages["10-prd-alignment"].Extra = map[string]json.RawMessage{"alignment_verdict": json.RawMessage(`"aligned"`)}
+	s.Stages["11-review"].Extra = map[string]json.RawMessage{"code_review_verdict": json.RawMessage(`"changes-required"`)}
+
+	next := Next(s)
+	assert.Equal(t, ActionRevise, next.Action)
+	assert.Equal(t, "9-implementation", next.Stage)
+
+	s.Stages["11-review"].Extra = map[string]json.RawMessage{"code_review_verdict": json.RawMessage(`"approved"`)}
+	assert.Equal(t, ActionDone, Next(s).Action)
+
+	// Misalignment cannot be fixed by revising and goes to the user
+	s.Stages["10-prd-alignment"].Extra = map[string]json.RawMessage{"alignment_verdict": json.RawMessage(`"misaligned"`)}
+	s.Stages["11-review"].Status = StatusPending
+	next = Next(s)
+	assert.Equal(t, ActionBlocked, next.Action)
+	assert.Equal(t, "10-prd-alignment", next.Stage)
+}
+
+func TestNext_ForcedPastVerdict(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": "", "2-prd-review": "revisions"})
+	s.Stages["5-tech-spec"].Status = StatusInProgress
+
+	next := Next(s)
+	assert.Equal(t, ActionContinue, next.Action)
+	assert.Equal(t, "5-tech-spec", next.Stage)
+}
+
+func TestNext_TaskProgress(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	for _, id := range []string{"1-prd", "2-prd-review", "5-tech-spec", "6-spec-review-pm", "7-spec-review-sa", "8-test-plan"} {
+		finish(s, "2026-01-02T10:00:00Z", map[string]string{id: ""})
+	}
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"2-prd-review": "approved", "6-spec-review-pm": "approved", "7-spec-review-sa": "sound"})
+
+	impl := s.Stages["9-implementation"]
+	impl.Status = StatusInProgress
+	impl.Mode = "user"
+	impl.Tasks = []*Task{
+		{ID: "01", Title: "Schema", Status: StatusComplete},
+		{ID: "02", Title: "Handler", Status: StatusPending},
+	}
+
+	next := Next(s)
+	assert.Equal(t, ActionWaitUser, next.Action)
+	assert.Equal(t, &TaskProgress{Total: 2, Complete: 1}, next.Tasks)
+	assert.Equal(t, []string{"tech-spec.md", "test-plan.md"}, next.Inputs)
+
+	impl.Tasks[1].Status = StatusComplete
+	next = Next(s)
+	assert.Equal(t, ActionComplete, next.Action)
+	assert.Equal(t, "9-implementation", next.Stage)
+}
+
+func TestNext_Done(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	for _, stage := range s.Stages {
+		if stage.Status != StatusSkipped {
+			stage.Status = StatusComplete
+		}
+	}
+
+	next := Next(s)
+	assert.Equal(t, ActionDone, next.Action)
+	assert.Empty(t, next.Stage)
+}
+
+func TestAgentStage(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	assert.Equal(t, "1-prd", s.AgentStage("athena"))
+	assert.Equal(t, "1-prd", s.AgentStage("Athena"))
+	// An agent with no open stage is credited to the current stage
+	assert.Equal(t, "1-prd", s.AgentStage("apollo"))
+	assert.Equal(t, "1-prd", s.AgentStage("metis"))
+
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": "", "2-prd-review": "approved", "5-tech-spec": ""})
+	s.CurrentStage = "6-spec-review-pm"
+	s.Stages["6-spec-review-pm"].Status = StatusInProgress
+
+	// The SA review can start alongside the PM review
+	assert.Equal(t, "7-spec-review-sa", s.AgentStage("apollo"))
+	assert.Equal(t, "6-spec-review-pm", s.AgentStage("athena"))
+
+	s.Stages["7-spec-review-sa"].Status = StatusInProgress
+	assert.Equal(t, "7-spec-review-sa", s.AgentStage("apollo"))
+	assert.Equal(t, "6-spec-review-pm", s.AgentStage("ares"))
+}
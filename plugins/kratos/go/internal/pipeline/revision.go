@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Revise applies a negative verdict recorded on completed review stage id
+// The revision target reopens in progress with its revision counter incremented,
+// and every stage downstream of it loses its completion and verdict. A verdict
+// that cannot be fixed by revising (misaligned) blocks the pipeline instead.
+// Returns the history entry to record, or nil if the verdicts pass
+func (s *Status) Revise(id, ts string) *HistoryEntry {
+	verdict := s.FailedVerdict(id)
+	if verdict == "" {
+		return nil
+	}
+
+	entry := &HistoryEntry{
+		Timestamp: ts,
+		Stage:     id,
+		Agent:     s.agent(id),
+		Verdict:   verdict,
+	}
+
+	targetID := s.RevisionTarget(id, verdict)
+	target := s.Stages[targetID]
+	if target == nil {
+		s.PipelineStatus = StatusBlocked
+		entry.Action = "escalated"
+		entry.Notes = fmt.Sprintf("%s returned %s; pipeline blocked until the user decides", id, verdict)
+		return entry
+	}
+
+	target.Status = StatusInProgress
+	target.Started = ts
+	target.Completed = ""
+	target.Revisions++
+
+	var reset []string
+	for _, downstream := range s.Downstream(targetID) {
+		stage := s.Stages[downstream]
+		if stage.Status == StatusPending || stage.Status == StatusSkipped {
+			continue
+		}
+		stage.Status = StatusPending
+		stage.Started = ""
+		stage.Completed = ""
+		stage.clearVerdicts(s.rules(downstream))
+		reset = append(reset, downstream)
+	}
+
+	s.CurrentStage = targetID
+	s.PipelineStatus = StatusInProgress
+
+	entry.Action = "revision-requested"
+	entry.Notes = fmt.Sprintf("%s returned %s; reopened %s (revision %d)", id, verdict, targetID, target.Revisions)
+	if len(reset) > 0 {
+		entry.Notes += "; reset " + strings.Join(reset, ", ")
+	}
+	return entry
+}
+
+// Downstream returns the stages that depend on id, directly or transitively, in pipeline order
+func (s *Status) Downstream(id string) []string {
+	affected := map[string]bool{id: true}
+	var downstream []string
+	for changed := true; changed; {
+		changed = false
+		for _, other := range s.StageIDs() {
+			if affected[other] {
+				continue
+			}
+			for _, req := range s.Requires(other) {
+				if affected[req] {
+					affected[other] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for _, other := range s.StageIDs() {
+		if other != id && affected[other] {
+			downstream = append(downstream, other)
+		}
+	}
+	return downstream
+}
+
+// LastRevision returns the most recent revision-requested entry that reopened stage id
+// Returns nil if the stage was never sent back for revision
+func (s *Status) LastRevision(id string) *HistoryEntry {
+	for i := len(s.History) - 1; i >= 0; i-- {
+		entry := s.History[i]
+		if entry.Action == "revision-requested" && s.RevisionTarget(entry.Stage, entry.Verdict) == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+// clearVerdicts removes the verdicts a review stage recorded, including agent-written fields
+func (st *Stage) clearVerdicts(rules []verdictRule) {
+	st.Verdict = ""
+	for _, rule := range rules {
+		for _, field := range rule.fields {
+			delete(st.Extra, field)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// throughImplementation returns a pipeline finished up to and including stage 9
+func throughImplementation() *Status {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	for _, id := range []string{"1-prd", "5-tech-spec", "8-test-plan", "9-implementation"} {
+		finish(s, "2026-01-02T10:00:00Z", map[string]string{id: ""})
+	}
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"2-prd-review": "approved", "6-spec-review-pm": "approved", "7-spec-review-sa": "sound"})
+	return s
+}
+
+func TestRevise_PassingVerdict(t *testing.T) {
+	s := throughImplementation()
+	assert.Nil(t, s.Revise("2-prd-review", "2026-01-03T10:00:00Z"))
+	assert.Nil(t, s.Revise("8-test-plan", "2026-01-03T10:00:00Z"))
+}
+
+func TestRevise_ResetsDownstream(t *testing.T) {
+	s := throughImplementation()
+	s.Stages["3-decomposition"].Status = StatusComplete
+	finish(s, "2026-01-03T10:00:00Z", map[string]string{"2-prd-review": "revisions"})
+
+	entry := s.Revise("2-prd-review", "2026-01-04T10:00:00Z")
+	require.NotNil(t, entry)
+	assert.Equal(t, "revision-requested", entry.Action)
+	assert.Equal(t, "2-prd-review", entry.Stage)
+	assert.Equal(t, "revisions", entry.Verdict)
+	assert.Equal(t, "2-prd-review returned revisions; reopened 1-prd (revision 1); reset 2-prd-review, 3-decomposition, 5-tech-spec, 6-spec-review-pm, 7-spec-review-sa, 8-test-plan, 9-implementation", entry.Notes)
+
+	prd := s.Stages["1-prd"]
+	assert.Equal(t, StatusInProgress, prd.Status)
+	assert.Equal(t, "2026-01-04T10:00:00Z", prd.Started)
+	assert.Empty(t, prd.Completed)
+	assert.Equal(t, 1, prd.Revisions)
+	assert.Equal(t, "1-prd", s.CurrentStage)
+
+	// Skipped optional stages stay skipped
+	assert.Equal(t, StatusSkipped, s.Stages["4-discuss"].Status)
+	assert.Equal(t, StatusPending, s.Stages["2-prd-review"].Status)
+	assert.Empty(t, s.Stages["2-prd-review"].Verdict)
+	assert.Empty(t, s.Stages["9-implementation"].Completed)
+}
+
+func TestRevise_CountsRevisions(t *testing.T) {
+	s := throughImplementation()
+	for i := 1; i <= 2; i++ {
+		finish(s, "2026-01-03T10:00:00Z", map[string]string{"10-prd-alignment": ""})
+		s.Stages["10-prd-alignment"].Extra = map[string]json.RawMessage{"alignment_verdict": json.RawMessage(`"gaps"`), "coverage_pct": json.RawMessage(`80`)}
+
+		entry := s.Revise("10-prd-alignment", "2026-01-04T10:00:00Z")
+		require.NotNil(t, entry)
+		assert.Equal(t, i, s.Stages["9-implementation"].Revisions)
+	}
+
+	// The agent-written verdict is cleared; other fields survive
+	assert.Equal(t, map[string]json.RawMessage{"coverage_pct": json.RawMessage(`80`)}, s.Stages["10-prd-alignment"].Extra)
+}
+
+func TestRevise_Escalates(t *testing.T) {
+	s := throughImplementation()
+	finish(s, "2026-01-03T10:00:00Z", map[string]string{"10-prd-alignment": "misaligned"})
+
+	entry := s.Revise("10-prd-alignment", "2026-01-04T10:00:00Z")
+	require.NotNil(t, entry)
+	assert.Equal(t, "escalated", entry.Action)
+	assert.Equal(t, StatusBlocked, s.PipelineStatus)
+	assert.Equal(t, StatusComplete, s.Stages["9-implementation"].Status)
+}
+
+func TestDownstream(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	assert.Equal(t, []string{"8-test-plan", "9-implementation", "10-prd-alignment", "11-review"}, s.Downstream("7-spec-review-sa"))
+	assert.Empty(t, s.Downstream("11-review"))
+}
+
+func TestNext_AfterRevise(t *testing.T) {
+	s := throughImplementation()
+	finish(s, "2026-01-03T10:00:00Z", map[string]string{"7-spec-review-sa": "unsound"})
+	s.History = append(s.History, s.Revise("7-spec-review-sa", "2026-01-04T10:00:00Z"))
+
+	next := Next(s)
+	assert.Equal(t, ActionContinue, next.Action)
+	assert.Equal(t, "5-tech-spec", next.Stage)
+	assert.Equal(t, "5-tech-spec is being revised after 7-spec-review-sa returned unsound", next.Reason)
+	assert.Equal(t, []string{"prd.md", "spec-review-sa.md"}, next.Inputs)
+}
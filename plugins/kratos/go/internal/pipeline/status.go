@@ -0,0 +1,466 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage status values
+const (
+	StatusPending     = "pending"
+	StatusInProgress  = "in-progress"
+	StatusComplete    = "complete"
+	StatusSkipped     = "skipped"
+	StatusBlocked     = "blocked"
+	StatusReady       = "ready"
+	StatusWaitingUser = "waiting-user"
+)
+
+// Status is the canonical status.json of a feature pipeline
+// See references/status-json-schema.md. Fields not modelled here are kept in
+// Extra and written back unchanged, so hand-edited fields survive a rewrite.
+type Status struct {
+	Feature            string            `json:"feature"`
+	Description        string            `json:"description,omitempty"`
+	Priority           string            `json:"priority,omitempty"`
+	Created            string            `json:"created"`
+	Updated            string            `json:"updated"`
+	Revision           int               `json:"revision,omitempty"` // incremented by every locked update
+	Template           string            `json:"template,omitempty"` // empty for the default pipeline
+	CurrentStage       string            `json:"current_stage"`
+	PipelineStatus     string            `json:"pipeline_status,omitempty"`
+	Mode               string            `json:"mode,omitempty"`
+	ImplementationMode string            `json:"implementation_mode,omitempty"`
+	Stages             map[string]*Stage `json:"stages"`
+	History            []*HistoryEntry   `json:"history"`
+
+	// Legacy is set when the file used the old "pipeline"/"stage" layout
+	Legacy bool                       `json:"-"`
+	Extra  map[string]json.RawMessage `json:"-"`
+}
+
+// Stage is a single pipeline stage
+type Stage struct {
+	Status    string   `json:"status"`
+	Agent     string   `json:"agent,omitempty"`
+	Started   string   `json:"started,omitempty"`
+	Completed string   `json:"completed,omitempty"`
+	Documents []string `json:"documents,omitempty"`
+	Verdict   string   `json:"verdict,omitempty"`
+	Revisions int      `json:"revisions,omitempty"` // times a review sent this stage back
+	Optional  bool     `json:"optional,omitempty"`
+	Mode      string   `json:"mode,omitempty"`
+	Tasks     []*Task  `json:"tasks,omitempty"`
+	Gate      *Gate    `json:"gate,omitempty"`
+
+	// Template stages record what the default pipeline keeps compiled in
+	Inputs   []string `json:"inputs,omitempty"`   // stages whose documents the agent reads
+	Verdicts []string `json:"verdicts,omitempty"` // passing verdicts of a review stage
+	Revises  string   `json:"revises,omitempty"`  // stage a failing verdict reopens
+
+	// InputHashes holds the SHA-256 of each input document when the stage completed
+	InputHashes map[string]string `json:"input_hashes,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Gate lists the stages that must be complete before a stage may start
+type Gate struct {
+	Requires  []string `json:"requires"`
+	Condition string   `json:"condition,omitempty"`
+}
+
+// Task is a stage-9 implementation task
+type Task struct {
+	ID          string  `json:"id"`
+	File        string  `json:"file,omitempty"`
+	Title       string  `json:"title"`
+	Status      string  `json:"status"`
+	CompletedAt *string `json:"completed_at"`
+}
+
+// HistoryEntry is one audit-trail event
+type HistoryEntry struct {
+	Timestamp string `json:"timestamp"`
+	Stage     string `json:"stage"`
+	Action    string `json:"action"` // started, completed, skipped, revision-requested, ...
+	From      string `json:"from,omitempty"` // stage status before a transition
+	To        string `json:"to,omitempty"`   // stage status after a transition
+	Agent     string `json:"agent,omitempty"`
+	Verdict   string `json:"verdict,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Path returns the status.json path of a feature under a repository root
+func Path(root, feature string) string {
+	return filepath.Join(root, ".claude", "feature", feature, "status.json")
+}
+
+// Now returns the current time in the RFC3339 format used by status.json
+func Now() string {
+	return time.Now().Format(time.RFC3339)
+}
+
+// Load reads and parses a status.json file in either layout
+func Load(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	status, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return status, nil
+}
+
+// Parse decodes status.json data in either layout into the canonical model
+func Parse(data []byte) (*Status, error) {
+	status := &Status{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Save writes the status atomically in the canonical layout
+func Save(path string, status *Status) error {
+	return WriteJSON(path, status)
+}
+
+// WriteJSON atomically writes v as indented JSON
+func WriteJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal JSON: %w", err)
+	}
+	data = append(data, '\n')
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", dir, err)
+	}
+
+	// A unique temp file per writer, so concurrent writers never share one
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write temp file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot rename temp file: %w", err)
+	}
+	return nil
+}
+
+// StageNumber returns the numeric prefix of a stage ID such as "5-tech-spec"
+// Returns -1 if the stage has no numeric prefix
+func StageNumber(stage string) int {
+	prefix, _, _ := strings.Cut(stage, "-")
+	n, err := strconv.Atoi(prefix)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// StageIDs returns the stage IDs in pipeline order
+func (s *Status) StageIDs() []string {
+	ids := make([]string, 0, len(s.Stages))
+	for id := range s.Stages {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ni, nj := StageNumber(ids[i]), StageNumber(ids[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Stage returns the named stage or an error if the pipeline has no such stage
+func (s *Status) Stage(id string) (*Stage, error) {
+	stage, ok := s.Stages[id]
+	if !ok || stage == nil {
+		return nil, fmt.Errorf("unknown stage: %s", id)
+	}
+	return stage, nil
+}
+
+// Finished reports whether every stage is complete or skipped
+func (s *Status) Finished() bool {
+	if len(s.Stages) == 0 {
+		return false
+	}
+	for _, stage := range s.Stages {
+		if stage.Status != StatusComplete && stage.Status != StatusSkipped {
+			return false
+		}
+	}
+	return true
+}
+
+// SettlePipelineStatus derives the pipeline status after a stage changed
+// A finished pipeline is complete and a reopened one in progress; any other
+// status, such as blocked, is kept until an update changes it explicitly
+func (s *Status) SettlePipelineStatus() {
+	switch {
+	case s.Finished():
+		s.PipelineStatus = StatusComplete
+	case s.PipelineStatus == "" || s.PipelineStatus == StatusComplete:
+		s.PipelineStatus = StatusInProgress
+	}
+}
+
+// SetDocument records doc as the stage's primary document
+// A document already listed is moved to the front rather than duplicated
+func (st *Stage) SetDocument(doc string) {
+	docs := []string{doc}
+	for _, d := range st.Documents {
+		if d != doc {
+			docs = append(docs, d)
+		}
+	}
+	st.Documents = docs
+}
+
+// Document returns the stage's primary document, or "" if it has none
+func (st *Stage) Document() string {
+	if len(st.Documents) == 0 {
+		return ""
+	}
+	return st.Documents[0]
+}
+
+// --- JSON encoding ---
+
+type statusJSON Status
+
+// UnmarshalJSON accepts both the canonical "stages"/"current_stage" layout
+// and the legacy "pipeline"/"stage" layout written by older binaries
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if _, hasStages := raw["stages"]; !hasStages {
+		if legacy, ok := raw["pipeline"]; ok {
+			raw["stages"] = legacy
+			s.Legacy = true
+		}
+	}
+	if _, ok := raw["current_stage"]; !ok {
+		if legacy, ok := raw["stage"]; ok {
+			raw["current_stage"] = legacy
+			s.Legacy = true
+		}
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	aux := (*statusJSON)(s)
+	if err := json.Unmarshal(normalized, aux); err != nil {
+		return err
+	}
+
+	// Legacy files kept stage documents in a top-level map
+	var docs map[string]string
+	if d, ok := raw["documents"]; ok && json.Unmarshal(d, &docs) == nil {
+		for id, doc := range docs {
+			if stage := s.Stages[id]; stage != nil && doc != "" {
+				stage.SetDocument(doc)
+			}
+		}
+		delete(raw, "documents")
+	}
+
+	if s.Legacy {
+		// The legacy writer used "blocked" for stages that had not started yet
+		for _, stage := range s.Stages {
+			if stage != nil && stage.Status == StatusBlocked {
+				stage.Status = StatusPending
+			}
+		}
+		if s.PipelineStatus == "" {
+			s.PipelineStatus = StatusInProgress
+			if s.Finished() {
+				s.PipelineStatus = StatusComplete
+			}
+		}
+	}
+	for id, stage := range s.Stages {
+		if stage == nil {
+			delete(s.Stages, id)
+		}
+	}
+
+	delete(raw, "pipeline")
+	delete(raw, "stage")
+	s.Extra = extraFields(raw, reflect.TypeOf(Status{}))
+	return nil
+}
+
+// MarshalJSON writes the canonical layout followed by any preserved fields
+func (s *Status) MarshalJSON() ([]byte, error) {
+	aux := *s
+	if aux.Stages == nil {
+		aux.Stages = map[string]*Stage{}
+	}
+	if aux.History == nil {
+		aux.History = []*HistoryEntry{}
+	}
+	data, err := json.Marshal((*statusJSON)(&aux))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(data, s.Extra)
+}
+
+type stageJSON Stage
+
+// UnmarshalJSON accepts the legacy "assignee", single "document" and
+// {"items": [...]} task fields
+func (st *Stage) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var legacyTasks []*Task
+	if t, ok := raw["tasks"]; ok && bytes.HasPrefix(bytes.TrimSpace(t), []byte("{")) {
+		var err error
+		if legacyTasks, err = parseLegacyTasks(t); err != nil {
+			return err
+		}
+		delete(raw, "tasks")
+		if data, err = json.Marshal(raw); err != nil {
+			return err
+		}
+	}
+
+	if err := json.Unmarshal(data, (*stageJSON)(st)); err != nil {
+		return err
+	}
+	if legacyTasks != nil {
+		st.Tasks = legacyTasks
+	}
+
+	if st.Agent == "" {
+		var assignee string
+		if a, ok := raw["assignee"]; ok && json.Unmarshal(a, &assignee) == nil {
+			st.Agent = assignee
+		}
+	}
+	var doc string
+	if d, ok := raw["document"]; ok && json.Unmarshal(d, &doc) == nil && doc != "" {
+		st.SetDocument(doc)
+	}
+
+	delete(raw, "assignee")
+	delete(raw, "document")
+	st.Extra = extraFields(raw, reflect.TypeOf(Stage{}))
+	return nil
+}
+
+// MarshalJSON writes the stage followed by any preserved fields
+func (st *Stage) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*stageJSON)(st))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(data, st.Extra)
+}
+
+type historyJSON HistoryEntry
+
+// UnmarshalJSON keeps fields written by agents that are not modelled
+func (h *HistoryEntry) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*historyJSON)(h)); err != nil {
+		return err
+	}
+	h.Extra = extraFields(raw, reflect.TypeOf(HistoryEntry{}))
+	return nil
+}
+
+// MarshalJSON writes the entry followed by any preserved fields
+func (h *HistoryEntry) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*historyJSON)(h))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(data, h.Extra)
+}
+
+// extraFields returns the raw fields that do not map to a JSON tag of t
+func extraFields(raw map[string]json.RawMessage, t reflect.Type) map[string]json.RawMessage {
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		delete(raw, name)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	return raw
+}
+
+// appendExtra splices preserved fields into an encoded JSON object, sorted by key
+func appendExtra(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(bytes.TrimSpace(data), []byte("}")))
+	for i, k := range keys {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
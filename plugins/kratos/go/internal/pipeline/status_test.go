@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyStatus is a status.json in the layout written by older binaries
+const legacyStatus = `{
+  "feature": "auth",
+  "description": "Login flow",
+  "priority": "P1",
+  "created": "2026-01-01T10:00:00Z",
+  "updated": "2026-01-02T10:00:00Z",
+  "stage": "2-prd-review",
+  "pipeline": {
+    "1-prd": {"status": "complete", "assignee": "athena", "started": "2026-01-01T10:00:00Z", "completed": "2026-01-01T12:00:00Z", "document": "prd.md"},
+    "2-prd-review": {"status": "in-progress", "assignee": "athena", "started": null, "completed": null, "document": "prd-review.md",
+      "gate": {"requires": ["1-prd"], "condition": "prd.status === 'complete'"}},
+    "5-tech-spec": {"status": "blocked", "assignee": "hephaestus", "document": "tech-spec.md"}
+  },
+  "documents": {"1-prd": "docs/prd-v2.md"},
+  "history": [{"timestamp": "2026-01-01T12:00:00Z", "stage": "1-prd", "action": "status changed from 'in-progress' to 'complete'"}]
+}`
+
+// TestParse_Legacy tests reading the legacy "pipeline"/"stage" layout
+func TestParse_Legacy(t *testing.T) {
+	status, err := Parse([]byte(legacyStatus))
+	require.NoError(t, err)
+
+	assert.True(t, status.Legacy)
+	assert.Equal(t, "2-prd-review", status.CurrentStage)
+	assert.Equal(t, StatusInProgress, status.PipelineStatus)
+	require.Len(t, status.Stages, 3)
+
+	prd := status.Stages["1-prd"]
+	assert.Equal(t, "athena", prd.Agent)
+	assert.Equal(t, []string{"docs/prd-v2.md", "prd.md"}, prd.Documents, "recorded document becomes primary")
+	assert.Equal(t, "2026-01-01T12:00:00Z", prd.Completed)
+
+	review := status.Stages["2-prd-review"]
+	require.NotNil(t, review.Gate)
+	assert.Equal(t, []string{"1-prd"}, review.Gate.Requires)
+	assert.Empty(t, review.Started)
+
+	assert.Equal(t, StatusPending, status.Stages["5-tech-spec"].Status, "legacy blocked means not started")
+	require.Len(t, status.History, 1)
+	assert.Equal(t, "1-prd", status.History[0].Stage)
+}
+
+// TestMarshal_Canonical tests that a legacy file is written back in the canonical layout
+func TestMarshal_Canonical(t *testing.T) {
+	status, err := Parse([]byte(legacyStatus))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(status)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Contains(t, raw, "stages")
+	assert.Contains(t, raw, "current_stage")
+	assert.NotContains(t, raw, "pipeline")
+	assert.NotContains(t, raw, "stage")
+	assert.NotContains(t, raw, "documents")
+
+	prd := raw["stages"].(map[string]interface{})["1-prd"].(map[string]interface{})
+	assert.Equal(t, "athena", prd["agent"])
+	assert.NotContains(t, prd, "assignee")
+	assert.NotContains(t, prd, "document")
+
+	// Re-parsing the canonical output is not legacy
+	again, err := Parse(data)
+	require.NoError(t, err)
+	assert.False(t, again.Legacy)
+	assert.Equal(t, status.Stages["1-prd"].Documents, again.Stages["1-prd"].Documents)
+}
+
+// TestParse_PreservesUnknownFields tests that fields agents add by hand survive a rewrite
+func TestParse_PreservesUnknownFields(t *testing.T) {
+	input := `{
+		"feature": "auth",
+		"current_stage": "10-prd-alignment",
+		"custom_flag": true,
+		"stages": {
+			"5-tech-spec": {"status": "complete", "based_on_prd_version": "2026-01-01T12:00:00Z"},
+			"10-prd-alignment": {"status": "in-progress", "alignment_verdict": "gaps", "coverage_pct": 80}
+		},
+		"history": [{"timestamp": "t", "stage": "1-prd", "action": "started", "session_id": "abc"}]
+	}`
+
+	status, err := Parse([]byte(input))
+	require.NoError(t, err)
+	assert.False(t, status.Legacy)
+
+	data, err := json.Marshal(status)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, true, raw["custom_flag"])
+
+	stages := raw["stages"].(map[string]interface{})
+	spec := stages["5-tech-spec"].(map[string]interface{})
+	assert.Equal(t, "2026-01-01T12:00:00Z", spec["based_on_prd_version"])
+	alignment := stages["10-prd-alignment"].(map[string]interface{})
+	assert.Equal(t, "gaps", alignment["alignment_verdict"])
+	assert.Equal(t, float64(80), alignment["coverage_pct"])
+
+	history := raw["history"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "abc", history["session_id"])
+}
+
+// TestNew tests the default pipeline
+func TestNew(t *testing.T) {
+	status := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+
+	assert.Equal(t, "1-prd", status.CurrentStage)
+	assert.Equal(t, StatusInProgress, status.PipelineStatus)
+	require.Len(t, status.Stages, 11)
+
+	ids := status.StageIDs()
+	assert.Equal(t, "1-prd", ids[0])
+	assert.Equal(t, "11-review", ids[10])
+
+	prd := status.Stages["1-prd"]
+	assert.Equal(t, StatusInProgress, prd.Status)
+	assert.Equal(t, "2026-01-01T10:00:00Z", prd.Started)
+	assert.Nil(t, prd.Gate)
+
+	assert.Equal(t, StatusSkipped, status.Stages["4-discuss"].Status)
+	assert.True(t, status.Stages["4-discuss"].Optional)
+	assert.Equal(t, StatusPending, status.Stages["8-test-plan"].Status)
+	assert.Equal(t, []string{"6-spec-review-pm", "7-spec-review-sa"}, status.Stages["8-test-plan"].Gate.Requires)
+	assert.Equal(t, "code-review.md", status.Stages["11-review"].Document())
+}
+
+// TestSaveLoad tests the atomic write and read round trip
+func TestSaveLoad(t *testing.T) {
+	path := Path(t.TempDir(), "auth")
+	status := New("auth", "Login flow", "P1", Now())
+
+	require.NoError(t, Save(path, status))
+	_, err := os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, status.Feature, loaded.Feature)
+	assert.Equal(t, status.StageIDs(), loaded.StageIDs())
+}
+
+// TestStageNumber tests parsing stage ID prefixes
+func TestStageNumber(t *testing.T) {
+	assert.Equal(t, 1, StageNumber("1-prd"))
+	assert.Equal(t, 11, StageNumber("11-review"))
+	assert.Equal(t, -1, StageNumber("review"))
+}
+
+// TestSetDocument tests that the recorded document becomes primary without duplicates
+func TestSetDocument(t *testing.T) {
+	stage := &Stage{Documents: []string{"prd.md"}}
+	stage.SetDocument("prd-v2.md")
+	stage.SetDocument("prd-v2.md")
+	assert.Equal(t, []string{"prd-v2.md", "prd.md"}, stage.Documents)
+	assert.Equal(t, "prd-v2.md", stage.Document())
+}
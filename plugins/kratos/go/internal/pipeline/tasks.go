@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TaskStage is the stage that carries user-mode implementation tasks
+const TaskStage = "9-implementation"
+
+// taskFilePattern matches task files such as tasks/03-auth-middleware.md
+var taskFilePattern = regexp.MustCompile(`^(\d+)-.+\.md$`)
+
+// Task returns the stage's task with the given ID
+func (st *Stage) Task(id string) (*Task, error) {
+	for _, task := range st.Tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown task: %s", id)
+}
+
+// AddTask appends a task, assigning the next free ID when it has none
+func (st *Stage) AddTask(task *Task) error {
+	if task.ID == "" {
+		task.ID = st.NextTaskID()
+	}
+	if _, err := st.Task(task.ID); err == nil {
+		return fmt.Errorf("task %s already exists", task.ID)
+	}
+	if task.Status == "" {
+		task.Status = StatusPending
+	}
+	st.Tasks = append(st.Tasks, task)
+	sort.SliceStable(st.Tasks, func(i, j int) bool {
+		return taskNumber(st.Tasks[i].ID) < taskNumber(st.Tasks[j].ID)
+	})
+	return nil
+}
+
+// NextTaskID returns the two-digit ID following the highest numeric task ID
+func (st *Stage) NextTaskID() string {
+	next := 1
+	for _, task := range st.Tasks {
+		if n := taskNumber(task.ID); n >= next {
+			next = n + 1
+		}
+	}
+	return fmt.Sprintf("%02d", next)
+}
+
+// SetTaskStatus moves a task to status, stamping completed_at when it completes
+// Completing an already complete task keeps its original timestamp
+func (st *Stage) SetTaskStatus(id, status, ts string) (*Task, error) {
+	task, err := st.Task(id)
+	if err != nil {
+		return nil, err
+	}
+	if status == StatusComplete {
+		if task.Status != StatusComplete || task.CompletedAt == nil {
+			task.CompletedAt = &ts
+		}
+	} else {
+		task.CompletedAt = nil
+	}
+	task.Status = status
+	return task, nil
+}
+
+// TasksDone reports whether the stage has tasks and all of them are complete
+func (st *Stage) TasksDone() bool {
+	progress := st.TaskProgress()
+	return progress.Total > 0 && progress.Complete == progress.Total
+}
+
+// CompleteStage marks stage id complete at ts and records it in history
+func (s *Status) CompleteStage(id, ts, notes string) error {
+	stage, err := s.Stage(id)
+	if err != nil {
+		return err
+	}
+
+	from := stage.Status
+	stage.Status = StatusComplete
+	stage.Completed = ts
+	if stage.Started == "" {
+		stage.Started = ts
+	}
+	s.Updated = ts
+	s.CurrentStage = id
+	s.SettlePipelineStatus()
+
+	s.History = append(s.History, &HistoryEntry{
+		Timestamp: ts,
+		Stage:     id,
+		Action:    "completed",
+		From:      from,
+		To:        StatusComplete,
+		Agent:     stage.Agent,
+		Notes:     notes,
+	})
+	return nil
+}
+
+// FindTaskFile returns the tasks/NN-*.md file of task id relative to the feature folder
+// Returns "" if no such file exists
+func FindTaskFile(dir, id string) string {
+	n := taskNumber(id)
+	if n < 0 {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "tasks", "*.md"))
+	sort.Strings(matches)
+	for _, match := range matches {
+		m := taskFilePattern.FindStringSubmatch(filepath.Base(match))
+		if m == nil {
+			continue
+		}
+		if num, _ := strconv.Atoi(m[1]); num == n {
+			return "tasks/" + filepath.Base(match)
+		}
+	}
+	return ""
+}
+
+// ScanTasks returns a pending task for every tasks/NN-*.md file in the feature folder
+// tasks/00-overview.md is the task index, not a task. Titles come from each
+// file's first heading, falling back to the file name
+func ScanTasks(dir string) []*Task {
+	matches, _ := filepath.Glob(filepath.Join(dir, "tasks", "*.md"))
+	sort.Strings(matches)
+
+	var tasks []*Task
+	for _, match := range matches {
+		m := taskFilePattern.FindStringSubmatch(filepath.Base(match))
+		if m == nil || taskNumber(m[1]) == 0 {
+			continue
+		}
+		tasks = append(tasks, &Task{
+			ID:     m[1],
+			File:   "tasks/" + filepath.Base(match),
+			Title:  taskTitle(match),
+			Status: StatusPending,
+		})
+	}
+	return tasks
+}
+
+// taskTitle returns the first markdown heading of a task file
+func taskTitle(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".md")
+	if _, rest, ok := strings.Cut(name, "-"); ok {
+		name = strings.ReplaceAll(rest, "-", " ")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return name
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			if title := strings.TrimSpace(strings.TrimLeft(line, "#")); title != "" {
+				return title
+			}
+		}
+	}
+	return name
+}
+
+// taskNumber returns the numeric value of a task ID, or -1 if it is not numeric
+func taskNumber(id string) int {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// legacyTasks is the {"total", "completed", "items"} task object older agents wrote
+type legacyTasks struct {
+	Items []struct {
+		ID          string  `json:"id"`
+		Name        string  `json:"name"`
+		Title       string  `json:"title"`
+		File        string  `json:"file"`
+		Status      string  `json:"status"`
+		CompletedAt *string `json:"completed_at"`
+	} `json:"items"`
+}
+
+// parseLegacyTasks converts the legacy task object into the task array
+// Legacy file names were relative to the tasks folder
+func parseLegacyTasks(data json.RawMessage) ([]*Task, error) {
+	var legacy legacyTasks
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	tasks := []*Task{}
+	for _, item := range legacy.Items {
+		task := &Task{
+			ID:          item.ID,
+			File:        item.File,
+			Title:       item.Title,
+			Status:      item.Status,
+			CompletedAt: item.CompletedAt,
+		}
+		if task.Title == "" {
+			task.Title = item.Name
+		}
+		if task.File != "" && !strings.Contains(task.File, "/") {
+			task.File = "tasks/" + task.File
+		}
+		if task.Status == "" {
+			task.Status = StatusPending
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
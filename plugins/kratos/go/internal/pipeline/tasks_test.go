@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTask(t *testing.T) {
+	st := &Stage{Status: StatusPending}
+
+	require.NoError(t, st.AddTask(&Task{Title: "Schema"}))
+	require.NoError(t, st.AddTask(&Task{ID: "05", Title: "Docs"}))
+	require.NoError(t, st.AddTask(&Task{ID: "03", Title: "Handler"}))
+	require.NoError(t, st.AddTask(&Task{Title: "Tests"}))
+
+	var ids []string
+	for _, task := range st.Tasks {
+		ids = append(ids, task.ID)
+		assert.Equal(t, StatusPending, task.Status)
+	}
+	assert.Equal(t, []string{"01", "03", "05", "06"}, ids)
+
+	err := st.AddTask(&Task{ID: "03", Title: "Duplicate"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "task 03 already exists")
+}
+
+func TestSetTaskStatus(t *testing.T) {
+	st := &Stage{}
+	require.NoError(t, st.AddTask(&Task{Title: "Schema"}))
+
+	task, err := st.SetTaskStatus("01", StatusComplete, "2026-01-01T10:00:00Z")
+	require.NoError(t, err)
+	require.NotNil(t, task.CompletedAt)
+
+	// Completing again keeps the first timestamp
+	task, err = st.SetTaskStatus("01", StatusComplete, "2026-01-02T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01-01T10:00:00Z", *task.CompletedAt)
+	assert.True(t, st.TasksDone())
+
+	task, err = st.SetTaskStatus("01", StatusInProgress, "2026-01-03T10:00:00Z")
+	require.NoError(t, err)
+	assert.Nil(t, task.CompletedAt)
+	assert.False(t, st.TasksDone())
+
+	_, err = st.SetTaskStatus("99", StatusComplete, "2026-01-03T10:00:00Z")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown task: 99")
+}
+
+func TestScanTasksAndFindTaskFile(t *testing.T) {
+	dir := t.TempDir()
+	tasks := filepath.Join(dir, "tasks")
+	require.NoError(t, os.MkdirAll(tasks, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tasks, "00-overview.md"), []byte("# Overview"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tasks, "01-user-model.md"), []byte("\n# Task 01: Create user model\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tasks, "02-login-endpoint.md"), []byte("no heading"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tasks, "notes.md"), []byte("# Notes"), 0o644))
+
+	scanned := ScanTasks(dir)
+	require.Len(t, scanned, 2)
+	assert.Equal(t, &Task{ID: "01", File: "tasks/01-user-model.md", Title: "Task 01: Create user model", Status: StatusPending}, scanned[0])
+	assert.Equal(t, "login endpoint", scanned[1].Title)
+
+	assert.Equal(t, "tasks/02-login-endpoint.md", FindTaskFile(dir, "2"))
+	assert.Empty(t, FindTaskFile(dir, "07"))
+	assert.Empty(t, FindTaskFile(dir, "abc"))
+}
+
+func TestCompleteStage(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+
+	require.NoError(t, s.CompleteStage("9-implementation", "2026-01-02T10:00:00Z", "all 2 tasks complete"))
+	impl := s.Stages["9-implementation"]
+	assert.Equal(t, StatusComplete, impl.Status)
+	assert.Equal(t, "2026-01-02T10:00:00Z", impl.Started)
+	assert.Equal(t, "9-implementation", s.CurrentStage)
+	require.Len(t, s.History, 1)
+	assert.Equal(t, "completed", s.History[0].Action)
+
+	require.Error(t, s.CompleteStage("99-nope", "2026-01-02T10:00:00Z", ""))
+}
+
+func TestParse_LegacyTaskObject(t *testing.T) {
+	s, err := Parse([]byte(`{
+  "feature": "auth",
+  "stages": {
+    "9-implementation": {
+      "status": "in-progress",
+      "mode": "user",
+      "tasks": {"total": 2, "completed": 1, "items": [
+        {"id": "01", "name": "Create user model", "file": "01-create-user-model.md", "status": "complete"},
+        {"id": "02", "name": "Add migrations", "file": "tasks/02-add-migrations.md", "status": "pending"}
+      ]}
+    }
+  }
+}`))
+	require.NoError(t, err)
+
+	tasks := s.Stages["9-implementation"].Tasks
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "Create user model", tasks[0].Title)
+	assert.Equal(t, "tasks/01-create-user-model.md", tasks[0].File)
+	assert.Equal(t, "tasks/02-add-migrations.md", tasks[1].File)
+	assert.Empty(t, s.Stages["9-implementation"].Extra)
+}
@@ -0,0 +1,275 @@
+package pipeline
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTemplate is the name of the built-in 11-stage pipeline
+const DefaultTemplate = "default"
+
+// SourceBuiltin marks templates compiled into the binary
+const SourceBuiltin = "built-in"
+
+// Execution modes a template or feature may select
+var modes = []string{"normal", "eco", "power"}
+
+// templateFS embeds the built-in templates other than the default pipeline
+//
+//go:embed templates/*.json
+var templateFS embed.FS
+
+// Template is a named pipeline definition
+type Template struct {
+	Name        string           `json:"name" yaml:"name"`
+	Description string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Mode        string           `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Stages      []*StageTemplate `json:"stages" yaml:"stages"`
+
+	// Source is SourceBuiltin or the file the template was read from
+	Source string `json:"source" yaml:"-"`
+}
+
+// StageTemplate defines one stage of a pipeline template
+type StageTemplate struct {
+	ID        string   `json:"id" yaml:"id"`
+	Agent     string   `json:"agent" yaml:"agent"`
+	Documents []string `json:"documents,omitempty" yaml:"documents,omitempty"`
+	Optional  bool     `json:"optional,omitempty" yaml:"optional,omitempty"`
+	Requires  []string `json:"requires,omitempty" yaml:"requires,omitempty"`
+	Condition string   `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Inputs    []string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Verdicts  []string `json:"verdicts,omitempty" yaml:"verdicts,omitempty"` // passing verdicts of a review stage
+	Revises   string   `json:"revises,omitempty" yaml:"revises,omitempty"`   // stage a failing verdict reopens
+}
+
+// TemplateDir returns the project pipeline template directory under a repository root
+func TemplateDir(root string) string {
+	return filepath.Join(root, ".claude", "kratos", "pipelines")
+}
+
+// Templates returns the built-in templates overridden by the project's, sorted by name
+func Templates(root string) ([]*Template, error) {
+	byName := map[string]*Template{DefaultTemplate: defaultTemplate()}
+
+	builtin, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in templates: %w", err)
+	}
+	for _, entry := range builtin {
+		path := "templates/" + entry.Name()
+		data, err := templateFS.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in template %s: %w", path, err)
+		}
+		t, err := ParseTemplate(data, path)
+		if err != nil {
+			return nil, err
+		}
+		t.Source = SourceBuiltin
+		byName[t.Name] = t
+	}
+
+	files, err := filepath.Glob(filepath.Join(TemplateDir(root), "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline templates: %w", err)
+	}
+	for _, path := range files {
+		switch filepath.Ext(path) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+		t, err := ParseTemplate(data, path)
+		if err != nil {
+			return nil, err
+		}
+		t.Source = path
+		byName[t.Name] = t
+	}
+
+	templates := make([]*Template, 0, len(byName))
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// LoadTemplate returns the named template, preferring the project's over the built-ins
+func LoadTemplate(root, name string) (*Template, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	templates, err := Templates(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+	return nil, fmt.Errorf("unknown pipeline template: %s (available: %s)", name, strings.Join(names, ", "))
+}
+
+// ParseTemplate decodes and validates a JSON or YAML template
+// path selects the format by extension and names the template when it has no name
+func ParseTemplate(data []byte, path string) (*Template, error) {
+	t := &Template{}
+	var err error
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, t)
+	default:
+		err = json.Unmarshal(data, t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse template %s: %w", path, err)
+	}
+
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Validate checks that stage IDs are numbered in order and every reference resolves
+// Verdicts are normalized so they compare equal to recorded ones
+func (t *Template) Validate() error {
+	if len(t.Stages) == 0 {
+		return fmt.Errorf("no stages")
+	}
+	if t.Mode != "" && !contains(modes, t.Mode) {
+		return fmt.Errorf("invalid mode: %s (use %s)", t.Mode, strings.Join(modes, ", "))
+	}
+	if t.Stages[0].Optional {
+		return fmt.Errorf("first stage %s cannot be optional", t.Stages[0].ID)
+	}
+
+	seen := map[string]bool{}
+	last := 0
+	for _, st := range t.Stages {
+		n := StageNumber(st.ID)
+		switch {
+		case st.ID == "":
+			return fmt.Errorf("stage after %d has no id", last)
+		case n <= 0:
+			return fmt.Errorf("stage %s must start with its number, e.g. 1-%s", st.ID, st.ID)
+		case n <= last:
+			return fmt.Errorf("stage %s is out of order", st.ID)
+		case st.Agent == "":
+			return fmt.Errorf("stage %s has no agent", st.ID)
+		}
+
+		for _, ref := range append(append([]string{}, st.Requires...), st.Inputs...) {
+			if !seen[ref] {
+				return fmt.Errorf("stage %s refers to %s, which is not an earlier stage", st.ID, ref)
+			}
+		}
+		if st.Revises != "" && !seen[st.Revises] {
+			return fmt.Errorf("stage %s revises %s, which is not an earlier stage", st.ID, st.Revises)
+		}
+		for i, verdict := range st.Verdicts {
+			st.Verdicts[i] = NormalizeVerdict(verdict)
+		}
+
+		seen[st.ID] = true
+		last = n
+	}
+	return nil
+}
+
+// Instantiate returns the status of a feature freshly initialized from the template
+// The first stage starts in progress at ts; optional stages start skipped.
+// Features of the built-in default pipeline keep relying on its compiled-in
+// rules; every other template records its inputs, verdicts and revision
+// targets in the stages so the feature stays self-describing.
+func (t *Template) Instantiate(feature, description, priority, ts string) *Status {
+	status := &Status{
+		Feature:        feature,
+		Description:    description,
+		Priority:       priority,
+		Created:        ts,
+		Updated:        ts,
+		Mode:           t.Mode,
+		PipelineStatus: StatusInProgress,
+		Stages:         map[string]*Stage{},
+		History:        []*HistoryEntry{},
+	}
+	builtin := t.Name == DefaultTemplate && t.Source == SourceBuiltin
+	if !builtin {
+		status.Template = t.Name
+	}
+
+	for i, def := range t.Stages {
+		stage := &Stage{
+			Status:    StatusPending,
+			Agent:     def.Agent,
+			Documents: append([]string(nil), def.Documents...),
+			Optional:  def.Optional,
+		}
+		if def.Optional {
+			stage.Status = StatusSkipped
+		}
+		if def.Requires != nil {
+			stage.Gate = &Gate{Requires: def.Requires, Condition: def.Condition}
+		}
+		if !builtin {
+			stage.Inputs = def.Inputs
+			stage.Verdicts = def.Verdicts
+			stage.Revises = def.Revises
+		}
+		if i == 0 {
+			stage.Status = StatusInProgress
+			stage.Started = ts
+			status.CurrentStage = def.ID
+		}
+		status.Stages[def.ID] = stage
+	}
+
+	return status
+}
+
+// defaultTemplate describes the compiled-in 11-stage pipeline as a template
+func defaultTemplate() *Template {
+	t := &Template{
+		Name:        DefaultTemplate,
+		Description: "Full 11-stage pipeline from PRD to code review",
+		Source:      SourceBuiltin,
+	}
+	for _, def := range defaultStages {
+		st := &StageTemplate{
+			ID:        def.id,
+			Agent:     def.agent,
+			Documents: def.documents,
+			Optional:  def.optional,
+			Requires:  def.requires,
+			Condition: def.condition,
+			Inputs:    def.inputs,
+			Revises:   revisionTargets[def.id],
+		}
+		if rules := verdictRules[def.id]; len(rules) > 0 {
+			st.Verdicts = rules[0].pass
+		}
+		t.Stages = append(t.Stages, st)
+	}
+	return t
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_BuiltinAndProject(t *testing.T) {
+	root := t.TempDir()
+
+	templates, err := Templates(root)
+	require.NoError(t, err)
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+		assert.Equal(t, SourceBuiltin, tmpl.Source)
+	}
+	assert.Equal(t, []string{"bugfix", "default", "spike"}, names)
+
+	dir := TemplateDir(root)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hotfix.yaml"), []byte(`
+description: Ship a one-line fix
+stages:
+  - id: 1-fix
+    agent: ares
+    documents: [implementation-notes.md]
+  - id: 2-review
+    agent: hermes
+    requires: [1-fix]
+    inputs: [1-fix]
+    verdicts: [Approved]
+    revises: 1-fix
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bugfix.json"), []byte(`{"name": "bugfix", "stages": [{"id": "1-fix", "agent": "ares"}]}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# ignored"), 0o644))
+
+	hotfix, err := LoadTemplate(root, "hotfix")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "hotfix.yaml"), hotfix.Source)
+	assert.Equal(t, []string{"approved"}, hotfix.Stages[1].Verdicts)
+
+	// Project templates override built-ins of the same name
+	bugfix, err := LoadTemplate(root, "bugfix")
+	require.NoError(t, err)
+	assert.Len(t, bugfix.Stages, 1)
+
+	_, err = LoadTemplate(root, "nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown pipeline template: nope (available: bugfix, default, hotfix, spike)")
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	tests := map[string]string{
+		`{"stages": []}`: "no stages",
+		`{"stages": [{"id": "prd", "agent": "athena"}]}`:                                              "must start with its number",
+		`{"stages": [{"id": "1-a", "agent": "x"}, {"id": "1-b", "agent": "y"}]}`:                      "out of order",
+		`{"stages": [{"id": "1-a"}]}`:                                                                 "has no agent",
+		`{"stages": [{"id": "1-a", "agent": "x", "optional": true}]}`:                                 "cannot be optional",
+		`{"stages": [{"id": "1-a", "agent": "x", "requires": ["2-b"]}, {"id": "2-b", "agent": "y"}]}`: "refers to 2-b",
+		`{"stages": [{"id": "1-a", "agent": "x"}, {"id": "2-b", "agent": "y", "revises": "9-z"}]}`:    "revises 9-z",
+		`{"mode": "turbo", "stages": [{"id": "1-a", "agent": "x"}]}`:                                  "invalid mode: turbo",
+	}
+	for data, want := range tests {
+		_, err := ParseTemplate([]byte(data), "custom.json")
+		require.Error(t, err, data)
+		assert.Contains(t, err.Error(), want, data)
+		assert.Contains(t, err.Error(), "invalid template custom.json", data)
+	}
+}
+
+func TestInstantiate_Template(t *testing.T) {
+	bugfix, err := LoadTemplate(t.TempDir(), "bugfix")
+	require.NoError(t, err)
+
+	s := bugfix.Instantiate("login-crash", "Crash on login", "P0", "2026-01-01T10:00:00Z")
+	assert.Equal(t, "bugfix", s.Template)
+	assert.Equal(t, []string{"1-diagnosis", "2-test-plan", "3-fix", "4-review"}, s.StageIDs())
+	assert.Equal(t, StatusInProgress, s.Stages["1-diagnosis"].Status)
+	assert.Equal(t, StatusSkipped, s.Stages["2-test-plan"].Status)
+	assert.Equal(t, "1-diagnosis", s.CurrentStage)
+
+	// Template stages drive gates, routing and revisions
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-diagnosis": ""})
+	next := Next(s)
+	assert.Equal(t, "3-fix", next.Stage)
+	assert.Equal(t, "ares", next.Agent)
+	assert.Equal(t, []string{"diagnosis.md"}, next.Inputs)
+
+	finish(s, "2026-01-03T10:00:00Z", map[string]string{"3-fix": "", "4-review": "changes-requested"})
+	assert.Equal(t, "changes-requested", s.FailedVerdict("4-review"))
+	entry := s.Revise("4-review", "2026-01-04T10:00:00Z")
+	require.NotNil(t, entry)
+	assert.Equal(t, StatusInProgress, s.Stages["3-fix"].Status)
+	assert.Equal(t, StatusPending, s.Stages["4-review"].Status)
+
+	spike, err := LoadTemplate(t.TempDir(), "spike")
+	require.NoError(t, err)
+	assert.Equal(t, "eco", spike.Instantiate("cache", "Evaluate caches", "P3", "2026-01-01T10:00:00Z").Mode)
+
+	// Stage IDs shared with the default pipeline do not pick up its rules
+	custom, err := ParseTemplate([]byte(`{"stages": [{"id": "1-prd", "agent": "athena"}, {"id": "2-prd-review", "agent": "athena", "requires": ["1-prd"]}]}`), "lite.json")
+	require.NoError(t, err)
+	s = custom.Instantiate("lite", "No review gate", "P3", "2026-01-01T10:00:00Z")
+	finish(s, "2026-01-02T10:00:00Z", map[string]string{"1-prd": "", "2-prd-review": "revisions"})
+	assert.Empty(t, s.FailedVerdict("2-prd-review"))
+	assert.Empty(t, s.RevisionTarget("2-prd-review", "revisions"))
+	assert.Empty(t, s.Inputs("2-prd-review"))
+}
+
+func TestNew_DefaultTemplateUnchanged(t *testing.T) {
+	s := New("auth", "Login flow", "P1", "2026-01-01T10:00:00Z")
+	assert.Empty(t, s.Template)
+	assert.Len(t, s.Stages, len(defaultStages))
+	for _, stage := range s.Stages {
+		assert.Nil(t, stage.Inputs)
+		assert.Nil(t, stage.Verdicts)
+	}
+
+	// The default pipeline listed as a template shows its compiled-in rules
+	def := defaultTemplate()
+	assert.Equal(t, "1-prd", def.Stages[1].Revises)
+	assert.Equal(t, []string{"approved", "approved-with-comments"}, def.Stages[1].Verdicts)
+}
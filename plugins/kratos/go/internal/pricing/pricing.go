@@ -0,0 +1,100 @@
+// Package pricing converts agent token usage into USD cost
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+)
+
+// Price is the USD cost of one million tokens of each kind
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// Table maps a model name, or a fragment of it such as a family, to its price
+type Table map[string]Price
+
+// Default returns the built-in prices of the Claude model families
+// Agents record the short family name (opus, sonnet, haiku); full model IDs
+// match their family unless a more specific entry exists
+func Default() Table {
+	return Table{
+		"opus":             {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"claude-opus-4-5":  {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+		"sonnet":           {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"haiku":            {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+		"claude-haiku-4-5": {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+	}
+}
+
+// Path returns the pricing override file
+// Checks KRATOS_PRICING env var, defaults to ~/.kratos/pricing.json
+func Path() string {
+	if path := os.Getenv("KRATOS_PRICING"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kratos", "pricing.json")
+}
+
+// Load returns the built-in prices overridden by a JSON table at path
+// A missing file is not an error; entries in the file replace built-ins of the same name
+func Load(path string) (Table, error) {
+	table := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return table, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing %s: %w", path, err)
+	}
+
+	var overrides Table
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing %s: %w", path, err)
+	}
+	for name, price := range overrides {
+		table[strings.ToLower(name)] = price
+	}
+	return table, nil
+}
+
+// Lookup returns the price of a model: its exact entry, else the longest
+// entry contained in its name
+func (t Table) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return Price{}, false
+	}
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+
+	best := ""
+	for name := range t {
+		if strings.Contains(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
+
+// Cost returns the USD cost of a run's token usage
+func (p Price) Cost(u *models.TokenUsage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreationTokens)*p.CacheWrite +
+		float64(u.CacheReadTokens)*p.CacheRead) / 1e6
+}
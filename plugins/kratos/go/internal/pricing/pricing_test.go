@@ -0,0 +1,61 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	table := Default()
+
+	price, ok := table.Lookup("Opus")
+	require.True(t, ok)
+	assert.Equal(t, float64(75), price.Output)
+
+	// A full model ID matches its family unless a more specific entry exists
+	price, ok = table.Lookup("claude-opus-4-1-20250805")
+	require.True(t, ok)
+	assert.Equal(t, float64(15), price.Input)
+	price, ok = table.Lookup("claude-opus-4-5-20251101")
+	require.True(t, ok)
+	assert.Equal(t, float64(5), price.Input)
+
+	_, ok = table.Lookup("gpt-4")
+	assert.False(t, ok)
+	_, ok = table.Lookup("")
+	assert.False(t, ok)
+}
+
+func TestCost(t *testing.T) {
+	price := Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	usage := &models.TokenUsage{InputTokens: 1000000, OutputTokens: 100000, CacheCreationTokens: 200000, CacheReadTokens: 1000000}
+	assert.InDelta(t, 3+1.5+0.75+0.30, price.Cost(usage), 1e-9)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	table, err := Load(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, Default(), table)
+
+	path := filepath.Join(dir, "pricing.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Sonnet": {"input": 2, "output": 10}, "local-llm": {}}`), 0644))
+	table, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Price{Input: 2, Output: 10}, table["sonnet"])
+	assert.Contains(t, table, "local-llm")
+	assert.Contains(t, table, "opus")
+
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0644))
+	_, err = Load(path)
+	assert.Error(t, err)
+
+	t.Setenv("KRATOS_PRICING", path)
+	assert.Equal(t, path, Path())
+}
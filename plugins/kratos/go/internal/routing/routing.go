@@ -0,0 +1,247 @@
+// Package routing matches prompts against the keyword rules that route them to Kratos
+package routing
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/LizardLiang/lizard-market/plugins/kratos/internal/layered"
+)
+
+// Kinds of match, in the order a rule is tried
+const (
+	KindKeyword = "keyword"
+	KindAlias   = "alias"
+	KindPhrase  = "phrase"
+)
+
+// defaultConfig is the built-in rule set
+//
+//go:embed default.json
+var defaultConfig []byte
+
+// Config is a layer of routing rules
+type Config struct {
+	// Inject is the context injected for matched rules without their own text
+	Inject string `json:"inject,omitempty" yaml:"inject,omitempty"`
+	// Exclude holds regexes removed from every prompt before matching
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	// ContextBudget is the token budget of the project state injected with a
+	// matched prompt; negative turns it off
+	ContextBudget int     `json:"context_budget,omitempty" yaml:"context_budget,omitempty"`
+	Rules         []*Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule routes prompts that mention its keyword, an alias or an intent phrase
+type Rule struct {
+	Name     string   `json:"name" yaml:"name"`                           // keyword, matched as a whole word
+	Aliases  []string `json:"aliases,omitempty" yaml:"aliases,omitempty"` // other whole words
+	Phrases  []string `json:"phrases,omitempty" yaml:"phrases,omitempty"` // intent phrases such as "review this"
+	Exclude  []string `json:"exclude,omitempty" yaml:"exclude,omitempty"` // regexes removed before matching this rule
+	Inject   string   `json:"inject,omitempty" yaml:"inject,omitempty"`   // context replacing the default injection
+	Disabled bool     `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+
+	// Source is "built-in" or the file the rule was read from
+	Source string `json:"source" yaml:"-"`
+}
+
+// Match is a rule that matched a prompt
+type Match struct {
+	Rule    string `json:"rule"`
+	Kind    string `json:"kind"`    // keyword, alias or phrase
+	Matched string `json:"matched"` // the prompt text that matched
+	Inject  string `json:"inject"`  // the rule's context, else the default
+	Source  string `json:"source"`
+}
+
+// Router matches prompts against compiled rules
+type Router struct {
+	inject  string
+	budget  int
+	exclude []*regexp.Regexp
+	rules   []*compiledRule
+}
+
+type compiledRule struct {
+	*Rule
+	exclude  []*regexp.Regexp
+	patterns []pattern
+}
+
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// UserPath returns the user's routing file
+// Checks KRATOS_ROUTING env var, defaults to ~/.kratos/routing.json
+func UserPath() string {
+	if path := os.Getenv("KRATOS_ROUTING"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kratos", "routing.json")
+}
+
+// ProjectPaths returns the routing files a repository may hold, in load order
+func ProjectPaths(root string) []string {
+	return layered.ProjectPaths(root, "routing")
+}
+
+// Default returns the built-in rule set
+func Default() *Config {
+	cfg, err := Parse(defaultConfig, "default.json")
+	if err != nil {
+		panic(err) // the embedded file is covered by tests
+	}
+	for _, rule := range cfg.Rules {
+		rule.Source = "built-in"
+	}
+	return cfg
+}
+
+// Parse reads a routing layer, as YAML for .yaml/.yml paths and JSON otherwise
+func Parse(data []byte, path string) (*Config, error) {
+	cfg := &Config{}
+	if err := layered.Decode(data, path, "routing", cfg); err != nil {
+		return nil, err
+	}
+	for _, rule := range cfg.Rules {
+		rule.Name = strings.ToLower(strings.TrimSpace(rule.Name))
+		if rule.Name == "" {
+			return nil, fmt.Errorf("invalid routing %s: rule without a name", path)
+		}
+		rule.Source = path
+	}
+	return cfg, nil
+}
+
+// Load compiles the built-in rules overridden by the layers at paths
+func Load(paths ...string) (*Router, error) {
+	cfg, err := LoadConfig(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(cfg)
+}
+
+// LoadConfig returns the built-in rules overridden by the layers at paths, in order
+// Missing files are skipped. A rule replaces the earlier rule of the same name
+// (disabled: true drops it), excludes accumulate and a non-empty inject or
+// non-zero context budget wins
+func LoadConfig(paths ...string) (*Config, error) {
+	return layered.Load(Default(), paths, "routing", Parse, merge)
+}
+
+// merge applies layer on top of base
+func merge(base, layer *Config) *Config {
+	out := &Config{
+		Inject:        base.Inject,
+		Exclude:       append(append([]string{}, base.Exclude...), layer.Exclude...),
+		ContextBudget: base.ContextBudget,
+	}
+	if layer.Inject != "" {
+		out.Inject = layer.Inject
+	}
+	if layer.ContextBudget != 0 {
+		out.ContextBudget = layer.ContextBudget
+	}
+
+	out.Rules = layered.MergeByName(base.Rules, layer.Rules, func(rule *Rule) string { return rule.Name })
+	return out
+}
+
+// Compile builds a router, compiling every pattern once
+func Compile(cfg *Config) (*Router, error) {
+	r := &Router{inject: cfg.Inject, budget: cfg.ContextBudget}
+	var err error
+	if r.exclude, err = compileExcludes(cfg.Exclude); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range cfg.Rules {
+		if rule.Disabled {
+			continue
+		}
+		c := &compiledRule{Rule: rule}
+		if c.exclude, err = compileExcludes(rule.Exclude); err != nil {
+			return nil, fmt.Errorf("rule %s (%s): %w", rule.Name, rule.Source, err)
+		}
+		c.patterns = append(c.patterns, pattern{KindKeyword, wordPattern(rule.Name)})
+		for _, alias := range rule.Aliases {
+			c.patterns = append(c.patterns, pattern{KindAlias, wordPattern(alias)})
+		}
+		for _, phrase := range rule.Phrases {
+			c.patterns = append(c.patterns, pattern{KindPhrase, wordPattern(phrase)})
+		}
+		r.rules = append(r.rules, c)
+	}
+	return r, nil
+}
+
+// wordPattern matches text case-insensitively as whole words, across any whitespace
+func wordPattern(text string) *regexp.Regexp {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`(?i)\b` + strings.Join(words, `\s+`) + `\b`)
+}
+
+// compileExcludes compiles negative patterns, case-insensitively
+func compileExcludes(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// ContextBudget returns the token budget of the injected project state, 0 if off
+func (r *Router) ContextBudget() int {
+	return max(r.budget, 0)
+}
+
+// Match returns the rules matching text, in rule order, with the first pattern
+// of each rule that matched (keyword, then aliases, then phrases)
+func (r *Router) Match(text string) []Match {
+	text = strip(text, r.exclude)
+
+	var matches []Match
+	for _, rule := range r.rules {
+		ruleText := strip(text, rule.exclude)
+		for _, p := range rule.patterns {
+			if found := p.re.FindString(ruleText); found != "" {
+				inject := rule.Inject
+				if inject == "" {
+					inject = r.inject
+				}
+				matches = append(matches, Match{
+					Rule:    rule.Name,
+					Kind:    p.kind,
+					Matched: found,
+					Inject:  inject,
+					Source:  rule.Source,
+				})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// strip blanks out every match of the patterns
+func strip(text string, patterns []*regexp.Regexp) string {
+	for _, re := range patterns {
+		text = re.ReplaceAllString(text, " ")
+	}
+	return text
+}
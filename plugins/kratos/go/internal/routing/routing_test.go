@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleNames(matches []Match) []string {
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Rule)
+	}
+	return names
+}
+
+func TestDefaultRouter(t *testing.T) {
+	router, err := Compile(Default())
+	require.NoError(t, err)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"hey Kratos build this", []string{"kratos"}},
+		{"Kratos, have Ares implement and Hermes review", []string{"kratos", "ares", "hermes"}},
+		{"ask hera, themis and prometheus", []string{"hera", "themis", "prometheus"}},
+		{"the kratosConfig variable", nil},
+		{"can you review this before I merge", nil},
+		{"a walk through the ares of the garden", nil},
+		{"ares of the garden, ask Ares to fix it", []string{"ares"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, ruleNames(router.Match(tt.text)))
+		})
+	}
+
+	matches := router.Match("please ask HERMES")
+	require.Len(t, matches, 1)
+	assert.Equal(t, KindKeyword, matches[0].Kind)
+	assert.Equal(t, "HERMES", matches[0].Matched)
+	assert.Equal(t, "built-in", matches[0].Source)
+	assert.Contains(t, matches[0].Inject, "kratos:auto")
+	assert.Equal(t, 300, router.ContextBudget())
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "routing.json")
+	project := filepath.Join(dir, "routing.yaml")
+	require.NoError(t, os.WriteFile(user, []byte(`{
+  "exclude": ["\\bkratos\\s+cosplay\\b"],
+  "context_budget": -1,
+  "rules": [
+    {"name": "Hermes", "aliases": ["reviewer"]},
+    {"name": "mimir", "disabled": true}
+  ]
+}`), 0644))
+	require.NoError(t, os.WriteFile(project, []byte(`
+inject: Use the Kratos pipeline.
+rules:
+  - name: hermes
+    phrases: [look over my diff]
+    inject: Ask Hermes for a code review.
+  - name: ship it
+    aliases: [release]
+`), 0644))
+
+	router, err := Load(user, filepath.Join(dir, "missing.json"), project)
+	require.NoError(t, err)
+
+	matches := router.Match("Kratos, look over my diff, then ask Mimir to release")
+	assert.Equal(t, []string{"kratos", "hermes", "ship it"}, ruleNames(matches))
+	assert.Equal(t, "Use the Kratos pipeline.", matches[0].Inject)
+	assert.Equal(t, "Ask Hermes for a code review.", matches[1].Inject)
+	assert.Equal(t, project, matches[1].Source)
+	assert.Equal(t, KindAlias, matches[2].Kind)
+	// Phrases opted into by config match across whitespace
+	assert.Equal(t, []string{"hermes"}, ruleNames(router.Match("please Look over\nmy diff")))
+
+	// The project's hermes replaced the user's, aliases included
+	assert.Empty(t, router.Match("ask the reviewer"))
+	// A negative budget turns the project state off
+	assert.Equal(t, 0, router.ContextBudget())
+	// Global excludes accumulate over layers
+	assert.Empty(t, router.Match("my Kratos cosplay"))
+
+	cfg, err := LoadConfig(user, project)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Rules, len(Default().Rules)+1)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "routing.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"name": "ares", "exclude": ["("]}]}`), 0644))
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "rule ares")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"aliases": ["x"]}]}`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "rule without a name")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "failed to parse routing")
+}
@@ -22,6 +22,7 @@ const CLAUDE_DIR = path.join(HOME, '.claude');
 const HOOKS_DIR = path.join(CLAUDE_DIR, 'hooks', 'kratos');
 const SETTINGS_FILE = path.join(CLAUDE_DIR, 'settings.json');
 const KRATOS_HOME = path.join(HOME, '.kratos');
+const KRATOS_BIN = path.join(KRATOS_HOME, 'bin', process.platform === 'win32' ? 'kratos.exe' : 'kratos').replace(/\\/g, '/');
 
 // Source paths (relative to this script)
 const SOURCE_DIR = __dirname;
@@ -29,9 +30,6 @@ const SOURCE_MEMORY_DIR = path.join(SOURCE_DIR, '..', 'memory');
 
 // Files to copy
 const HOOK_FILES = [
-  'session-start.cjs',
-  'session-end.cjs',
-  'tool-use.cjs',
   'check-python.cjs'
 ];
 
@@ -80,12 +78,33 @@ function writeJson(filePath, data) {
   fs.writeFileSync(filePath, JSON.stringify(data, null, 2));
 }
 
+/**
+ * Copy the plugin's kratos binary for this platform to ~/.kratos/bin
+ * (the SessionStart hook runs it from there)
+ */
+function installBinary() {
+  const isWin = process.platform === 'win32';
+  const arch = os.arch() === 'arm64' ? 'arm64' : 'amd64';
+  const candidates = isWin
+    ? ['kratos.exe']
+    : [`kratos-${process.platform === 'darwin' ? 'darwin' : 'linux'}-${arch}`, 'kratos'];
+
+  for (const name of candidates) {
+    const src = path.join(SOURCE_DIR, '..', 'bin', name);
+    if (fs.existsSync(src)) {
+      ensureDir(path.dirname(KRATOS_BIN));
+      copyFile(src, KRATOS_BIN);
+      if (!isWin) fs.chmodSync(KRATOS_BIN, 0o755);
+      return true;
+    }
+  }
+  return false;
+}
+
 /**
  * Generate hook configuration for settings.json
  */
 function generateHookConfig() {
-  const hookPath = HOOKS_DIR.replace(/\\/g, '/');  // Normalize for JSON
-  
   return {
     "SessionStart": [
       {
@@ -93,7 +112,7 @@ function generateHookConfig() {
         "hooks": [
           {
             "type": "command",
-            "command": `node "${hookPath}/session-start.cjs"`,
+            "command": `"${KRATOS_BIN}" hook dispatch`,
             "timeout": 5000
           }
         ]
@@ -101,23 +120,23 @@ function generateHookConfig() {
     ],
     "PostToolUse": [
       {
-        "matcher": "Task|Write|Edit",
+        "matcher": "Task|Write|Edit|MultiEdit",
         "hooks": [
           {
             "type": "command",
-            "command": `node "${hookPath}/tool-use.cjs"`,
+            "command": `"${KRATOS_BIN}" hook dispatch`,
             "timeout": 5000
           }
         ]
       }
     ],
-    "Stop": [
+    "SessionEnd": [
       {
         "matcher": "",
         "hooks": [
           {
             "type": "command",
-            "command": `node "${hookPath}/session-end.cjs"`,
+            "command": `"${KRATOS_BIN}" hook dispatch`,
             "timeout": 10000
           }
         ]
@@ -162,6 +181,14 @@ function install() {
     }
   }
   
+  // Copy kratos binary
+  console.log('Copying kratos binary...');
+  if (installBinary()) {
+    console.log(`  - ${KRATOS_BIN}`);
+  } else {
+    console.log('  - kratos binary not found, skipping (build it: cd go && make build)');
+  }
+
   // Copy memory files
   console.log('Copying memory system files...');
   for (const file of MEMORY_FILES) {
@@ -185,6 +212,16 @@ function install() {
   }
   
   const kratosHooks = generateHookConfig();
+
+  // Earlier installs ended the session from Stop, which fires every turn
+  if (settings.hooks.Stop) {
+    settings.hooks.Stop = settings.hooks.Stop.filter(
+      h => !h.hooks?.some(hh => hh.command?.includes('kratos'))
+    );
+    if (settings.hooks.Stop.length === 0) {
+      delete settings.hooks.Stop;
+    }
+  }
   
   // For each hook type, add or replace kratos hooks
   for (const [hookType, hookConfigs] of Object.entries(kratosHooks)) {